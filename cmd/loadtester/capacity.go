@@ -0,0 +1,94 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"math"
+	"os"
+	"time"
+
+	application "github.com/go-squad-5/quiz-load-test/internal/app"
+	"github.com/go-squad-5/quiz-load-test/internal/capacity"
+	"github.com/go-squad-5/quiz-load-test/internal/thresholds"
+)
+
+const defaultSLO = "p95<500ms,error_rate<1%"
+
+// runCapacity runs the simulation with increasing load until the slo is violated,
+// it exits with 1 when even the lowest load violates the slo
+func runCapacity(args []string) int {
+	flags := flag.NewFlagSet("capacity", flag.ContinueOnError)
+	mode := flags.String("mode", "users", "load to increase, users (concurrent users) or rate (users started per second)")
+	strategy := flags.String("strategy", capacity.StrategyStep, "search strategy, step or binary")
+	start := flags.Int("start", 10, "first load to try")
+	step := flags.Int("step", 10, "load increase of the step strategy")
+	maxLoad := flags.Int("max", 1000, "highest load to try")
+	precision := flags.Int("precision", 5, "precision of the binary strategy")
+	duration := flags.Duration("duration", 30*time.Second, "duration of every trial of the rate mode")
+	cooldown := flags.Duration("cooldown", 5*time.Second, "pause between two trials, for the server to recover")
+	slo := flags.String("slo", defaultSLO, "thresholds every trial must pass, e.g. p95<500ms,error_rate<1%")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: loadtester capacity [flags]")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if *mode != "users" && *mode != "rate" {
+		fmt.Fprintf(os.Stderr, "invalid mode %q, expected users or rate\n", *mode)
+		return 2
+	}
+	sloThresholds, err := thresholds.Parse(*slo)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+
+	cfg := application.LoadConfig()
+	search := capacity.Search{Strategy: *strategy, Start: *start, Step: *step, Max: *maxLoad, Precision: *precision}
+	trials := 0
+	result, err := search.Run(func(load int) (capacity.Trial, error) {
+		if trials > 0 {
+			time.Sleep(*cooldown)
+		}
+		trials++
+
+		trialCfg := *cfg
+		trialCfg.RunID = fmt.Sprintf("%s-%s-%d", cfg.RunID, *mode, load)
+		// every trial keeps its logs and csv files
+		trialCfg.OutputDir = cfg.OutputDirOf(trialCfg.RunID)
+		trialCfg.NumUsers = load
+		trialCfg.ArrivalRate = 0
+		if *mode == "rate" {
+			trialCfg.ArrivalRate = float64(load)
+			trialCfg.NumUsers = int(math.Ceil(float64(load) * duration.Seconds()))
+		}
+
+		app := application.NewAppWithConfig(&trialCfg)
+		app.Run()
+		summary := app.Summary()
+		checks := thresholds.Evaluate(sloThresholds, summary)
+		// an aborted trial didn't run at the full load
+		passed := thresholds.AllPassed(checks) && summary.AbortReason == ""
+		app.ResultLogger.Println("Capacity trial with", load, *mode, "passed:", passed)
+		return capacity.Trial{Passed: passed, Checks: checks, Summary: summary}, nil
+	})
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+
+	unit := "users"
+	if *mode == "rate" {
+		unit = "users/s"
+	}
+	fmt.Printf("\nCapacity search for %s\n", *slo)
+	if err := result.Write(os.Stdout, unit); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+	if result.MaxSustainable == 0 {
+		return 1
+	}
+	return 0
+}
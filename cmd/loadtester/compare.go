@@ -0,0 +1,73 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/go-squad-5/quiz-load-test/internal/compare"
+)
+
+// ratioFlag is a flag accepting a fraction or a percentage
+type ratioFlag struct {
+	value *float64
+}
+
+func (f ratioFlag) String() string {
+	if f.value == nil {
+		return ""
+	}
+	return strconv.FormatFloat(*f.value, 'f', -1, 64)
+}
+
+func (f ratioFlag) Set(value string) error {
+	ratio, err := compare.ParseRatio(value)
+	if err != nil {
+		return err
+	}
+	*f.value = ratio
+	return nil
+}
+
+// runCompare compares two run summaries, it exits with 1 when the current run regressed
+func runCompare(args []string) int {
+	tolerances := compare.DefaultTolerances
+	flags := flag.NewFlagSet("compare", flag.ContinueOnError)
+	flags.Var(ratioFlag{&tolerances.Latency}, "latency-tolerance", "allowed relative increase of the latency, e.g. 10%")
+	flags.Var(ratioFlag{&tolerances.Throughput}, "throughput-tolerance", "allowed relative decrease of the requests per second, e.g. 10%")
+	flags.Var(ratioFlag{&tolerances.ErrorRate}, "error-rate-tolerance", "allowed absolute increase of the error rate, e.g. 1%")
+	flags.Var(ratioFlag{&tolerances.Alpha}, "alpha", "significance level of the statistical tests")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: loadtester compare [flags] <baseline.json> <current.json>")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if flags.NArg() != 2 {
+		flags.Usage()
+		return 2
+	}
+
+	baseline, err := compare.ReadSummary(flags.Arg(0))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+	current, err := compare.ReadSummary(flags.Arg(1))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+
+	result := compare.Compare(baseline, current, tolerances)
+	if err := result.Write(os.Stdout); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+	if result.HasRegression() {
+		return 1
+	}
+	return 0
+}
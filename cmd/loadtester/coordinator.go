@@ -0,0 +1,54 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	application "github.com/go-squad-5/quiz-load-test/internal/app"
+	"github.com/go-squad-5/quiz-load-test/internal/distributed"
+)
+
+// runCoordinator splits the configured load across the workers, and writes the reports of the whole run
+// once every worker is done, it exits with 2 when the run can't complete on every worker
+func runCoordinator(args []string) int {
+	flags := flag.NewFlagSet("coordinator", flag.ContinueOnError)
+	workers := flags.String("workers", "", "comma separated addresses of the workers, e.g. localhost:7071,localhost:7072")
+	startDelay := flags.Duration("start-delay", 2*time.Second, "time given to the workers to receive their job, they start their users together once it's over")
+	jobMargin := flags.Duration("job-margin", distributed.DefaultJobMargin, "time given to the sessions of the workers to end after their last user started, a worker answering later fails the run")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: loadtester coordinator -workers <addresses> [flags]")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if strings.TrimSpace(*workers) == "" {
+		fmt.Fprintln(os.Stderr, "no workers, set the -workers flag")
+		return 2
+	}
+
+	app := application.NewApp()
+	coordinator := distributed.NewCoordinator(strings.Split(*workers, ","))
+	coordinator.Margin = *jobMargin
+	job := app.DistributedJob(time.Now().Add(*startDelay))
+	app.Logger.Info("Starting distributed run", "run_id", job.RunID, "workers", len(coordinator.Workers))
+	results, err := coordinator.Run(context.Background(), job)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "distributed run failed:", err)
+		return 2
+	}
+	app.MergeWorkerResults(results)
+
+	app.ResultLogger.Println(
+		"Total time taken to complete all sessions on the workers: ",
+		app.FinishedAt.Sub(app.StartedAt).Seconds(),
+		" seconds",
+	)
+	summary := app.Summary()
+	summary.Config["WORKERS"] = strings.Join(coordinator.Workers, ",")
+	return reportRun(app, summary)
+}
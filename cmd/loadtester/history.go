@@ -0,0 +1,50 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/go-squad-5/quiz-load-test/internal/history"
+)
+
+// runHistory lists the saved runs, with the trends of their key metrics
+func runHistory(args []string) int {
+	historyDir := os.Getenv("HISTORY_DIR")
+	if historyDir == "" {
+		historyDir = "./tmp/runs"
+	}
+
+	flags := flag.NewFlagSet("history", flag.ContinueOnError)
+	dir := flags.String("dir", historyDir, "directory of the saved runs")
+	endpoint := flags.String("endpoint", history.AllEndpoints, "endpoint of the latency and throughput metrics")
+	labels := flags.String("labels", "", "only list the runs with all these labels, e.g. env=staging")
+	last := flags.Int("n", 20, "number of most recent runs to list, 0 for all")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: loadtester history [flags]")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	filter, err := history.ParseLabels(*labels)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+	runs, err := history.NewStore(*dir).List(filter)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	if *last > 0 && len(runs) > *last {
+		runs = runs[len(runs)-*last:]
+	}
+
+	if err := history.Write(os.Stdout, runs, *endpoint); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	return 0
+}
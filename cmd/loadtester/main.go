@@ -1,45 +1,46 @@
 package main
 
 import (
+	"fmt"
+	"os"
 	"runtime"
-	"time"
-
-	application "github.com/go-squad-5/quiz-load-test/internal/app"
 )
 
+const usage = `Usage:
+  loadtester [run]                                   run the load test configured by the environment
+  loadtester compare <baseline.json> <current.json>  compare two run summaries, see compare -h
+  loadtester history                                 list the saved runs and their trends, see history -h
+  loadtester capacity                                search the max load meeting an slo, see capacity -h
+  loadtester worker                                  run the jobs of a coordinator, see worker -h
+  loadtester coordinator -workers <addresses>        split the load test across workers, see coordinator -h
+`
+
 func main() {
 	// set the number of os threads to use for the simulation
 	runtime.GOMAXPROCS(runtime.NumCPU() - 1)
 
-	startTime := time.Now()
-
-	app := application.NewApp()
-
-	app.ErrorListener.Add(1)
-	app.InfoLogger.Println("GO ROUTINE STARTED for listening to errors")
-	go app.ListenForErrors()
-
-	app.ResultListener.Add(1)
-	app.InfoLogger.Println("GO ROUTINE STARTED for listening to results")
-	go app.ListenForResults()
-
-	app.InfoLogger.Println("Starting simulation with", app.Config.NumUsers, "users")
-	app.StartSimulation()
-
-	app.Wait.Wait()
-	elapsed := time.Since(startTime)
-
-	app.Stop()
-	elapsed2 := time.Since(startTime)
-
-	app.ResultLogger.Println(
-		"Total time taken to complete all sessions concurrently: ",
-		elapsed.Seconds(),
-		" seconds",
-	)
-	app.ResultLogger.Println(
-		"Total time taken by test: ",
-		elapsed2.Seconds(),
-		" seconds",
-	)
+	command := "run"
+	if len(os.Args) > 1 {
+		command = os.Args[1]
+	}
+
+	switch command {
+	case "run":
+		os.Exit(run())
+	case "compare":
+		os.Exit(runCompare(os.Args[2:]))
+	case "history":
+		os.Exit(runHistory(os.Args[2:]))
+	case "capacity":
+		os.Exit(runCapacity(os.Args[2:]))
+	case "worker":
+		os.Exit(runWorker(os.Args[2:]))
+	case "coordinator":
+		os.Exit(runCoordinator(os.Args[2:]))
+	case "help", "-h", "--help":
+		fmt.Print(usage)
+	default:
+		fmt.Fprintf(os.Stderr, "unknown command %q\n%s", command, usage)
+		os.Exit(2)
+	}
 }
@@ -0,0 +1,144 @@
+package main
+
+import (
+	"os"
+	"strings"
+	"time"
+
+	application "github.com/go-squad-5/quiz-load-test/internal/app"
+	"github.com/go-squad-5/quiz-load-test/internal/metrics"
+	"github.com/go-squad-5/quiz-load-test/internal/thresholds"
+)
+
+// run simulates the configured users and writes the run reports, it returns the exit code
+func run() int {
+	startTime := time.Now()
+
+	app := application.NewApp()
+
+	if err := app.OpenRequestsCSV(); err != nil {
+		app.Logger.Error("Failed to create the requests csv file", "error", err)
+	}
+
+	if app.Config.DashboardAddr != "" {
+		if err := app.StartDashboard(); err != nil {
+			app.Logger.Error("Failed to start the dashboard", "error", err)
+		}
+	}
+	if app.Config.MetricsAddr != "" {
+		if err := app.StartMetricsServer(); err != nil {
+			app.Logger.Error("Failed to start the metrics server", "error", err)
+		}
+	}
+
+	if app.Config.SMTPSinkAddr != "" {
+		if err := app.StartEmailSink(); err != nil {
+			app.Logger.Error("Failed to start the smtp sink", "error", err)
+		}
+	}
+
+	app.Run()
+	elapsed := app.FinishedAt.Sub(startTime)
+	if app.EmailSink != nil {
+		app.WaitForEmails()
+	}
+	elapsed2 := time.Since(startTime)
+
+	app.ResultLogger.Println(
+		"Total time taken to complete all sessions concurrently: ",
+		elapsed.Seconds(),
+		" seconds",
+	)
+	app.ResultLogger.Println(
+		"Total time taken by test: ",
+		elapsed2.Seconds(),
+		" seconds",
+	)
+
+	return reportRun(app, app.Summary())
+}
+
+// reportRun writes the reports of the run and prints its results, it returns the exit code
+func reportRun(app *application.App, summary *metrics.Summary) int {
+	if filePath, err := app.WriteHTMLReport(summary); err != nil {
+		app.Logger.Error("Failed to write the html report", "error", err)
+	} else {
+		app.Logger.Info("HTML report written", "file", filePath)
+	}
+
+	if filePath, err := app.WriteSummaryJSON(summary); err != nil {
+		app.Logger.Error("Failed to write the run summary", "error", err)
+	} else {
+		app.Logger.Info("Run summary written", "file", filePath)
+	}
+
+	if filePath, err := app.WriteTimeSeriesCSV(summary); err != nil {
+		app.Logger.Error("Failed to write the time series", "error", err)
+	} else {
+		app.Logger.Info("Time series written", "file", filePath)
+	}
+
+	checks := app.CheckThresholds(summary)
+	if app.Config.JUnitFile != "" {
+		if err := app.WriteJUnitReport(summary, checks); err != nil {
+			app.Logger.Error("Failed to write the junit report", "error", err)
+		} else {
+			app.Logger.Info("JUnit report written", "file", app.Config.JUnitFile)
+		}
+	}
+
+	if runDir, err := app.SaveRun(summary); err != nil {
+		app.Logger.Error("Failed to save the run in the history", "error", err)
+	} else {
+		app.Logger.Info("Run saved", "dir", runDir)
+	}
+
+	regressed := false
+	if app.Config.BaselineFile != "" {
+		result, err := app.CompareWithBaseline(summary)
+		if err != nil {
+			app.Logger.Error("Failed to compare with the baseline", "error", err)
+		} else {
+			app.ResultLogger.Println("Comparison with the baseline", app.Config.BaselineFile)
+			if err := result.Write(os.Stdout); err != nil {
+				app.Logger.Error("Failed to print the comparison", "error", err)
+			}
+			regressed = result.HasRegression()
+		}
+	}
+
+	// both lists start with the quiz api endpoints, in the same order
+	for i, intended := range summary.IntendedEndpoints {
+		sent := summary.Endpoints[i]
+		app.ResultLogger.Printf("Latency of %s from the intended start: p50 %.1fms, p95 %.1fms, p99 %.1fms (from the send time: p50 %.1fms, p95 %.1fms, p99 %.1fms)\n",
+			intended.Endpoint, intended.P50, intended.P95, intended.P99, sent.P50, sent.P95, sent.P99)
+	}
+
+	for _, stage := range summary.Stages {
+		app.ResultLogger.Printf("Stage %s: %.1f seconds, %d requests, %.2f/s, %.2f%% errors\n",
+			stage.Name, stage.Seconds, stage.Requests, stage.RPS, stage.ErrorRate*100)
+	}
+
+	if delivery := summary.EmailDelivery; delivery != nil {
+		app.ResultLogger.Printf("Emails: %d expected, %d delivered, %d missing, %d unmatched, delivery latency p50 %.1fms, p95 %.1fms, max %.1fms\n",
+			delivery.Expected, delivery.Delivered, delivery.Missing, delivery.Unmatched, delivery.P50, delivery.P95, delivery.Max)
+		if delivery.Missing > 0 {
+			app.ResultLogger.Println("Emails missing for the sessions:", strings.Join(delivery.MissingSessions, ", "))
+		}
+	}
+
+	for _, scenario := range summary.NegativeScenarios {
+		app.ResultLogger.Printf("Negative scenario %s: %d runs, %d rejected as expected, %d failed, %d server errors, status codes %v\n",
+			scenario.Scenario, scenario.Runs, scenario.Passed, scenario.Failed, scenario.ServerErrors, scenario.StatusCodes)
+	}
+
+	if summary.AbortReason != "" {
+		app.ResultLogger.Println("Run aborted:", summary.AbortReason)
+	}
+
+	// fail the process so ci pipelines fail when a threshold is not met, the run was aborted or regressed
+	if !thresholds.AllPassed(checks) || summary.AbortReason != "" || regressed {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+
+	application "github.com/go-squad-5/quiz-load-test/internal/app"
+	"github.com/go-squad-5/quiz-load-test/internal/distributed"
+)
+
+// runWorker runs the jobs sent by a coordinator until the process is stopped,
+// the settings other than the load come from the environment of the worker
+func runWorker(args []string) int {
+	flags := flag.NewFlagSet("worker", flag.ContinueOnError)
+	addr := flags.String("addr", ":7071", "address the worker listens on for the jobs of the coordinator")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: loadtester worker [flags]")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	cfg := application.LoadConfig()
+	worker := distributed.NewWorker(func(ctx context.Context, job distributed.Job) (*distributed.Result, error) {
+		jobCfg, err := application.WorkerConfig(cfg, job)
+		if err != nil {
+			return nil, err
+		}
+		app := application.NewAppWithConfig(jobCfg)
+		app.Logger.Info("Running the job of the coordinator", "run_id", job.RunID, "worker", job.Worker+1, "workers", job.Workers, "start_at", job.StartAt)
+		// every worker starts its users at the same time, so the load of the run adds up from the start
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("the job was cancelled before it started: %w", ctx.Err())
+		case <-time.After(time.Until(job.StartAt)):
+		}
+		// the coordinator cancels the job when another worker fails or the job is over its deadline
+		stop := context.AfterFunc(ctx, func() {
+			app.Abort.Abort("the coordinator cancelled the job")
+		})
+		defer stop()
+		app.Run()
+		return app.WorkerResult(), nil
+	})
+
+	fmt.Println("Worker listening on", *addr)
+	if err := http.ListenAndServe(*addr, worker.Handler()); err != nil {
+		fmt.Fprintln(os.Stderr, "worker stopped:", err)
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,223 @@
+package abort
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/go-squad-5/quiz-load-test/internal/metrics"
+	"github.com/go-squad-5/quiz-load-test/internal/thresholds"
+)
+
+const connectionRefusedMetric = "connection_refused"
+
+var (
+	durationRegexp          = regexp.MustCompile(`^(.*?)\s+for\s+(\S+)$`)
+	connectionRefusedRegexp = regexp.MustCompile(`^connection_refused\s*(>=|>)\s*([0-9]+)$`)
+)
+
+// Condition aborts the run when a metric of the collector intervals meets the threshold
+// for the whole duration, e.g. "error_rate>20% for 30s"
+type Condition struct {
+	Expression string
+	Threshold  thresholds.Threshold
+	For        time.Duration
+}
+
+// Rules are the conditions which end the run early
+type Rules struct {
+	Conditions []Condition
+	// aborts the run after this many consecutive refused connections, 0 disables the rule
+	ConnectionRefused int
+}
+
+func (r Rules) Empty() bool {
+	return len(r.Conditions) == 0 && r.ConnectionRefused == 0
+}
+
+func (r Rules) String() string {
+	expressions := []string{}
+	for _, condition := range r.Conditions {
+		expressions = append(expressions, condition.Expression)
+	}
+	if r.ConnectionRefused > 0 {
+		expressions = append(expressions, fmt.Sprintf("%s>=%d", connectionRefusedMetric, r.ConnectionRefused))
+	}
+	return strings.Join(expressions, ",")
+}
+
+// Parse parses comma separated abort rules, e.g. "error_rate>20% for 30s,p99>10s,connection_refused>50"
+func Parse(expressions string) (Rules, error) {
+	rules := Rules{Conditions: []Condition{}}
+	for _, expression := range strings.Split(expressions, ",") {
+		expression = strings.TrimSpace(expression)
+		if expression == "" {
+			continue
+		}
+
+		if matches := connectionRefusedRegexp.FindStringSubmatch(expression); matches != nil {
+			count, err := strconv.Atoi(matches[2])
+			if err != nil {
+				return Rules{}, fmt.Errorf("invalid abort rule %q: %w", expression, err)
+			}
+			if matches[1] == ">" {
+				count++
+			}
+			if count <= 0 {
+				return Rules{}, fmt.Errorf("invalid abort rule %q, the count must be positive", expression)
+			}
+			rules.ConnectionRefused = count
+			continue
+		}
+
+		condition := Condition{Expression: expression}
+		thresholdExpression := expression
+		if matches := durationRegexp.FindStringSubmatch(expression); matches != nil {
+			duration, err := time.ParseDuration(matches[2])
+			if err != nil {
+				return Rules{}, fmt.Errorf("invalid abort rule %q: %w", expression, err)
+			}
+			thresholdExpression = matches[1]
+			condition.For = duration
+		}
+		threshold, err := thresholds.ParseThreshold(thresholdExpression)
+		if err != nil {
+			return Rules{}, fmt.Errorf("invalid abort rule %q: %w", expression, err)
+		}
+		if threshold.Metric == "session_failure_rate" || threshold.Metric == "failed_sessions" {
+			return Rules{}, fmt.Errorf("invalid abort rule %q, %s is only known at the end of the run", expression, threshold.Metric)
+		}
+		condition.Threshold = threshold
+		rules.Conditions = append(rules.Conditions, condition)
+	}
+	return rules, nil
+}
+
+// IsConnectionRefused reports whether the request failed because the server refused the connection
+func IsConnectionRefused(err error) bool {
+	return errors.Is(err, syscall.ECONNREFUSED)
+}
+
+// Monitor evaluates the rules against every collector interval and every request,
+// and closes its Aborted channel when a rule is met
+type Monitor struct {
+	mu      sync.Mutex
+	rules   Rules
+	since   []time.Time
+	refused int
+	reason  string
+	aborted chan struct{}
+}
+
+func NewMonitor(rules Rules) *Monitor {
+	return &Monitor{
+		rules:   rules,
+		since:   make([]time.Time, len(rules.Conditions)),
+		aborted: make(chan struct{}),
+	}
+}
+
+// ObserveSnapshot evaluates the conditions against the metrics of an interval
+func (m *Monitor) ObserveSnapshot(snapshot metrics.Snapshot) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	summary := &metrics.Summary{
+		Seconds:   snapshot.Seconds,
+		Requests:  snapshot.Requests,
+		Errors:    snapshot.Errors,
+		RPS:       snapshot.RPS,
+		Endpoints: snapshot.Endpoints,
+	}
+	if snapshot.Requests > 0 {
+		summary.ErrorRate = float64(snapshot.Errors) / float64(snapshot.Requests)
+	}
+	windowStart := snapshot.Time.Add(-time.Duration(snapshot.Seconds * float64(time.Second)))
+
+	for i, condition := range m.rules.Conditions {
+		// an interval without requests tells nothing of the error rate or the latency, e.g. while the requests
+		// to a stalled server hang until their timeout, so the condition holds as long as it did before
+		if !measured(condition.Threshold, snapshot) {
+			continue
+		}
+		actual, err := condition.Threshold.Actual(summary)
+		if err != nil || !condition.Threshold.Passes(actual) {
+			m.since[i] = time.Time{}
+			continue
+		}
+		if m.since[i].IsZero() {
+			m.since[i] = windowStart
+		}
+		if snapshot.Time.Sub(m.since[i]) >= condition.For {
+			m.abort(fmt.Sprintf("%s, actual %s", condition.Expression, condition.Threshold.FormatValue(actual)))
+		}
+	}
+}
+
+// measured reports whether the interval has requests to measure the metric of the threshold on,
+// the request count and the throughput are measured even without requests
+func measured(threshold thresholds.Threshold, snapshot metrics.Snapshot) bool {
+	if threshold.Metric == "requests" || threshold.Metric == "rps" {
+		return true
+	}
+	if threshold.Endpoint == "" {
+		return snapshot.Requests > 0
+	}
+	for _, stats := range snapshot.Endpoints {
+		if stats.Endpoint == threshold.Endpoint {
+			return stats.Count > 0
+		}
+	}
+	return false
+}
+
+// ObserveRequest counts the consecutive refused connections
+func (m *Monitor) ObserveRequest(err error) {
+	if m.rules.ConnectionRefused == 0 {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if !IsConnectionRefused(err) {
+		m.refused = 0
+		return
+	}
+	m.refused++
+	if m.refused >= m.rules.ConnectionRefused {
+		m.abort(fmt.Sprintf("%d consecutive requests refused by the server, last error: %v", m.refused, err))
+	}
+}
+
+// Abort aborts the run for a reason other than the rules, e.g. a distributed run cancelled by its coordinator
+func (m *Monitor) Abort(reason string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.abort(reason)
+}
+
+// abort records the first reason, it should be called with the lock held
+func (m *Monitor) abort(reason string) {
+	if m.reason != "" {
+		return
+	}
+	m.reason = reason
+	close(m.aborted)
+}
+
+// Aborted is closed when a rule is met
+func (m *Monitor) Aborted() <-chan struct{} {
+	return m.aborted
+}
+
+// Reason returns the rule which aborted the run, empty if the run wasn't aborted
+func (m *Monitor) Reason() string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.reason
+}
@@ -0,0 +1,155 @@
+package abort
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/go-squad-5/quiz-load-test/internal/metrics"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func isAborted(m *Monitor) bool {
+	select {
+	case <-m.Aborted():
+		return true
+	default:
+		return false
+	}
+}
+
+func snapshot(at time.Time, requests, errors uint64, p99 float64) metrics.Snapshot {
+	return metrics.Snapshot{
+		Time:     at,
+		Seconds:  1,
+		Requests: requests,
+		Errors:   errors,
+		Endpoints: []metrics.EndpointStats{
+			{Endpoint: "start_quiz", Count: requests, Errors: errors, P99: p99},
+		},
+	}
+}
+
+func Test_abort_Parse(t *testing.T) {
+	rules, err := Parse("error_rate>20% for 30s, start_quiz.p99>10s,connection_refused>50")
+	require.NoError(t, err, "Expected valid rules")
+	require.Len(t, rules.Conditions, 2, "Expected the metric conditions")
+	assert.Equal(t, 30*time.Second, rules.Conditions[0].For, "Expected the duration of the condition")
+	assert.InDelta(t, 0.2, rules.Conditions[0].Threshold.Value, 1e-9, "Expected the threshold of the condition")
+	assert.Equal(t, "start_quiz", rules.Conditions[1].Threshold.Endpoint, "Expected the endpoint of the condition")
+	assert.Equal(t, time.Duration(0), rules.Conditions[1].For, "Expected no duration")
+	assert.Equal(t, 51, rules.ConnectionRefused, "Expected the consecutive refused connections")
+	assert.Equal(t, "error_rate>20% for 30s,start_quiz.p99>10s,connection_refused>=51", rules.String(), "Expected the rules as a string")
+	assert.False(t, rules.Empty(), "Expected rules")
+
+	rules, err = Parse("")
+	require.NoError(t, err, "Expected no error without rules")
+	assert.True(t, rules.Empty(), "Expected no rules")
+}
+
+func Test_abort_Parse_WhenInvalid(t *testing.T) {
+	for _, expression := range []string{"error_rate", "error_rate>20% for ever", "failed_sessions>10", "connection_refused>=0"} {
+		_, err := Parse(expression)
+		assert.Errorf(t, err, "Expected %q to be invalid", expression)
+	}
+}
+
+func Test_abort_Monitor_ObserveSnapshot_WithDuration(t *testing.T) {
+	rules, err := Parse("error_rate>20% for 3s")
+	require.NoError(t, err, "Expected valid rules")
+	m := NewMonitor(rules)
+	start := time.Now()
+
+	m.ObserveSnapshot(snapshot(start.Add(1*time.Second), 10, 5, 0))
+	m.ObserveSnapshot(snapshot(start.Add(2*time.Second), 10, 0, 0))
+	m.ObserveSnapshot(snapshot(start.Add(3*time.Second), 10, 5, 0))
+	m.ObserveSnapshot(snapshot(start.Add(4*time.Second), 10, 5, 0))
+	assert.False(t, isAborted(m), "Expected no abort before the condition holds for the duration")
+
+	m.ObserveSnapshot(snapshot(start.Add(5*time.Second), 10, 5, 0))
+	assert.True(t, isAborted(m), "Expected an abort once the condition held for the duration")
+	assert.Equal(t, "error_rate>20% for 3s, actual 50.00%", m.Reason(), "Expected the rule in the reason")
+}
+
+func Test_abort_Monitor_ObserveSnapshot_WhenNoRequests(t *testing.T) {
+	rules, err := Parse("error_rate>20% for 3s,start_quiz.p99>10s for 3s")
+	require.NoError(t, err, "Expected valid rules")
+	m := NewMonitor(rules)
+	start := time.Now()
+
+	// the requests hang on a stalled server, the intervals in between have no requests
+	m.ObserveSnapshot(snapshot(start.Add(1*time.Second), 10, 5, 0))
+	m.ObserveSnapshot(metrics.Snapshot{Time: start.Add(2 * time.Second), Seconds: 1})
+	m.ObserveSnapshot(metrics.Snapshot{Time: start.Add(3 * time.Second), Seconds: 1})
+	assert.False(t, isAborted(m), "Expected no abort before the condition holds for the duration")
+
+	m.ObserveSnapshot(snapshot(start.Add(4*time.Second), 10, 10, 0))
+	assert.True(t, isAborted(m), "Expected the intervals without requests not to reset the condition")
+	assert.Equal(t, "error_rate>20% for 3s, actual 100.00%", m.Reason(), "Expected the error rate rule in the reason")
+
+	// the throughput is measured without requests
+	rules, err = Parse("rps<1 for 2s")
+	require.NoError(t, err, "Expected valid rules")
+	m = NewMonitor(rules)
+	m.ObserveSnapshot(metrics.Snapshot{Time: start.Add(1 * time.Second), Seconds: 1})
+	m.ObserveSnapshot(metrics.Snapshot{Time: start.Add(2 * time.Second), Seconds: 1})
+	assert.True(t, isAborted(m), "Expected the throughput rule to hold without requests")
+	assert.Equal(t, "rps<1 for 2s, actual 0.00/s", m.Reason(), "Expected the throughput rule in the reason")
+}
+
+func Test_abort_Monitor_ObserveSnapshot_WithoutDuration(t *testing.T) {
+	rules, err := Parse("start_quiz.p99>10s,p99>20s")
+	require.NoError(t, err, "Expected valid rules")
+	m := NewMonitor(rules)
+
+	m.ObserveSnapshot(snapshot(time.Now(), 10, 0, 5000))
+	assert.False(t, isAborted(m), "Expected no abort below the threshold")
+
+	m.ObserveSnapshot(snapshot(time.Now(), 10, 0, 25000))
+	assert.True(t, isAborted(m), "Expected an abort on the first interval above the threshold")
+	assert.Equal(t, "start_quiz.p99>10s, actual 25000.0ms", m.Reason(), "Expected the first rule met in the reason")
+}
+
+func Test_abort_Monitor_ObserveRequest(t *testing.T) {
+	rules, err := Parse("connection_refused>=3")
+	require.NoError(t, err, "Expected valid rules")
+	m := NewMonitor(rules)
+
+	// a refused connection to a closed port
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err, "Expected to listen on a free port")
+	addr := listener.Addr().String()
+	listener.Close()
+	_, refused := net.Dial("tcp", addr)
+	require.True(t, IsConnectionRefused(fmt.Errorf("failed to create session: %w", refused)), "Expected a refused connection through wrapped errors")
+
+	m.ObserveRequest(refused)
+	m.ObserveRequest(refused)
+	m.ObserveRequest(errors.New("status code: 500"))
+	m.ObserveRequest(refused)
+	m.ObserveRequest(refused)
+	assert.False(t, isAborted(m), "Expected the count to reset on other responses")
+
+	m.ObserveRequest(refused)
+	assert.True(t, isAborted(m), "Expected an abort after consecutive refused connections")
+	assert.Contains(t, m.Reason(), "3 consecutive requests refused", "Expected the rule in the reason")
+}
+
+func Test_abort_Monitor_WhenNoRules(t *testing.T) {
+	m := NewMonitor(Rules{})
+	m.ObserveSnapshot(snapshot(time.Now(), 10, 10, 100000))
+	m.ObserveRequest(errors.New("status code: 500"))
+	assert.False(t, isAborted(m), "Expected no abort without rules")
+	assert.Empty(t, m.Reason(), "Expected no reason without abort")
+}
+
+func Test_abort_Monitor_Abort(t *testing.T) {
+	m := NewMonitor(Rules{})
+	m.Abort("cancelled")
+	m.Abort("cancelled again")
+	assert.True(t, isAborted(m), "Expected an abort without rules")
+	assert.Equal(t, "cancelled", m.Reason(), "Expected the first reason")
+}
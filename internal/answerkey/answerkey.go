@@ -0,0 +1,55 @@
+package answerkey
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/go-squad-5/quiz-load-test/internal/quizapi"
+)
+
+// Key is the correct answer of every question of the question bank, by question ID
+type Key map[string]string
+
+// Load reads an answer key file, either an object of answers by question ID, e.g. {"q1": "4"},
+// or a list of answers as submitted to the quiz api, e.g. [{"ques_id": "q1", "answer": "4"}]
+func Load(filePath string) (Key, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read answer key: %w", err)
+	}
+
+	key := Key{}
+	if err := json.Unmarshal(data, &key); err == nil {
+		return key, nil
+	}
+	answers := []quizapi.Answer{}
+	if err := json.Unmarshal(data, &answers); err != nil {
+		return nil, fmt.Errorf("failed to parse answer key, expected an object of answers by question ID or a list of answers: %w", err)
+	}
+	for _, answer := range answers {
+		if answer.QuestionID == "" {
+			return nil, fmt.Errorf("failed to parse answer key, answer %q without a question ID", answer.Answer)
+		}
+		key[answer.QuestionID] = answer.Answer
+	}
+	return key, nil
+}
+
+// Score returns the expected score of the answers, a point per correct answer.
+// The questions missing from the key are returned, the score can't be verified when any is missing
+func (k Key) Score(answers []quizapi.Answer) (int, []string) {
+	score := 0
+	missing := []string{}
+	for _, answer := range answers {
+		correct, ok := k[answer.QuestionID]
+		if !ok {
+			missing = append(missing, answer.QuestionID)
+			continue
+		}
+		if answer.Answer == correct {
+			score++
+		}
+	}
+	return score, missing
+}
@@ -0,0 +1,61 @@
+package answerkey
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-squad-5/quiz-load-test/internal/quizapi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeTestKey(t *testing.T, content string) string {
+	filePath := filepath.Join(t.TempDir(), "answers.json")
+	require.NoError(t, os.WriteFile(filePath, []byte(content), 0644), "Expected the answer key to be written")
+	return filePath
+}
+
+func Test_answerkey_Load(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+	}{
+		{"object", `{"q1": "4", "q2": "Paris"}`},
+		{"list", `[{"ques_id": "q1", "answer": "4"}, {"ques_id": "q2", "answer": "Paris"}]`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			key, err := Load(writeTestKey(t, tt.content))
+			require.NoError(t, err, "Expected the answer key to be loaded")
+			assert.Equal(t, Key{"q1": "4", "q2": "Paris"}, key, "Expected the answers by question ID")
+		})
+	}
+}
+
+func Test_answerkey_Load_WhenInvalid(t *testing.T) {
+	_, err := Load(writeTestKey(t, `{"q1": 4}`))
+	assert.Error(t, err, "Expected an error for an answer which is not a string")
+
+	_, err = Load(writeTestKey(t, `[{"answer": "4"}]`))
+	assert.ErrorContains(t, err, "without a question ID", "Expected an error for an answer without a question")
+
+	_, err = Load(filepath.Join(t.TempDir(), "missing.json"))
+	assert.ErrorContains(t, err, "failed to read answer key", "Expected an error for a missing file")
+}
+
+func Test_answerkey_Score(t *testing.T) {
+	key := Key{"q1": "4", "q2": "Paris", "q3": "Go"}
+
+	score, missing := key.Score([]quizapi.Answer{
+		{QuestionID: "q1", Answer: "4"},
+		{QuestionID: "q2", Answer: "London"},
+		{QuestionID: "q3", Answer: "Go"},
+	})
+	assert.Equal(t, 2, score, "Expected a point per correct answer")
+	assert.Empty(t, missing, "Expected every question in the key")
+
+	_, missing = key.Score([]quizapi.Answer{{QuestionID: "q1", Answer: "4"}, {QuestionID: "q9", Answer: "x"}})
+	assert.Equal(t, []string{"q9"}, missing, "Expected the questions missing from the key")
+}
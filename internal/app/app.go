@@ -1,11 +1,20 @@
 package app
 
 import (
+	"context"
 	"log"
+	"log/slog"
+	"net/http"
 	"os"
 	"sync"
+	"time"
 
+	"github.com/go-squad-5/quiz-load-test/internal/abort"
+	"github.com/go-squad-5/quiz-load-test/internal/capture"
+	"github.com/go-squad-5/quiz-load-test/internal/dashboard"
+	"github.com/go-squad-5/quiz-load-test/internal/metrics"
 	"github.com/go-squad-5/quiz-load-test/internal/quizapi"
+	"github.com/go-squad-5/quiz-load-test/internal/smtpsink"
 )
 
 type App struct {
@@ -16,46 +25,188 @@ type App struct {
 	Errors         chan error
 	ResultListener *sync.WaitGroup
 	ErrorListener  *sync.WaitGroup
-	InfoLogger     *log.Logger
-	ErrorLogger    *log.Logger
-	DebugLogger    *log.Logger
+	Logger         *slog.Logger
 	ResultLogger   *log.Logger
+	Metrics        *metrics.Collector
+	Prometheus     *metrics.PrometheusExporter
+	Dashboard      *dashboard.Server
+	MetricsServer  *http.Server
+	EmailSink      *smtpsink.Sink
+	SessionStats   *metrics.SessionStats
+	NegativeStats  *metrics.NegativeStats
+	Abort          *abort.Monitor
+	StartedAt      time.Time
+	FinishedAt     time.Time
+	// sends the requests misusing the api of the negative scenarios, observed apart from the normal traffic
+	NegativeQuizAPI quizapi.IQuizAPI
+	// sessions in progress by session ID, to label their requests
+	sessions    sync.Map
+	requestsCSV *csvFile
+	// source of the random answers, seeded with the configured seed
+	rand *lockedRand
+	// requests and responses of the sessions in progress, nil unless the capture is enabled
+	captures *capture.Recorder
+	// reason the first aborted worker of a distributed run gave, the coordinator doesn't run the users itself
+	workerAbortReason string
+	// durations of the steps of the scheduled sessions, their typical duration makes the schedule of the sessions
+	schedule stepDurations
 }
 
 func NewApp() *App {
-	cfg := LoadConfig()
+	return NewAppWithConfig(LoadConfig())
+}
 
+// NewAppWithConfig creates an app for the given configuration, to run several simulations in a process
+func NewAppWithConfig(cfg *Config) *App {
 	quizApi := quizapi.NewQuizAPI(
 		cfg.BaseURL,
 		cfg.ReportServerBaseURL,
 	)
 
 	// create loggers
-	infoLog := log.New(os.Stdout, "INFO\t", log.Ltime)
-	errorLog := log.New(os.Stdout, "ERROR\t", log.Ltime)
-	debugLog := log.New(os.Stdout, "DEBUG\t", log.Ltime)
+	logger, logErr := newLogger(cfg)
 	resultLog := log.New(os.Stdout, "RESULT\t", log.Ltime)
 
-	return &App{
-		Config:         LoadConfig(),
+	collector := metrics.NewCollector(quizapi.Endpoints...)
+
+	app := &App{
+		Config:         cfg,
 		Wait:           &sync.WaitGroup{},
 		QuizAPI:        quizApi,
-		Results:        make(chan *Session, cfg.NumUsers),
+		Results:        make(chan *Session, max(cfg.NumUsers, cfg.LoadProfile.Peak())),
 		Errors:         make(chan error),
 		ResultListener: &sync.WaitGroup{},
 		ErrorListener:  &sync.WaitGroup{},
-		InfoLogger:     infoLog,
-		ErrorLogger:    errorLog,
-		DebugLogger:    debugLog,
+		Logger:         logger,
 		ResultLogger:   resultLog,
+		Metrics:        collector,
+		Prometheus:     metrics.NewPrometheusExporter(collector.ActiveUsers),
+		SessionStats:   metrics.NewSessionStats(),
+		NegativeStats:  metrics.NewNegativeStats(),
+		Abort:          abort.NewMonitor(cfg.AbortRules),
+		rand:           newLockedRand(cfg.Seed),
+	}
+	if logErr != nil {
+		app.Logger.Error("Failed to open the log file, logging to stdout", "file", cfg.LogFile, "error", logErr)
+	}
+	quizApi.SetObserver(app.observeRequest)
+	if cfg.ContractValidation {
+		quizApi.SetContractValidation()
+	}
+	if cfg.ReportValidation {
+		quizApi.SetReportValidation(cfg.ReportMinSize)
+		if len(cfg.ReportTexts) > 0 {
+			quizApi.SetReportCheck(app.checkReport)
+		}
+	}
+	quizApi.SetReportStorage(cfg.ReportStorage, cfg.ReportSampleRate)
+	if cfg.Capture.Enabled() {
+		quizApi.SetCapture(cfg.CaptureBodyLimit)
+		app.captures = capture.NewRecorder(cfg.Capture, cfg.CaptureSampleRate)
+	}
+
+	if !cfg.NegativeScenarios.Empty() {
+		negativeApi := quizapi.NewQuizAPI(cfg.BaseURL, cfg.ReportServerBaseURL)
+		negativeApi.SetObserver(app.observeNegativeRequest)
+		// a report wrongly returned for an incomplete session is not kept
+		negativeApi.SetReportStorage(quizapi.ReportStorageDiscard, 0)
+		if cfg.Capture.Enabled() {
+			negativeApi.SetCapture(cfg.CaptureBodyLimit)
+		}
+		app.NegativeQuizAPI = negativeApi
+	}
+
+	return app
+}
+
+// Run simulates the configured users, and stops the app once all the results are processed
+func (app *App) Run() {
+	// aggregate the requests metrics every interval, for the time series, the live dashboard and the abort rules
+	if !app.Config.AbortRules.Empty() {
+		go app.monitorAbortRules(app.Metrics.Subscribe())
+	}
+	app.Metrics.Start(app.Config.MetricsInterval)
+
+	app.ErrorListener.Add(1)
+	app.Logger.Debug("GO ROUTINE STARTED for listening to errors")
+	go app.ListenForErrors()
+
+	app.ResultListener.Add(1)
+	app.Logger.Debug("GO ROUTINE STARTED for listening to results")
+	go app.ListenForResults()
+
+	app.Logger.Info("Starting run", "run_id", app.Config.RunID, "seed", app.Config.Seed)
+	if app.Config.LoadProfile.Empty() {
+		app.Logger.Info("Starting simulation", "users", app.Config.NumUsers)
+	} else {
+		app.Logger.Info("Starting simulation with the load profile", "load_profile", app.Config.LoadProfile.String())
+	}
+	app.StartSimulation()
+
+	app.Wait.Wait()
+	app.Stop()
+}
+
+// aborted reports whether an abort rule ended the run
+func (app *App) aborted() bool {
+	select {
+	case <-app.Abort.Aborted():
+		return true
+	default:
+		return false
+	}
+}
+
+// monitorAbortRules evaluates the abort rules against every collector interval until the collector stops
+func (app *App) monitorAbortRules(snapshots <-chan metrics.Snapshot, unsubscribe func()) {
+	defer unsubscribe()
+	for snapshot := range snapshots {
+		app.Abort.ObserveSnapshot(snapshot)
 	}
 }
 
 func (app *App) Stop() {
+	app.FinishedAt = time.Now()
+	// flush the last interval, so the time series is complete when the results are written
+	app.Metrics.Stop()
+
 	// wait for the results and errors to be processed
-	app.InfoLogger.Println("Waiting for results and errors to be processed...")
+	app.Logger.Info("Waiting for results and errors to be processed...")
 	close(app.Errors)
 	app.ErrorListener.Wait()
 	close(app.Results)
 	app.ResultListener.Wait()
+
+	if app.requestsCSV != nil {
+		if err := app.requestsCSV.Close(); err != nil {
+			app.Logger.Error("Failed to close the requests csv file", "error", err)
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if app.Dashboard != nil {
+		if err := app.Dashboard.Shutdown(ctx); err != nil {
+			app.Logger.Error("Failed to shutdown the dashboard", "error", err)
+		}
+	}
+	if app.MetricsServer != nil {
+		if err := app.MetricsServer.Shutdown(ctx); err != nil {
+			app.Logger.Error("Failed to shutdown the metrics server", "error", err)
+		}
+	}
+}
+
+// Summary describes the run, it should be called after the app is stopped
+func (app *App) Summary() *metrics.Summary {
+	summary := metrics.NewSummary(app.StartedAt, app.FinishedAt, app.Config.Values(), app.Metrics, app.SessionStats)
+	summary.AbortReason = app.Abort.Reason()
+	if summary.AbortReason == "" {
+		summary.AbortReason = app.workerAbortReason
+	}
+	if app.EmailSink != nil {
+		summary.EmailDelivery = app.emailDelivery()
+	}
+	summary.NegativeScenarios = app.NegativeStats.Scenarios()
+	return summary
 }
@@ -2,10 +2,20 @@ package app
 
 import (
 	"bytes"
+	"errors"
+	"log/slog"
+	"net"
 	"os"
+	"syscall"
 	"testing"
+	"time"
 
+	"github.com/go-squad-5/quiz-load-test/internal/abort"
+	"github.com/go-squad-5/quiz-load-test/internal/loadprofile"
+	"github.com/go-squad-5/quiz-load-test/internal/quizapi"
+	"github.com/go-squad-5/quiz-load-test/internal/quizapi/mock"
 	"github.com/stretchr/testify/assert"
+	testifymock "github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 )
 
@@ -19,9 +29,7 @@ func Test_app_NewApp(t *testing.T) {
 	require.NotNil(t, app.Errors, "App Errors channel should not be nil")
 	require.NotNil(t, app.ResultListener, "App ResultListener WaitGroup should not be nil")
 	require.NotNil(t, app.ErrorListener, "App ErrorListener WaitGroup should not be nil")
-	require.NotNil(t, app.InfoLogger, "App InfoLogger should not be nil")
-	require.NotNil(t, app.ErrorLogger, "App ErrorLogger should not be nil")
-	require.NotNil(t, app.DebugLogger, "App DebugLogger should not be nil")
+	require.NotNil(t, app.Logger, "App Logger should not be nil")
 	require.NotNil(t, app.ResultLogger, "App ResultLogger should not be nil")
 }
 
@@ -32,58 +40,146 @@ func Test_app_Stop(t *testing.T) {
 	}, "Stop should not panic")
 }
 
-func Test_app_InfoLogger(t *testing.T) {
+func Test_app_Logger(t *testing.T) {
 	app := NewTestApp()
 
 	var buf bytes.Buffer
-	app.InfoLogger.SetOutput(&buf)
+	app.Logger = slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
 
-	app.InfoLogger.Print("Hello World")
-	app.InfoLogger.SetOutput(os.Stdout)
+	app.Logger.Debug("Hello World")
+	app.Logger.Error("Hello World")
 	output := buf.String()
 
-	assert.Containsf(t, output, "INFO\t", "Expected 'INFO' in the log")
+	assert.Containsf(t, output, "level=DEBUG", "Expected 'DEBUG' in the log")
+	assert.Containsf(t, output, "level=ERROR", "Expected 'ERROR' in the log")
 	assert.Containsf(t, output, "Hello World", "Expected 'Hello World' in the log")
 }
 
-func Test_app_ErrorLogger(t *testing.T) {
+func Test_app_ResultLogger(t *testing.T) {
 	app := NewTestApp()
 
 	var buf bytes.Buffer
-	app.ErrorLogger.SetOutput(&buf)
+	app.ResultLogger.SetOutput(&buf)
 
-	app.ErrorLogger.Print("Hello World")
-	app.ErrorLogger.SetOutput(os.Stdout)
+	app.ResultLogger.Print("Hello World")
+	app.ResultLogger.SetOutput(os.Stdout)
 	output := buf.String()
 
-	assert.Containsf(t, output, "ERROR\t", "Expected 'INFO' in the log")
+	assert.Containsf(t, output, "RESULT\t", "Expected 'INFO' in the log")
 	assert.Containsf(t, output, "Hello World", "Expected 'Hello World' in the log")
 }
 
-func Test_app_DebugLogger(t *testing.T) {
+func Test_app_Summary(t *testing.T) {
 	app := NewTestApp()
+	app.StartedAt = time.Now().Add(-2 * time.Second)
+	app.FinishedAt = app.StartedAt.Add(2 * time.Second)
+	app.SessionStats.Add(getSessionResult(&Session{Topic: "go", Score: 3, Status: STATUS_COMPLETED}))
+
+	summary := app.Summary()
+	require.NotNil(t, summary, "Expected a non-nil summary")
+	assert.InDelta(t, 2, summary.Seconds, 0.001, "Expected the run duration between start and finish")
+	assert.Equal(t, 1, summary.Sessions.Completed, "Expected the completed session in the summary")
+	assert.Equal(t, "10", summary.Config["NUM_USERS"], "Expected the run configuration in the summary")
+}
 
-	var buf bytes.Buffer
-	app.DebugLogger.SetOutput(&buf)
+func Test_app_NewAppWithConfig(t *testing.T) {
+	cfg := &Config{BaseURL: "http://localhost:3000", ReportServerBaseURL: "http://localhost:3070", NumUsers: 3}
+	app := NewAppWithConfig(cfg)
+	require.NotNil(t, app, "NewAppWithConfig should return a non-nil App instance")
+	assert.Same(t, cfg, app.Config, "Expected the given configuration")
+	assert.Equal(t, 3, cap(app.Results), "Expected a result buffer for every user")
+}
 
-	app.DebugLogger.Print("Hello World")
-	app.DebugLogger.SetOutput(os.Stdout)
-	output := buf.String()
+func Test_app_Run_WithArrivalRate(t *testing.T) {
+	tmpDirPath = "./test" // change path for the test environment
+	defer func() {
+		if err := os.RemoveAll(tmpDirPath); err != nil && !os.IsNotExist(err) {
+			t.Fatalf("Error cleaning up the test files: %s", tmpDirPath)
+		}
+	}()
 
-	assert.Containsf(t, output, "DEBUG\t", "Expected 'INFO' in the log")
-	assert.Containsf(t, output, "Hello World", "Expected 'Hello World' in the log")
+	app := NewTestApp()
+	app.Config.NumUsers = 5
+	app.Config.ArrivalRate = 50 // a user every 20ms
+	mockApp, ok := app.QuizAPI.(*mock.MockQuizAPI)
+	require.True(t, ok, "Error while getting the mock quizapi")
+	mockApp.On("CreateSession", testifymock.Anything, testifymock.Anything).Return("", errors.New("connection refused"))
+
+	app.Run()
+
+	assert.GreaterOrEqual(t, app.FinishedAt.Sub(app.StartedAt), 80*time.Millisecond, "Expected the users to start at the arrival rate")
+	assert.Equal(t, 5, app.SessionStats.Counts().Failed, "Expected every user to be processed before the app stops")
+	mockApp.AssertNumberOfCalls(t, "CreateSession", 5)
+	intended := app.Summary().IntendedEndpoints
+	require.NotEmpty(t, intended, "Expected the latency from the intended start at an arrival rate")
+	assert.Equal(t, uint64(5), intended[0].Count, "Expected every scheduled session to be measured from its intended start")
 }
 
-func Test_app_ResultLogger(t *testing.T) {
+func Test_app_Run_WithLoadProfile(t *testing.T) {
+	tmpDirPath = "./test" // change path for the test environment
+	defer func() {
+		if err := os.RemoveAll(tmpDirPath); err != nil && !os.IsNotExist(err) {
+			t.Fatalf("Error cleaning up the test files: %s", tmpDirPath)
+		}
+	}()
+
 	app := NewTestApp()
+	profile, err := loadprofile.Parse("base=hold(2, 200ms),spike(3x, 100ms)")
+	require.NoError(t, err, "Expected a valid load profile")
+	app.Config.LoadProfile = profile
+	mockApp, ok := app.QuizAPI.(*mock.MockQuizAPI)
+	require.True(t, ok, "Error while getting the mock quizapi")
+	mockApp.On("CreateSession", testifymock.Anything, testifymock.Anything).
+		After(10*time.Millisecond).Return("", errors.New("connection refused"))
+
+	app.Run()
+
+	assert.GreaterOrEqual(t, app.FinishedAt.Sub(app.StartedAt), 300*time.Millisecond, "Expected the run to last as long as the profile")
+	stages := app.Summary().Stages
+	require.Len(t, stages, 2, "Expected a summary per stage")
+	assert.Equal(t, "base", stages[0].Name, "Expected the label of the stage")
+	assert.Equal(t, "2-spike", stages[1].Name, "Expected the default label of the stage")
+
+	calls := 0
+	for _, call := range mockApp.Calls {
+		if call.Method == "CreateSession" {
+			calls++
+		}
+	}
+	assert.Greater(t, calls, 6, "Expected the virtual users to run sessions in a loop")
+	assert.Equal(t, calls, app.SessionStats.Counts().Failed, "Expected every session to be processed before the app stops")
+}
 
-	var buf bytes.Buffer
-	app.ResultLogger.SetOutput(&buf)
+func Test_app_Run_WhenAborted(t *testing.T) {
+	tmpDirPath = "./test" // change path for the test environment
+	defer func() {
+		if err := os.RemoveAll(tmpDirPath); err != nil && !os.IsNotExist(err) {
+			t.Fatalf("Error cleaning up the test files: %s", tmpDirPath)
+		}
+	}()
 
-	app.ResultLogger.Print("Hello World")
-	app.ResultLogger.SetOutput(os.Stdout)
-	output := buf.String()
-
-	assert.Containsf(t, output, "RESULT\t", "Expected 'INFO' in the log")
-	assert.Containsf(t, output, "Hello World", "Expected 'Hello World' in the log")
+	app := NewTestApp()
+	app.Config.NumUsers = 50
+	app.Config.ArrivalRate = 100
+	rules, err := abort.Parse("connection_refused>=2")
+	require.NoError(t, err, "Expected valid abort rules")
+	app.Config.AbortRules = rules
+	app.Abort = abort.NewMonitor(rules)
+
+	refused := &net.OpError{Op: "dial", Net: "tcp", Err: os.NewSyscallError("connect", syscall.ECONNREFUSED)}
+	mockApp, ok := app.QuizAPI.(*mock.MockQuizAPI)
+	require.True(t, ok, "Error while getting the mock quizapi")
+	mockApp.On("CreateSession", testifymock.Anything, testifymock.Anything).
+		Run(func(args testifymock.Arguments) {
+			// the mock doesn't call the observer of the real quiz api
+			app.observeRequest(quizapi.RequestInfo{Endpoint: quizapi.EndpointCreateSession, StartTime: time.Now(), Err: refused})
+		}).
+		Return("", refused)
+
+	app.Run()
+
+	summary := app.Summary()
+	assert.Contains(t, summary.AbortReason, "2 consecutive requests refused", "Expected the abort reason in the summary")
+	assert.Less(t, summary.Sessions.Total, 50, "Expected the remaining users not to be started")
+	assert.GreaterOrEqual(t, summary.Sessions.Total, 2, "Expected the users started before the abort to be processed")
 }
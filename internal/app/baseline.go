@@ -0,0 +1,38 @@
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/go-squad-5/quiz-load-test/internal/compare"
+	"github.com/go-squad-5/quiz-load-test/internal/metrics"
+)
+
+// WriteSummaryJSON writes the run summary in the output directory, to be used as the baseline of later runs
+func (app *App) WriteSummaryJSON(summary *metrics.Summary) (string, error) {
+	mustInitDir(app.outputDir())
+
+	filePath := fmt.Sprintf("%s/summary.json", app.outputDir())
+	file, err := os.Create(filePath)
+	if err != nil {
+		return "", fmt.Errorf("failed to create summary file: %w", err)
+	}
+	defer file.Close()
+
+	encoder := json.NewEncoder(file)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(summary); err != nil {
+		return "", fmt.Errorf("failed to encode summary: %w", err)
+	}
+	return filePath, nil
+}
+
+// CompareWithBaseline compares the run with the summary of the configured baseline file
+func (app *App) CompareWithBaseline(summary *metrics.Summary) (*compare.Result, error) {
+	baseline, err := compare.ReadSummary(app.Config.BaselineFile)
+	if err != nil {
+		return nil, err
+	}
+	return compare.Compare(baseline, summary, app.Config.Tolerances), nil
+}
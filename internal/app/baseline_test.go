@@ -0,0 +1,57 @@
+package app
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/go-squad-5/quiz-load-test/internal/compare"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_app_baseline_WriteSummaryJSON(t *testing.T) {
+	tmpDirPath = "./test" // change path for the test environment
+	defer func() {
+		if err := os.RemoveAll(tmpDirPath); err != nil && !os.IsNotExist(err) {
+			t.Fatalf("Error cleaning up the test files: %s", tmpDirPath)
+		}
+	}()
+
+	app := NewTestApp()
+	app.SessionStats.Add(getSessionResult(&Session{Topic: "go", Score: 3, Status: STATUS_COMPLETED}))
+	app.StartedAt = time.Now().Add(-time.Second)
+	app.FinishedAt = time.Now()
+
+	filePath, err := app.WriteSummaryJSON(app.Summary())
+	require.NoError(t, err, "Expected the summary to be written")
+
+	summary, err := compare.ReadSummary(filePath)
+	require.NoError(t, err, "Expected the written summary to be readable")
+	assert.Equal(t, 1, summary.Sessions.Completed, "Expected the session counts in the summary")
+	assert.Equal(t, "10", summary.Config["NUM_USERS"], "Expected the run configuration in the summary")
+}
+
+func Test_app_baseline_CompareWithBaseline(t *testing.T) {
+	tmpDirPath = "./test" // change path for the test environment
+	defer func() {
+		if err := os.RemoveAll(tmpDirPath); err != nil && !os.IsNotExist(err) {
+			t.Fatalf("Error cleaning up the test files: %s", tmpDirPath)
+		}
+	}()
+
+	app := NewTestApp()
+	app.Config.Tolerances = compare.DefaultTolerances
+	app.StartedAt = time.Now().Add(-time.Second)
+	app.FinishedAt = time.Now()
+	summary := app.Summary()
+
+	app.Config.BaselineFile, _ = app.WriteSummaryJSON(summary)
+	result, err := app.CompareWithBaseline(summary)
+	require.NoError(t, err, "Expected the run to be compared with the baseline")
+	assert.False(t, result.HasRegression(), "Expected no regression against itself")
+
+	app.Config.BaselineFile = tmpDirPath + "/missing.json"
+	_, err = app.CompareWithBaseline(summary)
+	assert.Error(t, err, "Expected an error for a missing baseline")
+}
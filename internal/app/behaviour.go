@@ -0,0 +1,40 @@
+package app
+
+import (
+	"time"
+
+	"github.com/go-squad-5/quiz-load-test/internal/behaviour"
+	"github.com/go-squad-5/quiz-load-test/internal/quizapi"
+)
+
+// submitRetries is the number of times a user of the retry submit profile submits the quiz again after a failed submit
+const submitRetries = 2
+
+// submitRetryDelay is the time a user waits before submitting the quiz again
+var submitRetryDelay time.Duration = time.Second
+
+// pickBehaviour returns the behaviour profile of a new user
+func (app *App) pickBehaviour() behaviour.Profile {
+	if app.Config.BehaviourProfiles.Empty() {
+		return behaviour.Complete
+	}
+	return app.Config.BehaviourProfiles.Pick(app.rand.Float64())
+}
+
+// abandonSession ends the session of a user leaving after starting the quiz, the session is neither completed nor failed
+func (app *App) abandonSession(session *Session) {
+	session.SetEndTime(time.Now())
+	session.SetStatus(STATUS_ABANDONED)
+	app.sessionLogger(session).Info("Session abandoned")
+	app.Results <- session
+}
+
+// shouldRetrySubmit reports whether the user submits the quiz again after the failed submit,
+// like a user clicking submit again when the page failed to respond; a rejected submit would be rejected again
+func shouldRetrySubmit(session *Session, err error, attempt int) bool {
+	if session.Behaviour != behaviour.RetrySubmit || attempt > submitRetries || isContractError(err) {
+		return false
+	}
+	statusCode := quizapi.StatusCode(err)
+	return statusCode == 0 || statusCode >= 500
+}
@@ -0,0 +1,90 @@
+package app
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/go-squad-5/quiz-load-test/internal/behaviour"
+	"github.com/go-squad-5/quiz-load-test/internal/quizapi"
+	"github.com/go-squad-5/quiz-load-test/internal/quizapi/mock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_app_behaviour_PickBehaviour(t *testing.T) {
+	app := NewTestApp()
+	assert.Equal(t, behaviour.Complete, app.pickBehaviour(), "Expected every user to complete the quiz without profiles")
+
+	app.Config.BehaviourProfiles = behaviour.Mix{{Profile: behaviour.SkipEmail, Weight: 1}}
+	assert.Equal(t, behaviour.SkipEmail, app.pickBehaviour(), "Expected the only weighted profile")
+}
+
+func Test_app_behaviour_ShouldRetrySubmit(t *testing.T) {
+	serverErr := &quizapi.StatusError{StatusCode: http.StatusBadGateway, Err: errors.New("bad gateway")}
+	tests := []struct {
+		name    string
+		profile behaviour.Profile
+		err     error
+		attempt int
+		retry   bool
+	}{
+		{"server error", behaviour.RetrySubmit, serverErr, 1, true},
+		{"no response", behaviour.RetrySubmit, errors.New("connection refused"), submitRetries, true},
+		{"too many retries", behaviour.RetrySubmit, serverErr, submitRetries + 1, false},
+		{"rejected submit", behaviour.RetrySubmit, &quizapi.StatusError{StatusCode: http.StatusBadRequest, Err: errors.New("bad request")}, 1, false},
+		{"contract violation", behaviour.RetrySubmit, &quizapi.ContractError{Endpoint: quizapi.EndpointSubmitQuiz, Violations: []string{"negative score"}}, 1, false},
+		{"other profile", behaviour.Complete, serverErr, 1, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			session := NewSession("test@example.com", "math", nil)
+			session.SetBehaviour(tt.profile)
+			assert.Equal(t, tt.retry, shouldRetrySubmit(session, tt.err, tt.attempt), "Expected the submit to be retried only after a failure of the server")
+		})
+	}
+}
+
+func Test_app_behaviour_CallSubmitQuiz_WhenRetrySubmit(t *testing.T) {
+	defer func(delay time.Duration) { submitRetryDelay = delay }(submitRetryDelay)
+	submitRetryDelay = 0
+
+	session := NewSession("test@example.com", "math", nil)
+	session.SetBehaviour(behaviour.RetrySubmit)
+	answers := []quizapi.Answer{{QuestionID: "q1", Answer: "1"}}
+	session.SetAnswers(answers)
+
+	app := NewTestApp()
+	mockApp, ok := app.QuizAPI.(*mock.MockQuizAPI)
+	require.True(t, ok, "Error while getting the mock quizapi")
+	mockApp.On("SubmitQuiz", "12345", answers).Return(0, &quizapi.StatusError{StatusCode: http.StatusServiceUnavailable, Err: errors.New("unavailable")}).Once()
+	mockApp.On("SubmitQuiz", "12345", answers).Return(7, nil).Once()
+
+	score, _, err := app.callSubmitQuiz("12345", session)
+
+	require.NoError(t, err, "Expected the retried submit to succeed")
+	assert.Equal(t, 7, score, "Expected the score of the retried submit")
+	mockApp.AssertNumberOfCalls(t, "SubmitQuiz", 2)
+}
+
+func Test_app_behaviour_SimulateUser_WhenAbandon(t *testing.T) {
+	app := NewTestApp()
+	app.Config.BehaviourProfiles = behaviour.Mix{{Profile: behaviour.Abandon, Weight: 1}}
+	mockApp, ok := app.QuizAPI.(*mock.MockQuizAPI)
+	require.True(t, ok, "Error while getting the mock quizapi")
+	mockApp.On("CreateSession", "test@example.com", "math").Return("12345", nil)
+	mockApp.On("StartQuiz", "12345", "math").Return([]quizapi.Question{{ID: "q1", Question: "What is 2 + 2?", Options: []string{"4"}}}, nil)
+
+	app.Wait.Add(1)
+	app.SimulateUser("test@example.com", "math")
+
+	result := <-app.Results
+	assert.Equal(t, STATUS_ABANDONED, result.Status, "Expected the session to be abandoned")
+	assert.Equal(t, behaviour.Abandon, result.Behaviour, "Expected the behaviour profile of the session")
+	assert.NoError(t, result.Error, "Expected an abandoned session not to fail")
+	assert.NotZero(t, result.EndTime, "Expected the session to end when abandoned")
+	mockApp.AssertExpectations(t)
+	mockApp.AssertNotCalled(t, "SubmitQuiz", "12345", []quizapi.Answer{{QuestionID: "q1", Answer: "4"}})
+}
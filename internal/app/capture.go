@@ -0,0 +1,96 @@
+package app
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/go-squad-5/quiz-load-test/internal/capture"
+	"github.com/go-squad-5/quiz-load-test/internal/negative"
+	"github.com/go-squad-5/quiz-load-test/internal/quizapi"
+)
+
+// capturesDirPath is the directory the requests and responses of the captured sessions are saved to,
+// unless the output directory of the run is set
+var capturesDirPath string = "./tmp/captures"
+
+// capturesDir returns the directory the captured sessions of the run are saved to
+func (app *App) capturesDir() string {
+	if app.Config.OutputDir != "" {
+		return filepath.Join(app.Config.OutputDir, "captures")
+	}
+	return capturesDirPath
+}
+
+// captureKey returns the key the requests of a session are recorded with, its ID once created,
+// or else the user and topic creating it
+func captureKey(sessionID, email, topic string) string {
+	if sessionID != "" {
+		return sessionID
+	}
+	return "create_session:" + email + ":" + topic
+}
+
+// recordExchange records the captured request and response until the session is over
+func (app *App) recordExchange(info quizapi.RequestInfo) {
+	if app.captures == nil || info.Exchange == nil {
+		return
+	}
+	app.captures.Add(captureKey(info.SessionID, info.Email, info.Topic), *info.Exchange)
+}
+
+// saveCapture saves the requests and responses of the finished session when the capture mode keeps the session,
+// and returns the path of the capture, empty when the session is not captured
+func (app *App) saveCapture(session *Session) (string, error) {
+	var exchanges []quizapi.Exchange
+	if session.ID != "" {
+		exchanges = app.captures.Take(session.ID)
+	} else {
+		// a session failing to be created has no ID, its request is told apart from the other users by its email and topic
+		exchanges = app.captures.TakeFirst(captureKey("", session.Email, session.Topic))
+	}
+	if len(exchanges) == 0 || !app.captures.Keep(session.Status == STATUS_FAILED) {
+		return "", nil
+	}
+
+	name := session.ID + ".json"
+	if session.ID == "" {
+		name = fmt.Sprintf("create_session-%d.json", exchanges[0].StartTime.UnixNano())
+	}
+	errMessage := ""
+	if session.Error != nil {
+		errMessage = session.Error.Error()
+	}
+	return capture.Save(app.capturesDir(), name, capture.Capture{
+		SessionID:  session.ID,
+		Email:      session.Email,
+		Topic:      session.Topic,
+		Status:     string(session.Status),
+		FailedStep: session.FailedStep,
+		Error:      errMessage,
+		Exchanges:  exchanges,
+	})
+}
+
+// saveNegativeCapture saves the set up and misuse requests of the negative scenario when the capture mode keeps it,
+// a misuse the server didn't reject as expected is kept as a failure. It returns the path of the capture, empty when
+// the scenario is not captured
+func (app *App) saveNegativeCapture(scenario negative.Scenario, session *Session, problem error) (string, error) {
+	exchanges := app.captures.Take(session.ID)
+	if len(exchanges) == 0 || !app.captures.Keep(problem != nil) {
+		return "", nil
+	}
+
+	status, errMessage := "passed", ""
+	if problem != nil {
+		status, errMessage = "failed", problem.Error()
+	}
+	return capture.Save(app.capturesDir(), session.ID+".json", capture.Capture{
+		SessionID: session.ID,
+		Email:     session.Email,
+		Topic:     session.Topic,
+		Status:    status,
+		Scenario:  string(scenario),
+		Error:     errMessage,
+		Exchanges: exchanges,
+	})
+}
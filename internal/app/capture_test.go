@@ -0,0 +1,51 @@
+package app
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/go-squad-5/quiz-load-test/internal/capture"
+	"github.com/go-squad-5/quiz-load-test/internal/quizapi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_app_capture_saveCapture(t *testing.T) {
+	capturesDirPath = t.TempDir()
+	defer func() { capturesDirPath = "./tmp/captures" }()
+
+	app := NewTestApp()
+	app.captures = capture.NewRecorder(capture.Failures, 0)
+	app.recordExchange(quizapi.RequestInfo{SessionID: "s1", Exchange: &quizapi.Exchange{Endpoint: quizapi.EndpointCreateSession}})
+	app.recordExchange(quizapi.RequestInfo{SessionID: "s1", Exchange: &quizapi.Exchange{Endpoint: quizapi.EndpointSubmitQuiz, StatusCode: 500}})
+	app.recordExchange(quizapi.RequestInfo{SessionID: "s2", Exchange: &quizapi.Exchange{Endpoint: quizapi.EndpointCreateSession}})
+	app.recordExchange(quizapi.RequestInfo{SessionID: "s3"})
+
+	failed := &Session{ID: "s1", Email: "test@example.com", Topic: "go", Status: STATUS_FAILED, FailedStep: quizapi.EndpointSubmitQuiz, Error: errors.New("status code: 500")}
+	captureFile, err := app.saveCapture(failed)
+	require.NoError(t, err, "Expected the capture of the failed session to be saved")
+	assert.Equal(t, capturesDirPath+"/s1.json", captureFile, "Expected the capture named after the session")
+
+	captureFile, err = app.saveCapture(&Session{ID: "s2", Status: STATUS_COMPLETED})
+	require.NoError(t, err, "Expected no error")
+	assert.Empty(t, captureFile, "Expected the completed session not to be captured")
+	assert.Empty(t, app.captures.Take("s2"), "Expected the requests of the session to be forgotten once it is over")
+
+	captureFile, err = app.saveCapture(&Session{ID: "s3", Status: STATUS_FAILED})
+	require.NoError(t, err, "Expected no error")
+	assert.Empty(t, captureFile, "Expected no capture without captured requests")
+}
+
+func Test_app_capture_saveCapture_WhenCreateFailed(t *testing.T) {
+	capturesDirPath = t.TempDir()
+	defer func() { capturesDirPath = "./tmp/captures" }()
+
+	app := NewTestApp()
+	app.captures = capture.NewRecorder(capture.Failures, 0)
+	app.recordExchange(quizapi.RequestInfo{Email: "test@example.com", Topic: "go", Exchange: &quizapi.Exchange{Endpoint: quizapi.EndpointCreateSession, StatusCode: 503}})
+
+	captureFile, err := app.saveCapture(&Session{Email: "test@example.com", Topic: "go", Status: STATUS_FAILED})
+	require.NoError(t, err, "Expected the capture of the failed create to be saved")
+	assert.Contains(t, captureFile, "create_session-", "Expected the capture named after the failed request")
+	assert.Empty(t, app.captures.TakeFirst(captureKey("", "test@example.com", "go")), "Expected the request to be taken by the failed session")
+}
@@ -1,10 +1,23 @@
 package app
 
 import (
+	"log/slog"
 	"os"
 	"strconv"
 	"strings"
+	"time"
 
+	"github.com/go-squad-5/quiz-load-test/internal/abort"
+	"github.com/go-squad-5/quiz-load-test/internal/answerkey"
+	"github.com/go-squad-5/quiz-load-test/internal/behaviour"
+	"github.com/go-squad-5/quiz-load-test/internal/capture"
+	"github.com/go-squad-5/quiz-load-test/internal/compare"
+	"github.com/go-squad-5/quiz-load-test/internal/flow"
+	"github.com/go-squad-5/quiz-load-test/internal/history"
+	"github.com/go-squad-5/quiz-load-test/internal/loadprofile"
+	"github.com/go-squad-5/quiz-load-test/internal/negative"
+	"github.com/go-squad-5/quiz-load-test/internal/quizapi"
+	"github.com/go-squad-5/quiz-load-test/internal/thresholds"
 	_ "github.com/joho/godotenv/autoload"
 )
 
@@ -12,6 +25,60 @@ type Config struct {
 	BaseURL             string
 	ReportServerBaseURL string
 	NumUsers            int
+	ArrivalRate         float64
+	LoadProfile         loadprofile.Profile
+	AnswerKeyFile       string
+	AnswerKey           answerkey.Key
+	NegativeScenarios   negative.Mix
+	BehaviourProfiles   behaviour.Mix
+	FlowFile            string
+	Flow                *flow.Flow
+	MetricsInterval     time.Duration
+	ContractValidation  bool
+	ReportValidation    bool
+	ReportMinSize       int
+	ReportTexts         []string
+	ReportStorage       quizapi.ReportStorage
+	ReportSampleRate    float64
+	Capture             capture.Mode
+	CaptureSampleRate   float64
+	CaptureBodyLimit    int
+	DashboardAddr       string
+	MetricsAddr         string
+	SMTPSinkAddr        string
+	SMTPSinkWait        time.Duration
+	EmailStatusInterval time.Duration
+	EmailStatusTimeout  time.Duration
+	Thresholds          []thresholds.Threshold
+	AbortRules          abort.Rules
+	JUnitFile           string
+	JUnitSessions       bool
+	BaselineFile        string
+	Tolerances          compare.Tolerances
+	RunID               string
+	Seed                int64
+	RunLabels           map[string]string
+	HistoryDir          string
+	LogLevel            slog.Level
+	LogFormat           string
+	LogFile             string
+	Quiet               bool
+	// directory the logs, csv files and reports of the run are written to, the tmp directory unless set,
+	// set for the job of a worker so the jobs running in the same process don't overwrite each other
+	OutputDir string
+	// index of the worker and number of workers of a distributed run, the users are numbered across the workers,
+	// set for the job of a worker rather than from the environment
+	Worker  int
+	Workers int
+}
+
+// userIndex returns the index of the i-th user of this process among the users of the run,
+// the workers of a distributed run take the users in turn so they cycle through the emails and topics together
+func (cfg *Config) userIndex(i int) int {
+	if cfg.Workers <= 1 {
+		return i
+	}
+	return i*cfg.Workers + cfg.Worker
 }
 
 type Endpoints struct {
@@ -36,6 +103,268 @@ func LoadConfig() *Config {
 		numUsers = "10"
 	}
 
+	// users started per second, all the users start at once when not set
+	arrivalRate := 0.0
+	if value := os.Getenv("ARRIVAL_RATE"); value != "" {
+		rate, err := strconv.ParseFloat(value, 64)
+		if err != nil || rate < 0 {
+			panic("Invalid ARRIVAL_RATE value, must be a positive number")
+		}
+		arrivalRate = rate
+	}
+
+	// stages of concurrent users, e.g. "ramp(20, 1m),step(50, 2m, 3),spike(10x, 30s)",
+	// NUM_USERS and ARRIVAL_RATE are ignored when set
+	loadProfile, err := loadprofile.Parse(os.Getenv("LOAD_PROFILE"))
+	if err != nil {
+		panic("Invalid LOAD_PROFILE value, " + err.Error())
+	}
+
+	// scores are verified against the answer key of the question bank when a file path is set
+	answerKeyFile := os.Getenv("ANSWER_KEY_FILE")
+	var answerKey answerkey.Key
+	if answerKeyFile != "" {
+		answerKey, err = answerkey.Load(answerKeyFile)
+		if err != nil {
+			panic("Invalid ANSWER_KEY_FILE value, " + err.Error())
+		}
+	}
+
+	// share of the sessions misusing the api instead of running the quiz, e.g. "double_submit=5%,unknown_session=2%"
+	negativeScenarios, err := negative.ParseMix(os.Getenv("NEGATIVE_SCENARIOS"))
+	if err != nil {
+		panic("Invalid NEGATIVE_SCENARIOS value, " + err.Error())
+	}
+
+	// weights of the paths the users take through the quiz, e.g. "complete=70%,abandon=15%,skip_email=10%,retry_submit=5%"
+	behaviourProfiles, err := behaviour.ParseMix(os.Getenv("BEHAVIOUR_PROFILES"))
+	if err != nil {
+		panic("Invalid BEHAVIOUR_PROFILES value, " + err.Error())
+	}
+
+	// steps run by every user, the users complete the quiz and request both reports without a flow file
+	flowFile := os.Getenv("FLOW_FILE")
+	var userFlow *flow.Flow
+	if flowFile != "" {
+		userFlow, err = flow.Load(flowFile)
+		if err != nil {
+			panic("Invalid FLOW_FILE value, " + err.Error())
+		}
+	}
+
+	// responses are checked strictly against the contract of their endpoint when enabled
+	contractValidation := false
+	if value := os.Getenv("CONTRACT_VALIDATION"); value != "" {
+		contractValidation, err = strconv.ParseBool(value)
+		if err != nil {
+			panic("Invalid CONTRACT_VALIDATION value, must be a boolean")
+		}
+	}
+
+	// reports are checked to be valid pdf documents when enabled
+	reportValidation := false
+	if value := os.Getenv("REPORT_VALIDATION"); value != "" {
+		reportValidation, err = strconv.ParseBool(value)
+		if err != nil {
+			panic("Invalid REPORT_VALIDATION value, must be a boolean")
+		}
+	}
+	reportMinSize := 1024
+	if value := os.Getenv("REPORT_MIN_SIZE"); value != "" {
+		reportMinSize, err = strconv.Atoi(value)
+		if err != nil || reportMinSize < 0 {
+			panic("Invalid REPORT_MIN_SIZE value, must be a positive number of bytes")
+		}
+	}
+	// comma separated texts expected in the reports, e.g. "{session_id},Score: {score}"
+	reportTexts := []string{}
+	for _, text := range strings.Split(os.Getenv("REPORT_EXPECTED_TEXTS"), ",") {
+		if text = strings.TrimSpace(text); text != "" {
+			reportTexts = append(reportTexts, text)
+		}
+	}
+
+	// reports saved to ./tmp/reports, the other reports are only counted and hashed
+	reportStorage := quizapi.ReportStorageAll
+	if value := os.Getenv("REPORT_STORAGE"); value != "" {
+		reportStorage, err = quizapi.ParseReportStorage(value)
+		if err != nil {
+			panic("Invalid REPORT_STORAGE value, " + err.Error())
+		}
+	}
+	// no report is found invalid without a check, the failures storage would save none
+	if reportStorage == quizapi.ReportStorageFailures && !reportValidation && len(reportTexts) == 0 {
+		panic("Invalid REPORT_STORAGE value, failures requires REPORT_VALIDATION or REPORT_EXPECTED_TEXTS to find the invalid reports")
+	}
+	// fraction of the reports saved by the sample storage, e.g. "1%" or "0.01"
+	reportSampleRate := 0.01
+	if value := os.Getenv("REPORT_SAMPLE_RATE"); value != "" {
+		reportSampleRate, err = compare.ParseRatio(value)
+		if err != nil || reportSampleRate > 1 {
+			panic("Invalid REPORT_SAMPLE_RATE value, must be a fraction or a percentage up to 100%")
+		}
+	}
+
+	// requests and responses of the sessions saved to ./tmp/captures, to reproduce the failed sessions
+	captureMode := capture.Off
+	if value := os.Getenv("CAPTURE"); value != "" {
+		captureMode, err = capture.ParseMode(value)
+		if err != nil {
+			panic("Invalid CAPTURE value, " + err.Error())
+		}
+	}
+	// fraction of the other sessions saved by the sample mode, e.g. "1%" or "0.01"
+	captureSampleRate := 0.01
+	if value := os.Getenv("CAPTURE_SAMPLE_RATE"); value != "" {
+		captureSampleRate, err = compare.ParseRatio(value)
+		if err != nil || captureSampleRate > 1 {
+			panic("Invalid CAPTURE_SAMPLE_RATE value, must be a fraction or a percentage up to 100%")
+		}
+	}
+	captureBodyLimit := 4096
+	if value := os.Getenv("CAPTURE_BODY_LIMIT"); value != "" {
+		captureBodyLimit, err = strconv.Atoi(value)
+		if err != nil || captureBodyLimit < 0 {
+			panic("Invalid CAPTURE_BODY_LIMIT value, must be a positive number of bytes")
+		}
+	}
+
+	// interval of the time series, also the refresh interval of the dashboard and the abort rules
+	metricsInterval := time.Second
+	if value := os.Getenv("METRICS_INTERVAL"); value != "" {
+		metricsInterval, err = time.ParseDuration(value)
+		if err != nil || metricsInterval <= 0 {
+			panic("Invalid METRICS_INTERVAL value, must be a positive duration, e.g. 1s or 10s")
+		}
+	}
+
+	// the status of the accepted email reports is polled every interval when set, until the email is sent or the timeout expires
+	emailStatusInterval := time.Duration(0)
+	if value := os.Getenv("EMAIL_STATUS_INTERVAL"); value != "" {
+		emailStatusInterval, err = time.ParseDuration(value)
+		if err != nil || emailStatusInterval < 0 {
+			panic("Invalid EMAIL_STATUS_INTERVAL value, must be a positive duration, e.g. 1s")
+		}
+	}
+	emailStatusTimeout := 2 * time.Minute
+	if value := os.Getenv("EMAIL_STATUS_TIMEOUT"); value != "" {
+		emailStatusTimeout, err = time.ParseDuration(value)
+		if err != nil || emailStatusTimeout <= 0 {
+			panic("Invalid EMAIL_STATUS_TIMEOUT value, must be a positive duration, e.g. 2m")
+		}
+	}
+
+	// dashboard is disabled unless an address is set, e.g. ":9090"
+	dashboardAddr := os.Getenv("DASHBOARD_ADDR")
+	// prometheus metrics endpoint is disabled unless an address is set, e.g. ":9091"
+	metricsAddr := os.Getenv("METRICS_ADDR")
+	// smtp sink receiving the emails of the report server is disabled unless an address is set, e.g. ":2525"
+	smtpSinkAddr := os.Getenv("SMTP_SINK_ADDR")
+	// time to wait for the missing emails after the last session
+	smtpSinkWait := 30 * time.Second
+	if value := os.Getenv("SMTP_SINK_WAIT"); value != "" {
+		smtpSinkWait, err = time.ParseDuration(value)
+		if err != nil || smtpSinkWait < 0 {
+			panic("Invalid SMTP_SINK_WAIT value, must be a positive duration, e.g. 30s")
+		}
+	}
+
+	// comma separated pass/fail conditions for the run, e.g. "p95<500ms,error_rate<1%"
+	runThresholds, err := thresholds.Parse(os.Getenv("THRESHOLDS"))
+	if err != nil {
+		panic("Invalid THRESHOLDS value, " + err.Error())
+	}
+	// comma separated rules ending the run early, e.g. "error_rate>20% for 30s,connection_refused>50"
+	abortRules, err := abort.Parse(os.Getenv("ABORT_RULES"))
+	if err != nil {
+		panic("Invalid ABORT_RULES value, " + err.Error())
+	}
+	// junit xml report is not written unless a file path is set
+	junitFile := os.Getenv("JUNIT_FILE")
+	junitSessions := false
+	if value := os.Getenv("JUNIT_SESSIONS"); value != "" {
+		junitSessions, err = strconv.ParseBool(value)
+		if err != nil {
+			panic("Invalid JUNIT_SESSIONS value, must be a boolean")
+		}
+	}
+
+	// the run is compared to the baseline summary when a file path is set
+	baselineFile := os.Getenv("BASELINE_FILE")
+	tolerances := compare.DefaultTolerances
+	for env, tolerance := range map[string]*float64{
+		"COMPARE_LATENCY_TOLERANCE":    &tolerances.Latency,
+		"COMPARE_THROUGHPUT_TOLERANCE": &tolerances.Throughput,
+		"COMPARE_ERROR_RATE_TOLERANCE": &tolerances.ErrorRate,
+		"COMPARE_ALPHA":                &tolerances.Alpha,
+	} {
+		if value := os.Getenv(env); value != "" {
+			*tolerance, err = compare.ParseRatio(value)
+			if err != nil {
+				panic("Invalid " + env + " value, " + err.Error())
+			}
+		}
+	}
+
+	// every run is saved in the history directory under its run ID
+	historyDir := os.Getenv("HISTORY_DIR")
+	if historyDir == "" {
+		historyDir = "./tmp/runs"
+	}
+	runID := os.Getenv("RUN_ID")
+	if runID == "" {
+		runID = history.NewRunID(time.Now())
+	} else if err := history.ValidateRunID(runID); err != nil {
+		panic("Invalid RUN_ID value, " + err.Error())
+	} else if history.NewStore(historyDir).Exists(runID) {
+		// fail before the run rather than when saving it
+		panic("Invalid RUN_ID value, a run " + runID + " is already saved in " + historyDir)
+	}
+	// seed of the random answers and picks of the run. The users and the negative scenarios share the random source,
+	// so the same seed repeats the draws of a previous run but which user gets which draw depends on their scheduling
+	seed := time.Now().UnixNano()
+	if value := os.Getenv("SEED"); value != "" {
+		seed, err = strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			panic("Invalid SEED value, must be an integer")
+		}
+	}
+	// labels to find the run in the history, e.g. "commit=3f2a1c,env=staging"
+	runLabels, err := history.ParseLabels(os.Getenv("RUN_LABELS"))
+	if err != nil {
+		panic("Invalid RUN_LABELS value, " + err.Error())
+	}
+	// the commit of the working directory labels the run unless set, best effort outside of a git repository
+	if _, ok := runLabels["commit"]; !ok {
+		if commit := history.GitCommit(); commit != "" {
+			runLabels["commit"] = commit
+		}
+	}
+
+	// logs are written from the level on, as text or json records, to stdout or else to the log file
+	logLevel := slog.LevelInfo
+	if value := os.Getenv("LOG_LEVEL"); value != "" {
+		if err := logLevel.UnmarshalText([]byte(value)); err != nil {
+			panic("Invalid LOG_LEVEL value, must be debug, info, warn or error")
+		}
+	}
+	logFormat := os.Getenv("LOG_FORMAT")
+	if logFormat == "" {
+		logFormat = LogFormatText
+	}
+	if logFormat != LogFormatText && logFormat != LogFormatJSON {
+		panic("Invalid LOG_FORMAT value, must be text or json")
+	}
+	logFile := os.Getenv("LOG_FILE")
+	// only the results of the run are printed when quiet, the logs still go to the log file
+	quiet := false
+	if value := os.Getenv("QUIET"); value != "" {
+		quiet, err = strconv.ParseBool(value)
+		if err != nil {
+			panic("Invalid QUIET value, must be a boolean")
+		}
+	}
+
 	// trim trailing slashes
 	baseUrl = strings.TrimSuffix(baseUrl, "/")
 	reportServerBaseUrl = strings.TrimSuffix(reportServerBaseUrl, "/")
@@ -50,5 +379,91 @@ func LoadConfig() *Config {
 		BaseURL:             baseUrl,
 		ReportServerBaseURL: reportServerBaseUrl,
 		NumUsers:            numUsersInt,
+		ArrivalRate:         arrivalRate,
+		LoadProfile:         loadProfile,
+		AnswerKeyFile:       answerKeyFile,
+		AnswerKey:           answerKey,
+		NegativeScenarios:   negativeScenarios,
+		BehaviourProfiles:   behaviourProfiles,
+		FlowFile:            flowFile,
+		Flow:                userFlow,
+		MetricsInterval:     metricsInterval,
+		ContractValidation:  contractValidation,
+		ReportValidation:    reportValidation,
+		ReportMinSize:       reportMinSize,
+		ReportTexts:         reportTexts,
+		ReportStorage:       reportStorage,
+		ReportSampleRate:    reportSampleRate,
+		Capture:             captureMode,
+		CaptureSampleRate:   captureSampleRate,
+		CaptureBodyLimit:    captureBodyLimit,
+		DashboardAddr:       dashboardAddr,
+		MetricsAddr:         metricsAddr,
+		SMTPSinkAddr:        smtpSinkAddr,
+		SMTPSinkWait:        smtpSinkWait,
+		EmailStatusInterval: emailStatusInterval,
+		EmailStatusTimeout:  emailStatusTimeout,
+		Thresholds:          runThresholds,
+		AbortRules:          abortRules,
+		JUnitFile:           junitFile,
+		JUnitSessions:       junitSessions,
+		BaselineFile:        baselineFile,
+		Tolerances:          tolerances,
+		RunID:               runID,
+		Seed:                seed,
+		RunLabels:           runLabels,
+		HistoryDir:          historyDir,
+		LogLevel:            logLevel,
+		LogFormat:           logFormat,
+		LogFile:             logFile,
+		Quiet:               quiet,
+	}
+}
+
+// Values returns the configuration by environment variable name, to describe the run in reports
+func (cfg *Config) Values() map[string]string {
+	return map[string]string{
+		"BASE_URL":              cfg.BaseURL,
+		"REPORT_SERVER_BASEURL": cfg.ReportServerBaseURL,
+		"NUM_USERS":             strconv.Itoa(cfg.NumUsers),
+		"ARRIVAL_RATE":          strconv.FormatFloat(cfg.ArrivalRate, 'f', -1, 64),
+		"LOAD_PROFILE":          cfg.LoadProfile.String(),
+		"METRICS_INTERVAL":      cfg.MetricsInterval.String(),
+		"ANSWER_KEY_FILE":       cfg.AnswerKeyFile,
+		"NEGATIVE_SCENARIOS":    cfg.NegativeScenarios.String(),
+		"BEHAVIOUR_PROFILES":    cfg.BehaviourProfiles.String(),
+		"FLOW_FILE":             cfg.FlowFile,
+		"CONTRACT_VALIDATION":   strconv.FormatBool(cfg.ContractValidation),
+		"REPORT_VALIDATION":     strconv.FormatBool(cfg.ReportValidation),
+		"REPORT_MIN_SIZE":       strconv.Itoa(cfg.ReportMinSize),
+		"REPORT_EXPECTED_TEXTS": strings.Join(cfg.ReportTexts, ","),
+		"REPORT_STORAGE":        string(cfg.ReportStorage),
+		"REPORT_SAMPLE_RATE":    strconv.FormatFloat(cfg.ReportSampleRate, 'f', -1, 64),
+		"CAPTURE":               string(cfg.Capture),
+		"CAPTURE_SAMPLE_RATE":   strconv.FormatFloat(cfg.CaptureSampleRate, 'f', -1, 64),
+		"CAPTURE_BODY_LIMIT":    strconv.Itoa(cfg.CaptureBodyLimit),
+		"DASHBOARD_ADDR":        cfg.DashboardAddr,
+		"METRICS_ADDR":          cfg.MetricsAddr,
+		"SMTP_SINK_ADDR":        cfg.SMTPSinkAddr,
+		"SMTP_SINK_WAIT":        cfg.SMTPSinkWait.String(),
+		"EMAIL_STATUS_INTERVAL": cfg.EmailStatusInterval.String(),
+		"EMAIL_STATUS_TIMEOUT":  cfg.EmailStatusTimeout.String(),
+		"THRESHOLDS":            thresholdsValue(cfg.Thresholds),
+		"ABORT_RULES":           cfg.AbortRules.String(),
+		"JUNIT_FILE":            cfg.JUnitFile,
+		"JUNIT_SESSIONS":        strconv.FormatBool(cfg.JUnitSessions),
+		"BASELINE_FILE":         cfg.BaselineFile,
+		"LOG_LEVEL":             cfg.LogLevel.String(),
+		"LOG_FORMAT":            cfg.LogFormat,
+		"LOG_FILE":              cfg.LogFile,
+		"QUIET":                 strconv.FormatBool(cfg.Quiet),
+	}
+}
+
+func thresholdsValue(runThresholds []thresholds.Threshold) string {
+	expressions := make([]string, 0, len(runThresholds))
+	for _, threshold := range runThresholds {
+		expressions = append(expressions, threshold.String())
 	}
+	return strings.Join(expressions, ",")
 }
@@ -1,10 +1,17 @@
 package app
 
 import (
+	"log/slog"
 	"os"
 	"strconv"
 	"testing"
+	"time"
 
+	"github.com/go-squad-5/quiz-load-test/internal/behaviour"
+	"github.com/go-squad-5/quiz-load-test/internal/capture"
+	"github.com/go-squad-5/quiz-load-test/internal/history"
+	"github.com/go-squad-5/quiz-load-test/internal/negative"
+	"github.com/go-squad-5/quiz-load-test/internal/quizapi"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -72,3 +79,663 @@ func Test_app_config_LoadConfig_WhenInvalidNumUsers(t *testing.T) {
 
 	LoadConfig()
 }
+
+func Test_app_config_LoadConfig_WhenDashboardAddr(t *testing.T) {
+	os.Setenv("NUM_USERS", "10")
+	os.Setenv("DASHBOARD_ADDR", ":9090")
+	defer os.Unsetenv("DASHBOARD_ADDR")
+
+	config := LoadConfig()
+	require.NotNil(t, config, "Expected returned value to be non-nil, but got nil value")
+	assert.Equal(t, ":9090", config.DashboardAddr, "Expected dashboard address to be set from DASHBOARD_ADDR")
+}
+
+func Test_app_config_LoadConfig_WhenMetricsAddr(t *testing.T) {
+	os.Setenv("NUM_USERS", "10")
+	os.Setenv("METRICS_ADDR", ":9091")
+	defer os.Unsetenv("METRICS_ADDR")
+
+	config := LoadConfig()
+	require.NotNil(t, config, "Expected returned value to be non-nil, but got nil value")
+	assert.Equal(t, ":9091", config.MetricsAddr, "Expected metrics address to be set from METRICS_ADDR")
+}
+
+func Test_app_config_Values(t *testing.T) {
+	config := &Config{
+		BaseURL:             "http://localhost:3000",
+		ReportServerBaseURL: "http://localhost:3070",
+		NumUsers:            5,
+	}
+	values := config.Values()
+	assert.Equal(t, "http://localhost:3000", values["BASE_URL"], "Expected the base url value")
+	assert.Equal(t, "http://localhost:3070", values["REPORT_SERVER_BASEURL"], "Expected the report server url value")
+	assert.Equal(t, "5", values["NUM_USERS"], "Expected the number of users value")
+}
+
+func Test_app_config_LoadConfig_WhenThresholdsAndJUnit(t *testing.T) {
+	os.Setenv("NUM_USERS", "10")
+	os.Setenv("THRESHOLDS", "p95<500ms, error_rate<1%")
+	os.Setenv("JUNIT_FILE", "./tmp/junit.xml")
+	os.Setenv("JUNIT_SESSIONS", "true")
+	defer os.Unsetenv("THRESHOLDS")
+	defer os.Unsetenv("JUNIT_FILE")
+	defer os.Unsetenv("JUNIT_SESSIONS")
+
+	config := LoadConfig()
+	require.NotNil(t, config, "Expected returned value to be non-nil, but got nil value")
+	require.Len(t, config.Thresholds, 2, "Expected thresholds to be parsed from THRESHOLDS")
+	assert.Equal(t, "./tmp/junit.xml", config.JUnitFile, "Expected junit file to be set from JUNIT_FILE")
+	assert.True(t, config.JUnitSessions, "Expected junit sessions to be set from JUNIT_SESSIONS")
+	assert.Equal(t, "p95<500ms,error_rate<1%", config.Values()["THRESHOLDS"], "Expected the thresholds in the config values")
+}
+
+func Test_app_config_LoadConfig_WhenInvalidThresholds(t *testing.T) {
+	os.Setenv("NUM_USERS", "10")
+	os.Setenv("THRESHOLDS", "p95 under 500ms")
+	defer os.Unsetenv("THRESHOLDS")
+
+	defer func() {
+		if r := recover(); r == nil {
+			t.Errorf("Expected LoadConfig to panic with invalid thresholds, but it did not")
+		}
+	}()
+
+	LoadConfig()
+}
+
+func Test_app_config_LoadConfig_WhenBaseline(t *testing.T) {
+	os.Setenv("NUM_USERS", "10")
+	os.Setenv("BASELINE_FILE", "./baseline.json")
+	os.Setenv("COMPARE_LATENCY_TOLERANCE", "20%")
+	os.Setenv("COMPARE_ALPHA", "0.01")
+	defer os.Unsetenv("BASELINE_FILE")
+	defer os.Unsetenv("COMPARE_LATENCY_TOLERANCE")
+	defer os.Unsetenv("COMPARE_ALPHA")
+
+	config := LoadConfig()
+	require.NotNil(t, config, "Expected returned value to be non-nil, but got nil value")
+	assert.Equal(t, "./baseline.json", config.BaselineFile, "Expected baseline file to be set from BASELINE_FILE")
+	assert.InDelta(t, 0.2, config.Tolerances.Latency, 1e-9, "Expected latency tolerance to be set from COMPARE_LATENCY_TOLERANCE")
+	assert.InDelta(t, 0.01, config.Tolerances.Alpha, 1e-9, "Expected alpha to be set from COMPARE_ALPHA")
+	assert.InDelta(t, 0.1, config.Tolerances.Throughput, 1e-9, "Expected the default throughput tolerance")
+}
+
+func Test_app_config_LoadConfig_WhenInvalidTolerance(t *testing.T) {
+	os.Setenv("NUM_USERS", "10")
+	os.Setenv("COMPARE_ERROR_RATE_TOLERANCE", "a lot")
+	defer os.Unsetenv("COMPARE_ERROR_RATE_TOLERANCE")
+
+	defer func() {
+		if r := recover(); r == nil {
+			t.Errorf("Expected LoadConfig to panic with invalid tolerance, but it did not")
+		}
+	}()
+
+	LoadConfig()
+}
+
+func Test_app_config_LoadConfig_WhenRunHistory(t *testing.T) {
+	os.Setenv("NUM_USERS", "10")
+	os.Setenv("RUN_ID", "release-1")
+	os.Setenv("SEED", "42")
+	os.Setenv("RUN_LABELS", "commit=3f2a1c,env=staging")
+	os.Setenv("HISTORY_DIR", "./history")
+	defer os.Unsetenv("RUN_ID")
+	defer os.Unsetenv("SEED")
+	defer os.Unsetenv("RUN_LABELS")
+	defer os.Unsetenv("HISTORY_DIR")
+
+	config := LoadConfig()
+	require.NotNil(t, config, "Expected returned value to be non-nil, but got nil value")
+	assert.Equal(t, "release-1", config.RunID, "Expected run id to be set from RUN_ID")
+	assert.Equal(t, int64(42), config.Seed, "Expected seed to be set from SEED")
+	assert.Equal(t, map[string]string{"commit": "3f2a1c", "env": "staging"}, config.RunLabels, "Expected labels to be set from RUN_LABELS")
+	assert.Equal(t, "./history", config.HistoryDir, "Expected history directory to be set from HISTORY_DIR")
+}
+
+func Test_app_config_LoadConfig_WhenNoRunID(t *testing.T) {
+	os.Setenv("NUM_USERS", "10")
+
+	config := LoadConfig()
+	require.NotNil(t, config, "Expected returned value to be non-nil, but got nil value")
+	assert.NotEmpty(t, config.RunID, "Expected a generated run id")
+	assert.Equal(t, "./tmp/runs", config.HistoryDir, "Expected the default history directory")
+}
+
+func Test_app_config_LoadConfig_WhenGitCommit(t *testing.T) {
+	commit := history.GitCommit()
+	if commit == "" {
+		t.Skip("not in a git repository")
+	}
+	os.Setenv("NUM_USERS", "10")
+	os.Setenv("RUN_LABELS", "env=staging")
+	defer os.Unsetenv("RUN_LABELS")
+
+	config := LoadConfig()
+	assert.Equal(t, map[string]string{"commit": commit, "env": "staging"}, config.RunLabels, "Expected the run labelled with the commit")
+}
+
+func Test_app_config_LoadConfig_WhenInvalidRunID(t *testing.T) {
+	historyDir := t.TempDir()
+	_, err := history.NewStore(historyDir).Save(&history.Run{ID: "nightly"})
+	require.NoError(t, err, "Expected the run to be saved")
+
+	for _, runID := range []string{"../x", "nightly"} {
+		t.Run(runID, func(t *testing.T) {
+			os.Setenv("NUM_USERS", "10")
+			os.Setenv("RUN_ID", runID)
+			os.Setenv("HISTORY_DIR", historyDir)
+			defer os.Unsetenv("RUN_ID")
+			defer os.Unsetenv("HISTORY_DIR")
+
+			defer func() {
+				if r := recover(); r == nil {
+					t.Errorf("Expected LoadConfig to panic with run id %s, but it did not", runID)
+				}
+			}()
+
+			LoadConfig()
+		})
+	}
+}
+
+func Test_app_config_LoadConfig_WhenInvalidSeed(t *testing.T) {
+	os.Setenv("NUM_USERS", "10")
+	os.Setenv("SEED", "random")
+	defer os.Unsetenv("SEED")
+
+	defer func() {
+		if r := recover(); r == nil {
+			t.Errorf("Expected LoadConfig to panic with invalid seed, but it did not")
+		}
+	}()
+
+	LoadConfig()
+}
+
+func Test_app_config_LoadConfig_WhenArrivalRate(t *testing.T) {
+	os.Setenv("NUM_USERS", "10")
+	os.Setenv("ARRIVAL_RATE", "2.5")
+	defer os.Unsetenv("ARRIVAL_RATE")
+
+	config := LoadConfig()
+	require.NotNil(t, config, "Expected returned value to be non-nil, but got nil value")
+	assert.Equal(t, 2.5, config.ArrivalRate, "Expected arrival rate to be set from ARRIVAL_RATE")
+	assert.Equal(t, "2.5", config.Values()["ARRIVAL_RATE"], "Expected the arrival rate in the config values")
+}
+
+func Test_app_config_LoadConfig_WhenInvalidArrivalRate(t *testing.T) {
+	os.Setenv("NUM_USERS", "10")
+	os.Setenv("ARRIVAL_RATE", "-1")
+	defer os.Unsetenv("ARRIVAL_RATE")
+
+	defer func() {
+		if r := recover(); r == nil {
+			t.Errorf("Expected LoadConfig to panic with invalid arrival rate, but it did not")
+		}
+	}()
+
+	LoadConfig()
+}
+
+func Test_app_config_LoadConfig_WhenAbortRules(t *testing.T) {
+	os.Setenv("NUM_USERS", "10")
+	os.Setenv("ABORT_RULES", "error_rate>20% for 30s,connection_refused>=5")
+	defer os.Unsetenv("ABORT_RULES")
+
+	config := LoadConfig()
+	require.NotNil(t, config, "Expected returned value to be non-nil, but got nil value")
+	assert.Len(t, config.AbortRules.Conditions, 1, "Expected abort conditions to be set from ABORT_RULES")
+	assert.Equal(t, 5, config.AbortRules.ConnectionRefused, "Expected the refused connections rule from ABORT_RULES")
+}
+
+func Test_app_config_LoadConfig_WhenInvalidAbortRules(t *testing.T) {
+	os.Setenv("NUM_USERS", "10")
+	os.Setenv("ABORT_RULES", "error_rate>20% for a while")
+	defer os.Unsetenv("ABORT_RULES")
+
+	defer func() {
+		if r := recover(); r == nil {
+			t.Errorf("Expected LoadConfig to panic with invalid abort rules, but it did not")
+		}
+	}()
+
+	LoadConfig()
+}
+
+func Test_app_config_LoadConfig_WhenLoadProfile(t *testing.T) {
+	os.Setenv("NUM_USERS", "10")
+	os.Setenv("LOAD_PROFILE", "warmup=ramp(20, 1m),spike(10x, 30s)")
+	defer os.Unsetenv("LOAD_PROFILE")
+
+	config := LoadConfig()
+	require.NotNil(t, config, "Expected returned value to be non-nil, but got nil value")
+	assert.Len(t, config.LoadProfile.Segments, 2, "Expected load profile to be parsed from LOAD_PROFILE")
+	assert.Equal(t, 200, config.LoadProfile.Peak(), "Expected the peak users of the load profile")
+	assert.Equal(t, "warmup=ramp(20, 1m),spike(10x, 30s)", config.Values()["LOAD_PROFILE"], "Expected the load profile in the config values")
+}
+
+func Test_app_config_LoadConfig_WhenInvalidLoadProfile(t *testing.T) {
+	os.Setenv("NUM_USERS", "10")
+	os.Setenv("LOAD_PROFILE", "ramp(20)")
+	defer os.Unsetenv("LOAD_PROFILE")
+
+	defer func() {
+		if r := recover(); r == nil {
+			t.Errorf("Expected LoadConfig to panic with invalid load profile, but it did not")
+		}
+	}()
+
+	LoadConfig()
+}
+
+func Test_app_config_LoadConfig_WhenMetricsInterval(t *testing.T) {
+	os.Setenv("NUM_USERS", "10")
+	config := LoadConfig()
+	assert.Equal(t, time.Second, config.MetricsInterval, "Expected a one second interval by default")
+
+	os.Setenv("METRICS_INTERVAL", "10s")
+	defer os.Unsetenv("METRICS_INTERVAL")
+	config = LoadConfig()
+	assert.Equal(t, 10*time.Second, config.MetricsInterval, "Expected metrics interval to be set from METRICS_INTERVAL")
+	assert.Equal(t, "10s", config.Values()["METRICS_INTERVAL"], "Expected the metrics interval in the config values")
+}
+
+func Test_app_config_LoadConfig_WhenInvalidMetricsInterval(t *testing.T) {
+	os.Setenv("NUM_USERS", "10")
+	os.Setenv("METRICS_INTERVAL", "0s")
+	defer os.Unsetenv("METRICS_INTERVAL")
+
+	defer func() {
+		if r := recover(); r == nil {
+			t.Errorf("Expected LoadConfig to panic with invalid metrics interval, but it did not")
+		}
+	}()
+
+	LoadConfig()
+}
+
+func Test_app_config_LoadConfig_WhenNegativeScenarios(t *testing.T) {
+	os.Setenv("NUM_USERS", "10")
+	config := LoadConfig()
+	assert.True(t, config.NegativeScenarios.Empty(), "Expected no negative scenarios by default")
+
+	os.Setenv("NEGATIVE_SCENARIOS", "double_submit=5%,unknown_session=2%")
+	defer os.Unsetenv("NEGATIVE_SCENARIOS")
+
+	config = LoadConfig()
+	assert.Equal(t, negative.Mix{
+		{Scenario: negative.DoubleSubmit, Ratio: 0.05},
+		{Scenario: negative.UnknownSession, Ratio: 0.02},
+	}, config.NegativeScenarios, "Expected the scenarios to be set from NEGATIVE_SCENARIOS")
+	assert.Equal(t, "double_submit=0.05,unknown_session=0.02", config.Values()["NEGATIVE_SCENARIOS"], "Expected the scenarios in the config values")
+}
+
+func Test_app_config_LoadConfig_WhenInvalidNegativeScenarios(t *testing.T) {
+	os.Setenv("NUM_USERS", "10")
+	os.Setenv("NEGATIVE_SCENARIOS", "drop_tables=5%")
+	defer os.Unsetenv("NEGATIVE_SCENARIOS")
+
+	defer func() {
+		if r := recover(); r == nil {
+			t.Errorf("Expected LoadConfig to panic with an unknown negative scenario, but it did not")
+		}
+	}()
+
+	LoadConfig()
+}
+
+func Test_app_config_LoadConfig_WhenBehaviourProfiles(t *testing.T) {
+	os.Setenv("NUM_USERS", "10")
+	config := LoadConfig()
+	assert.True(t, config.BehaviourProfiles.Empty(), "Expected no behaviour profiles by default")
+
+	os.Setenv("BEHAVIOUR_PROFILES", "complete=70%,abandon=30%")
+	defer os.Unsetenv("BEHAVIOUR_PROFILES")
+
+	config = LoadConfig()
+	assert.Equal(t, behaviour.Mix{
+		{Profile: behaviour.Complete, Weight: 0.7},
+		{Profile: behaviour.Abandon, Weight: 0.3},
+	}, config.BehaviourProfiles, "Expected the profiles to be set from BEHAVIOUR_PROFILES")
+	assert.Equal(t, "complete=0.7,abandon=0.3", config.Values()["BEHAVIOUR_PROFILES"], "Expected the profiles in the config values")
+}
+
+func Test_app_config_LoadConfig_WhenInvalidBehaviourProfiles(t *testing.T) {
+	os.Setenv("NUM_USERS", "10")
+	os.Setenv("BEHAVIOUR_PROFILES", "rage_quit=5%")
+	defer os.Unsetenv("BEHAVIOUR_PROFILES")
+
+	defer func() {
+		if r := recover(); r == nil {
+			t.Errorf("Expected LoadConfig to panic with an unknown behaviour profile, but it did not")
+		}
+	}()
+
+	LoadConfig()
+}
+
+func Test_app_config_LoadConfig_WhenContractValidation(t *testing.T) {
+	os.Setenv("NUM_USERS", "10")
+	config := LoadConfig()
+	assert.False(t, config.ContractValidation, "Expected the contracts not to be validated by default")
+
+	os.Setenv("CONTRACT_VALIDATION", "true")
+	defer os.Unsetenv("CONTRACT_VALIDATION")
+
+	config = LoadConfig()
+	assert.True(t, config.ContractValidation, "Expected contract validation to be set from CONTRACT_VALIDATION")
+	assert.Equal(t, "true", config.Values()["CONTRACT_VALIDATION"], "Expected contract validation in the config values")
+}
+
+func Test_app_config_LoadConfig_WhenInvalidContractValidation(t *testing.T) {
+	os.Setenv("NUM_USERS", "10")
+	os.Setenv("CONTRACT_VALIDATION", "strict")
+	defer os.Unsetenv("CONTRACT_VALIDATION")
+
+	defer func() {
+		if r := recover(); r == nil {
+			t.Errorf("Expected LoadConfig to panic with invalid contract validation, but it did not")
+		}
+	}()
+
+	LoadConfig()
+}
+
+func Test_app_config_LoadConfig_WhenReportValidation(t *testing.T) {
+	os.Setenv("NUM_USERS", "10")
+	config := LoadConfig()
+	assert.False(t, config.ReportValidation, "Expected the reports not to be validated by default")
+	assert.Equal(t, 1024, config.ReportMinSize, "Expected a one kilobyte min size by default")
+
+	os.Setenv("REPORT_VALIDATION", "true")
+	os.Setenv("REPORT_MIN_SIZE", "2048")
+	os.Setenv("REPORT_EXPECTED_TEXTS", "{session_id}, Score: {score}")
+	defer os.Unsetenv("REPORT_VALIDATION")
+	defer os.Unsetenv("REPORT_MIN_SIZE")
+	defer os.Unsetenv("REPORT_EXPECTED_TEXTS")
+
+	config = LoadConfig()
+	assert.True(t, config.ReportValidation, "Expected report validation to be set from REPORT_VALIDATION")
+	assert.Equal(t, 2048, config.ReportMinSize, "Expected min size to be set from REPORT_MIN_SIZE")
+	assert.Equal(t, []string{"{session_id}", "Score: {score}"}, config.ReportTexts, "Expected texts to be set from REPORT_EXPECTED_TEXTS")
+	assert.Equal(t, "{session_id},Score: {score}", config.Values()["REPORT_EXPECTED_TEXTS"], "Expected the texts in the config values")
+}
+
+func Test_app_config_LoadConfig_WhenInvalidReportMinSize(t *testing.T) {
+	os.Setenv("NUM_USERS", "10")
+	os.Setenv("REPORT_MIN_SIZE", "-1")
+	defer os.Unsetenv("REPORT_MIN_SIZE")
+
+	defer func() {
+		if r := recover(); r == nil {
+			t.Errorf("Expected LoadConfig to panic with invalid report min size, but it did not")
+		}
+	}()
+
+	LoadConfig()
+}
+
+func Test_app_config_LoadConfig_WhenReportStorage(t *testing.T) {
+	os.Setenv("NUM_USERS", "10")
+	config := LoadConfig()
+	assert.Equal(t, quizapi.ReportStorageAll, config.ReportStorage, "Expected every report to be saved by default")
+	assert.Equal(t, 0.01, config.ReportSampleRate, "Expected a one percent sample rate by default")
+
+	os.Setenv("REPORT_STORAGE", "sample")
+	os.Setenv("REPORT_SAMPLE_RATE", "5%")
+	defer os.Unsetenv("REPORT_STORAGE")
+	defer os.Unsetenv("REPORT_SAMPLE_RATE")
+
+	config = LoadConfig()
+	assert.Equal(t, quizapi.ReportStorageSample, config.ReportStorage, "Expected the report storage to be set from REPORT_STORAGE")
+	assert.Equal(t, 0.05, config.ReportSampleRate, "Expected the sample rate to be set from REPORT_SAMPLE_RATE")
+	assert.Equal(t, "sample", config.Values()["REPORT_STORAGE"], "Expected the report storage in the config values")
+}
+
+func Test_app_config_LoadConfig_WhenInvalidReportStorage(t *testing.T) {
+	for env, value := range map[string]string{"REPORT_STORAGE": "none", "REPORT_SAMPLE_RATE": "150%"} {
+		t.Run(env, func(t *testing.T) {
+			os.Setenv("NUM_USERS", "10")
+			os.Setenv(env, value)
+			defer os.Unsetenv(env)
+
+			defer func() {
+				if r := recover(); r == nil {
+					t.Errorf("Expected LoadConfig to panic with invalid %s, but it did not", env)
+				}
+			}()
+
+			LoadConfig()
+		})
+	}
+}
+
+func Test_app_config_LoadConfig_WhenReportStorageFailures(t *testing.T) {
+	os.Setenv("NUM_USERS", "10")
+	os.Setenv("REPORT_STORAGE", "failures")
+	defer os.Unsetenv("REPORT_STORAGE")
+
+	func() {
+		defer func() {
+			if r := recover(); r == nil {
+				t.Errorf("Expected LoadConfig to panic with the failures storage without a report check, but it did not")
+			}
+		}()
+		LoadConfig()
+	}()
+
+	os.Setenv("REPORT_VALIDATION", "true")
+	defer os.Unsetenv("REPORT_VALIDATION")
+	config := LoadConfig()
+	assert.Equal(t, quizapi.ReportStorageFailures, config.ReportStorage, "Expected the failures storage with the report validation")
+}
+
+func Test_app_config_LoadConfig_WhenSMTPSink(t *testing.T) {
+	os.Setenv("NUM_USERS", "10")
+	config := LoadConfig()
+	assert.Empty(t, config.SMTPSinkAddr, "Expected the smtp sink to be disabled by default")
+	assert.Equal(t, 30*time.Second, config.SMTPSinkWait, "Expected a 30 seconds wait by default")
+
+	os.Setenv("SMTP_SINK_ADDR", ":2525")
+	os.Setenv("SMTP_SINK_WAIT", "1m")
+	defer os.Unsetenv("SMTP_SINK_ADDR")
+	defer os.Unsetenv("SMTP_SINK_WAIT")
+
+	config = LoadConfig()
+	assert.Equal(t, ":2525", config.SMTPSinkAddr, "Expected the address to be set from SMTP_SINK_ADDR")
+	assert.Equal(t, time.Minute, config.SMTPSinkWait, "Expected the wait to be set from SMTP_SINK_WAIT")
+	assert.Equal(t, "1m0s", config.Values()["SMTP_SINK_WAIT"], "Expected the wait in the config values")
+}
+
+func Test_app_config_LoadConfig_WhenInvalidSMTPSinkWait(t *testing.T) {
+	os.Setenv("NUM_USERS", "10")
+	os.Setenv("SMTP_SINK_WAIT", "soon")
+	defer os.Unsetenv("SMTP_SINK_WAIT")
+
+	defer func() {
+		if r := recover(); r == nil {
+			t.Errorf("Expected LoadConfig to panic with invalid smtp sink wait, but it did not")
+		}
+	}()
+
+	LoadConfig()
+}
+
+func Test_app_config_LoadConfig_WhenEmailStatus(t *testing.T) {
+	os.Setenv("NUM_USERS", "10")
+	config := LoadConfig()
+	assert.Zero(t, config.EmailStatusInterval, "Expected the email status not to be polled by default")
+	assert.Equal(t, 2*time.Minute, config.EmailStatusTimeout, "Expected a two minutes timeout by default")
+
+	os.Setenv("EMAIL_STATUS_INTERVAL", "500ms")
+	os.Setenv("EMAIL_STATUS_TIMEOUT", "30s")
+	defer os.Unsetenv("EMAIL_STATUS_INTERVAL")
+	defer os.Unsetenv("EMAIL_STATUS_TIMEOUT")
+
+	config = LoadConfig()
+	assert.Equal(t, 500*time.Millisecond, config.EmailStatusInterval, "Expected the interval to be set from EMAIL_STATUS_INTERVAL")
+	assert.Equal(t, 30*time.Second, config.EmailStatusTimeout, "Expected the timeout to be set from EMAIL_STATUS_TIMEOUT")
+	assert.Equal(t, "500ms", config.Values()["EMAIL_STATUS_INTERVAL"], "Expected the interval in the config values")
+}
+
+func Test_app_config_LoadConfig_WhenInvalidEmailStatus(t *testing.T) {
+	for env, value := range map[string]string{"EMAIL_STATUS_INTERVAL": "-1s", "EMAIL_STATUS_TIMEOUT": "0s"} {
+		t.Run(env, func(t *testing.T) {
+			os.Setenv("NUM_USERS", "10")
+			os.Setenv(env, value)
+			defer os.Unsetenv(env)
+
+			defer func() {
+				if r := recover(); r == nil {
+					t.Errorf("Expected LoadConfig to panic with invalid %s, but it did not", env)
+				}
+			}()
+
+			LoadConfig()
+		})
+	}
+}
+
+func Test_app_config_LoadConfig_WhenAnswerKeyFile(t *testing.T) {
+	os.Setenv("NUM_USERS", "10")
+	config := LoadConfig()
+	assert.Nil(t, config.AnswerKey, "Expected no answer key by default")
+
+	filePath := t.TempDir() + "/answers.json"
+	require.NoError(t, os.WriteFile(filePath, []byte(`{"q1": "4"}`), 0644), "Expected the answer key to be written")
+	os.Setenv("ANSWER_KEY_FILE", filePath)
+	defer os.Unsetenv("ANSWER_KEY_FILE")
+
+	config = LoadConfig()
+	assert.Equal(t, "4", config.AnswerKey["q1"], "Expected the answer key to be loaded from ANSWER_KEY_FILE")
+	assert.Equal(t, filePath, config.Values()["ANSWER_KEY_FILE"], "Expected the answer key file in the config values")
+}
+
+func Test_app_config_LoadConfig_WhenInvalidAnswerKeyFile(t *testing.T) {
+	os.Setenv("NUM_USERS", "10")
+	os.Setenv("ANSWER_KEY_FILE", "./missing-answers.json")
+	defer os.Unsetenv("ANSWER_KEY_FILE")
+
+	defer func() {
+		if r := recover(); r == nil {
+			t.Errorf("Expected LoadConfig to panic with a missing answer key file, but it did not")
+		}
+	}()
+
+	LoadConfig()
+}
+
+func Test_app_config_LoadConfig_WhenFlowFile(t *testing.T) {
+	os.Setenv("NUM_USERS", "10")
+	config := LoadConfig()
+	assert.Nil(t, config.Flow, "Expected no flow by default")
+
+	filePath := t.TempDir() + "/flow.json"
+	require.NoError(t, os.WriteFile(filePath, []byte(`{"name": "quick", "steps": ["create_session", "start_quiz"]}`), 0644), "Expected the flow to be written")
+	os.Setenv("FLOW_FILE", filePath)
+	defer os.Unsetenv("FLOW_FILE")
+
+	config = LoadConfig()
+	require.NotNil(t, config.Flow, "Expected the flow to be loaded from FLOW_FILE")
+	assert.Equal(t, "quick", config.Flow.Name, "Expected the name of the flow")
+	assert.Equal(t, filePath, config.Values()["FLOW_FILE"], "Expected the flow file in the config values")
+}
+
+func Test_app_config_LoadConfig_WhenInvalidFlowFile(t *testing.T) {
+	os.Setenv("NUM_USERS", "10")
+	filePath := t.TempDir() + "/flow.json"
+	require.NoError(t, os.WriteFile(filePath, []byte(`{"steps": ["start_quiz"]}`), 0644), "Expected the flow to be written")
+	os.Setenv("FLOW_FILE", filePath)
+	defer os.Unsetenv("FLOW_FILE")
+
+	defer func() {
+		if r := recover(); r == nil {
+			t.Errorf("Expected LoadConfig to panic with an invalid flow, but it did not")
+		}
+	}()
+
+	LoadConfig()
+}
+
+func Test_app_config_LoadConfig_WhenLogging(t *testing.T) {
+	os.Setenv("NUM_USERS", "10")
+	config := LoadConfig()
+	assert.Equal(t, slog.LevelInfo, config.LogLevel, "Expected the info level by default")
+	assert.Equal(t, LogFormatText, config.LogFormat, "Expected text logs by default")
+	assert.Empty(t, config.LogFile, "Expected the logs on stdout by default")
+	assert.False(t, config.Quiet, "Expected the logs to be printed by default")
+
+	os.Setenv("LOG_LEVEL", "debug")
+	os.Setenv("LOG_FORMAT", "json")
+	os.Setenv("LOG_FILE", "./tmp/loadtester.log")
+	os.Setenv("QUIET", "true")
+	defer os.Unsetenv("LOG_LEVEL")
+	defer os.Unsetenv("LOG_FORMAT")
+	defer os.Unsetenv("LOG_FILE")
+	defer os.Unsetenv("QUIET")
+
+	config = LoadConfig()
+	assert.Equal(t, slog.LevelDebug, config.LogLevel, "Expected the level to be set from LOG_LEVEL")
+	assert.Equal(t, LogFormatJSON, config.LogFormat, "Expected the format to be set from LOG_FORMAT")
+	assert.Equal(t, "./tmp/loadtester.log", config.LogFile, "Expected the log file to be set from LOG_FILE")
+	assert.True(t, config.Quiet, "Expected the quiet mode to be set from QUIET")
+	assert.Equal(t, "DEBUG", config.Values()["LOG_LEVEL"], "Expected the level in the config values")
+}
+
+func Test_app_config_LoadConfig_WhenInvalidLogging(t *testing.T) {
+	for env, value := range map[string]string{"LOG_LEVEL": "verbose", "LOG_FORMAT": "xml", "QUIET": "maybe"} {
+		t.Run(env, func(t *testing.T) {
+			os.Setenv("NUM_USERS", "10")
+			os.Setenv(env, value)
+			defer os.Unsetenv(env)
+
+			defer func() {
+				if r := recover(); r == nil {
+					t.Errorf("Expected LoadConfig to panic with invalid %s, but it did not", env)
+				}
+			}()
+
+			LoadConfig()
+		})
+	}
+}
+
+func Test_app_config_LoadConfig_WhenCapture(t *testing.T) {
+	os.Setenv("NUM_USERS", "10")
+	config := LoadConfig()
+	assert.Equal(t, capture.Off, config.Capture, "Expected the capture to be off by default")
+	assert.InDelta(t, 0.01, config.CaptureSampleRate, 0.0001, "Expected 1% of the sessions sampled by default")
+	assert.Equal(t, 4096, config.CaptureBodyLimit, "Expected bodies truncated to 4KB by default")
+
+	os.Setenv("CAPTURE", "sample")
+	os.Setenv("CAPTURE_SAMPLE_RATE", "10%")
+	os.Setenv("CAPTURE_BODY_LIMIT", "512")
+	defer os.Unsetenv("CAPTURE")
+	defer os.Unsetenv("CAPTURE_SAMPLE_RATE")
+	defer os.Unsetenv("CAPTURE_BODY_LIMIT")
+
+	config = LoadConfig()
+	assert.Equal(t, capture.Sample, config.Capture, "Expected the mode to be set from CAPTURE")
+	assert.InDelta(t, 0.1, config.CaptureSampleRate, 0.0001, "Expected the rate to be set from CAPTURE_SAMPLE_RATE")
+	assert.Equal(t, 512, config.CaptureBodyLimit, "Expected the limit to be set from CAPTURE_BODY_LIMIT")
+	assert.Equal(t, "sample", config.Values()["CAPTURE"], "Expected the mode in the config values")
+}
+
+func Test_app_config_LoadConfig_WhenInvalidCapture(t *testing.T) {
+	for env, value := range map[string]string{"CAPTURE": "errors", "CAPTURE_SAMPLE_RATE": "150%", "CAPTURE_BODY_LIMIT": "-1"} {
+		t.Run(env, func(t *testing.T) {
+			os.Setenv("NUM_USERS", "10")
+			os.Setenv(env, value)
+			defer os.Unsetenv(env)
+
+			defer func() {
+				if r := recover(); r == nil {
+					t.Errorf("Expected LoadConfig to panic with invalid %s, but it did not", env)
+				}
+			}()
+
+			LoadConfig()
+		})
+	}
+}
@@ -0,0 +1,195 @@
+package app
+
+import (
+	"encoding/csv"
+	"fmt"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/go-squad-5/quiz-load-test/internal/metrics"
+	"github.com/go-squad-5/quiz-load-test/internal/quizapi"
+)
+
+const csvTimeFormat = "2006-01-02T15:04:05.000Z07:00"
+
+var sessionsCSVHeader []string = []string{
+	"session_id", "email", "user_id", "topic", "status", "score",
+	"start_time", "end_time", "duration_ms", "failed_step", "error",
+	"create_session_ms", "start_quiz_ms", "submit_quiz_ms", "get_report_ms", "email_report_ms",
+	"email_status", "email_processing_ms", "behaviour",
+}
+
+var requestsCSVHeader []string = []string{
+	"timestamp", "session_id", "topic", "endpoint", "method", "status",
+	"latency_ms", "bytes", "error",
+}
+
+var timeSeriesCSVHeader []string = []string{
+	"time", "elapsed_s", "stage", "active_users", "endpoint", "requests", "errors",
+	"rps", "error_rate", "mean_ms", "p50_ms", "p90_ms", "p95_ms", "p99_ms", "max_ms",
+}
+
+// csvFile is a csv file safe for concurrent writes
+type csvFile struct {
+	mu     sync.Mutex
+	file   *os.File
+	writer *csv.Writer
+}
+
+func createCSVFile(filePath string, header []string) (*csvFile, error) {
+	file, err := os.Create(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create csv file: %w", err)
+	}
+	f := &csvFile{file: file, writer: csv.NewWriter(file)}
+	if err := f.Write(header); err != nil {
+		file.Close()
+		return nil, err
+	}
+	return f, nil
+}
+
+func (f *csvFile) Write(record []string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.writer.Write(record); err != nil {
+		return fmt.Errorf("failed to write csv record: %w", err)
+	}
+	return nil
+}
+
+func (f *csvFile) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.writer.Flush()
+	if err := f.writer.Error(); err != nil {
+		f.file.Close()
+		return fmt.Errorf("failed to flush csv file: %w", err)
+	}
+	return f.file.Close()
+}
+
+func openSessionsCSV(dirPath string) *csvFile {
+	mustInitDir(dirPath)
+
+	file, err := createCSVFile(fmt.Sprintf("%s/sessions.csv", dirPath), sessionsCSVHeader)
+	if err != nil {
+		panic("Failed to create sessions csv file: " + err.Error())
+	}
+	return file
+}
+
+// OpenRequestsCSV creates the requests csv file, every request sent afterwards is written as a row
+func (app *App) OpenRequestsCSV() error {
+	mustInitDir(app.outputDir())
+
+	file, err := createCSVFile(fmt.Sprintf("%s/requests.csv", app.outputDir()), requestsCSVHeader)
+	if err != nil {
+		return err
+	}
+	app.requestsCSV = file
+	return nil
+}
+
+func getSessionRecord(session *Session) []string {
+	errMessage := ""
+	if session.Error != nil {
+		errMessage = session.Error.Error()
+	}
+	apisTimeTaken := []string{"", "", "", "", ""}
+	if t := session.APIsTimeTaken; t != nil {
+		apisTimeTaken = []string{
+			strconv.FormatInt(t.SessionCreation, 10),
+			strconv.FormatInt(t.StartQuiz, 10),
+			strconv.FormatInt(t.SubmitQuiz, 10),
+			strconv.FormatInt(t.ReportAPI, 10),
+			strconv.FormatInt(t.EmailAPI, 10),
+		}
+	}
+	record := append([]string{
+		session.ID,
+		session.Email,
+		session.UserID,
+		session.Topic,
+		string(session.Status),
+		strconv.Itoa(session.Score),
+		time.UnixMilli(session.StartTime).Format(csvTimeFormat),
+		time.UnixMilli(session.EndTime).Format(csvTimeFormat),
+		strconv.FormatInt(session.EndTime-session.StartTime, 10),
+		session.FailedStep,
+		errMessage,
+	}, apisTimeTaken...)
+	return append(record, session.EmailStatus, strconv.FormatInt(session.EmailProcessingTime, 10), string(session.Behaviour))
+}
+
+func getRequestRecord(info quizapi.RequestInfo, topic string) []string {
+	errMessage := ""
+	if info.Err != nil {
+		errMessage = info.Err.Error()
+	}
+	return []string{
+		info.StartTime.Format(csvTimeFormat),
+		info.SessionID,
+		topic,
+		info.Endpoint,
+		info.Method,
+		strconv.Itoa(info.StatusCode),
+		strconv.FormatFloat(float64(info.Duration.Microseconds())/1000, 'f', 3, 64),
+		strconv.FormatInt(info.Bytes, 10),
+		errMessage,
+	}
+}
+
+// WriteTimeSeriesCSV writes the metrics of every interval of the run, a row per endpoint
+func (app *App) WriteTimeSeriesCSV(summary *metrics.Summary) (string, error) {
+	mustInitDir(app.outputDir())
+
+	filePath := fmt.Sprintf("%s/timeseries.csv", app.outputDir())
+	file, err := createCSVFile(filePath, timeSeriesCSVHeader)
+	if err != nil {
+		return "", err
+	}
+	for _, record := range getTimeSeriesRecords(summary.TimeSeries, summary.StartedAt) {
+		if err := file.Write(record); err != nil {
+			file.Close()
+			return "", err
+		}
+	}
+	if err := file.Close(); err != nil {
+		return "", err
+	}
+	return filePath, nil
+}
+
+func getTimeSeriesRecords(snapshots []metrics.Snapshot, startedAt time.Time) [][]string {
+	ms := func(v float64) string { return strconv.FormatFloat(v, 'f', 3, 64) }
+	records := [][]string{}
+	for _, snapshot := range snapshots {
+		for _, stats := range snapshot.Endpoints {
+			errorRate := 0.0
+			if stats.Count > 0 {
+				errorRate = float64(stats.Errors) / float64(stats.Count)
+			}
+			records = append(records, []string{
+				snapshot.Time.Format(csvTimeFormat),
+				strconv.FormatFloat(snapshot.Time.Sub(startedAt).Seconds(), 'f', 3, 64),
+				snapshot.Stage,
+				strconv.FormatInt(snapshot.ActiveUsers, 10),
+				stats.Endpoint,
+				strconv.FormatUint(stats.Count, 10),
+				strconv.FormatUint(stats.Errors, 10),
+				strconv.FormatFloat(stats.RPS, 'f', 3, 64),
+				strconv.FormatFloat(errorRate, 'f', 4, 64),
+				ms(stats.Mean),
+				ms(stats.P50),
+				ms(stats.P90),
+				ms(stats.P95),
+				ms(stats.P99),
+				ms(stats.Max),
+			})
+		}
+	}
+	return records
+}
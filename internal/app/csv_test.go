@@ -0,0 +1,158 @@
+package app
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/go-squad-5/quiz-load-test/internal/behaviour"
+	"github.com/go-squad-5/quiz-load-test/internal/metrics"
+	"github.com/go-squad-5/quiz-load-test/internal/quizapi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func readCSV(t *testing.T, filePath string) [][]string {
+	file, err := os.Open(filePath)
+	require.NoError(t, err, "Expected to open the csv file")
+	defer file.Close()
+
+	records, err := csv.NewReader(file).ReadAll()
+	require.NoError(t, err, "Expected a valid csv file")
+	return records
+}
+
+func Test_app_csv_GetSessionRecord(t *testing.T) {
+	start := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	session := &Session{
+		ID:         "1234",
+		Email:      "test@example.com",
+		UserID:     "user_test@example.com",
+		Topic:      "go",
+		Status:     STATUS_FAILED,
+		StartTime:  start.UnixMilli(),
+		EndTime:    start.Add(1500 * time.Millisecond).UnixMilli(),
+		FailedStep: quizapi.EndpointSubmitQuiz,
+		Error:      errors.New("failed to submit quiz, status code: 500"),
+		Behaviour:  behaviour.RetrySubmit,
+		APIsTimeTaken: &APIsTimeTaken{
+			SessionCreation: 10,
+			StartQuiz:       20,
+			SubmitQuiz:      30,
+		},
+	}
+
+	record := getSessionRecord(session)
+	require.Len(t, record, len(sessionsCSVHeader), "Expected a value for every column")
+	assert.Equal(t, "1234", record[0], "Expected the session id")
+	assert.Equal(t, "failed", record[4], "Expected the session status")
+	assert.Equal(t, "1500", record[8], "Expected the session duration")
+	assert.Equal(t, quizapi.EndpointSubmitQuiz, record[9], "Expected the failed step")
+	assert.Equal(t, "failed to submit quiz, status code: 500", record[10], "Expected the error message")
+	assert.Equal(t, []string{"10", "20", "30", "0", "0"}, record[11:16], "Expected the apis time taken")
+	assert.Equal(t, []string{"", "0"}, record[16:18], "Expected no email status when not polled")
+	assert.Equal(t, "retry_submit", record[18], "Expected the behaviour profile")
+
+	session.APIsTimeTaken = nil
+	record = getSessionRecord(session)
+	assert.Equal(t, []string{"", "", "", "", ""}, record[11:16], "Expected empty apis time taken when not available")
+
+	session.SetEmailStatus("sent")
+	session.SetEmailProcessingTime(2500)
+	record = getSessionRecord(session)
+	assert.Equal(t, []string{"sent", "2500"}, record[16:18], "Expected the email status and processing time")
+}
+
+func Test_app_csv_ListenForResults_WritesSessionsCSV(t *testing.T) {
+	tmpDirPath = "./test" // change path for the test environment
+	defer func() {
+		if err := os.RemoveAll(tmpDirPath); err != nil && !os.IsNotExist(err) {
+			t.Fatalf("Error cleaning up the test files: %s", tmpDirPath)
+		}
+	}()
+
+	app := NewTestApp()
+	app.ResultListener.Add(1)
+	go app.ListenForResults()
+
+	app.Results <- &Session{ID: "1", Email: "test1@example.com", Topic: "go", Status: STATUS_COMPLETED, Score: 4}
+	app.Results <- &Session{ID: "2", Email: "test2@example.com", Topic: "c", Status: STATUS_FAILED, Error: fmt.Errorf("session error")}
+	close(app.Results)
+	app.ResultListener.Wait()
+
+	records := readCSV(t, fmt.Sprintf("%s/sessions.csv", tmpDirPath))
+	require.Len(t, records, 3, "Expected the header and a row per session")
+	assert.Equal(t, sessionsCSVHeader, records[0], "Expected the header first")
+	assert.Equal(t, "4", records[1][5], "Expected the score of the completed session")
+	assert.Equal(t, "session error", records[2][10], "Expected the error of the failed session")
+}
+
+func Test_app_csv_OpenRequestsCSV(t *testing.T) {
+	tmpDirPath = "./test" // change path for the test environment
+	defer func() {
+		if err := os.RemoveAll(tmpDirPath); err != nil && !os.IsNotExist(err) {
+			t.Fatalf("Error cleaning up the test files: %s", tmpDirPath)
+		}
+	}()
+
+	app := NewTestApp()
+	require.NoError(t, app.OpenRequestsCSV(), "Expected the requests csv file to be created")
+
+	session := &Session{ID: "1234", Topic: "go"}
+	app.sessions.Store(session.ID, session)
+	app.observeRequest(quizapi.RequestInfo{
+		Endpoint:   quizapi.EndpointSubmitQuiz,
+		SessionID:  "1234",
+		Method:     "POST",
+		StatusCode: 500,
+		StartTime:  time.Now(),
+		Duration:   1500 * time.Microsecond,
+		Bytes:      42,
+		Err:        errors.New("failed to submit quiz, status code: 500"),
+	})
+	app.Stop()
+
+	records := readCSV(t, fmt.Sprintf("%s/requests.csv", tmpDirPath))
+	require.Len(t, records, 2, "Expected the header and a row per request")
+	assert.Equal(t, requestsCSVHeader, records[0], "Expected the header first")
+	assert.Equal(t, []string{"1234", "go", quizapi.EndpointSubmitQuiz, "POST", "500", "1.500", "42", "failed to submit quiz, status code: 500"}, records[1][1:], "Expected the request values")
+}
+
+func Test_app_csv_WriteTimeSeriesCSV(t *testing.T) {
+	tmpDirPath = "./test" // change path for the test environment
+	defer func() {
+		if err := os.RemoveAll(tmpDirPath); err != nil && !os.IsNotExist(err) {
+			t.Fatalf("Error cleaning up the test files: %s", tmpDirPath)
+		}
+	}()
+
+	start := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	summary := &metrics.Summary{
+		StartedAt: start,
+		TimeSeries: []metrics.Snapshot{
+			{Time: start.Add(time.Second), ActiveUsers: 3, Stage: "warmup", Endpoints: []metrics.EndpointStats{
+				{Endpoint: "create_session", Count: 4, Errors: 1, RPS: 4, P95: 12.5},
+				{Endpoint: "start_quiz"},
+			}},
+			{Time: start.Add(2 * time.Second), ActiveUsers: 5, Endpoints: []metrics.EndpointStats{
+				{Endpoint: "create_session", Count: 2, RPS: 2, P95: 20},
+				{Endpoint: "start_quiz", Count: 1, RPS: 1, P95: 30},
+			}},
+		},
+	}
+
+	app := NewTestApp()
+	filePath, err := app.WriteTimeSeriesCSV(summary)
+	require.NoError(t, err, "Expected the time series csv to be written")
+
+	records := readCSV(t, filePath)
+	require.Len(t, records, 5, "Expected the header and a row per interval and endpoint")
+	assert.Equal(t, timeSeriesCSVHeader, records[0], "Expected the header first")
+	assert.Equal(t, []string{"1.000", "warmup", "3", "create_session", "4", "1", "4.000", "0.2500"}, records[1][1:9], "Expected the interval metrics of the endpoint")
+	assert.Equal(t, "12.500", records[1][12], "Expected the p95 latency of the endpoint")
+	assert.Equal(t, "0.0000", records[2][8], "Expected no error rate without requests")
+	assert.Equal(t, []string{"2.000", "", "5", "start_quiz"}, records[4][1:5], "Expected the rows of the next interval")
+}
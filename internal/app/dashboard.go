@@ -0,0 +1,14 @@
+package app
+
+import "github.com/go-squad-5/quiz-load-test/internal/dashboard"
+
+// StartDashboard serves the live metrics dashboard on the configured address
+func (app *App) StartDashboard() error {
+	app.Dashboard = dashboard.NewServer(app.Config.DashboardAddr, app.Metrics)
+	if err := app.Dashboard.Start(); err != nil {
+		app.Dashboard = nil
+		return err
+	}
+	app.Logger.Info("Dashboard listening", "addr", app.Dashboard.Addr())
+	return nil
+}
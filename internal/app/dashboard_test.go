@@ -0,0 +1,37 @@
+package app
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_app_dashboard_StartDashboard(t *testing.T) {
+	app := NewTestApp()
+	app.Config.DashboardAddr = "127.0.0.1:0"
+
+	require.NoError(t, app.StartDashboard(), "Expected the dashboard to start")
+	require.NotNil(t, app.Dashboard, "Expected the dashboard server to be set")
+
+	resp, err := http.Get("http://" + app.Dashboard.Addr() + "/")
+	require.NoError(t, err, "Expected the dashboard to be served")
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode, "Expected status 200 for the dashboard page")
+
+	close(app.Errors)
+	close(app.Results)
+	require.NotPanics(t, func() {
+		app.Metrics.Stop()
+		app.Dashboard.Shutdown(t.Context())
+	}, "Expected the dashboard to shutdown")
+}
+
+func Test_app_dashboard_StartDashboard_WhenInvalidAddr(t *testing.T) {
+	app := NewTestApp()
+	app.Config.DashboardAddr = "invalid-address"
+
+	require.Error(t, app.StartDashboard(), "Expected an error for an invalid address")
+	assert.Nil(t, app.Dashboard, "Expected no dashboard server when it failed to start")
+}
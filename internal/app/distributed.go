@@ -0,0 +1,91 @@
+package app
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/go-squad-5/quiz-load-test/internal/distributed"
+	"github.com/go-squad-5/quiz-load-test/internal/history"
+	"github.com/go-squad-5/quiz-load-test/internal/loadprofile"
+)
+
+// WorkerConfig returns the configuration of a worker running its share of the job,
+// the settings other than the load come from the configuration of the worker.
+// The outputs of the job are written to a directory named after its run id, in the output directory of the worker
+func WorkerConfig(cfg *Config, job distributed.Job) (*Config, error) {
+	profile, err := loadprofile.Parse(job.LoadProfile)
+	if err != nil {
+		return nil, fmt.Errorf("invalid load profile: %w", err)
+	}
+	workerCfg := *cfg
+	workerCfg.RunID = fmt.Sprintf("%s-worker-%d", job.RunID, job.Worker+1)
+	// the run id comes from the coordinator, it must not lead the outputs out of the output directory
+	if err := history.ValidateRunID(workerCfg.RunID); err != nil {
+		return nil, err
+	}
+	workerCfg.OutputDir = cfg.OutputDirOf(workerCfg.RunID)
+	workerCfg.Worker = job.Worker
+	workerCfg.Workers = job.Workers
+	workerCfg.NumUsers = distributed.Share(job.NumUsers, job.Worker, job.Workers)
+	workerCfg.ArrivalRate = job.ArrivalRate / float64(job.Workers)
+	workerCfg.LoadProfile = distributed.SplitProfile(profile, job.Worker, job.Workers)
+	// the workers draw different random answers from the same seed
+	workerCfg.Seed = job.Seed + int64(job.Worker)
+	if job.MetricsInterval > 0 {
+		workerCfg.MetricsInterval = job.MetricsInterval
+	}
+	return &workerCfg, nil
+}
+
+// DistributedJob returns the configured load as a job to split across the workers, which start their users at startAt
+func (app *App) DistributedJob(startAt time.Time) distributed.Job {
+	return distributed.Job{
+		RunID:       app.Config.RunID,
+		NumUsers:    app.Config.NumUsers,
+		ArrivalRate: app.Config.ArrivalRate,
+		LoadProfile: app.Config.LoadProfile.Expression,
+		Seed:        app.Config.Seed,
+		StartAt:     startAt,
+		// the time series of the workers are merged by interval
+		MetricsInterval: app.Config.MetricsInterval,
+	}
+}
+
+// WorkerResult returns what the app observed running the job of a worker, to send back to the coordinator
+func (app *App) WorkerResult() *distributed.Result {
+	return &distributed.Result{
+		Worker:            app.Config.Worker,
+		StartedAt:         app.StartedAt,
+		FinishedAt:        app.FinishedAt,
+		Endpoints:         app.Metrics.Totals(),
+		IntendedEndpoints: app.Metrics.IntendedTotals(),
+		Sessions:          app.SessionStats.Aggregate(),
+		NegativeScenarios: app.NegativeStats.Scenarios(),
+		AbortReason:       app.Abort.Reason(),
+		TimeSeries:        app.Metrics.Intervals(),
+		Stages:            app.Metrics.StageTotals(app.FinishedAt),
+	}
+}
+
+// MergeWorkerResults merges the results of the workers of a distributed run into the metrics of the app,
+// the run lasts from the first worker start to the last worker finish
+func (app *App) MergeWorkerResults(results []*distributed.Result) {
+	for _, result := range results {
+		if app.StartedAt.IsZero() || result.StartedAt.Before(app.StartedAt) {
+			app.StartedAt = result.StartedAt
+		}
+		if result.FinishedAt.After(app.FinishedAt) {
+			app.FinishedAt = result.FinishedAt
+		}
+	}
+	for _, result := range results {
+		// the intervals of the workers are flushed from their start, merged by the time since the start of the run
+		app.Metrics.MergeTimeSeries(app.StartedAt, app.Config.MetricsInterval, result.TimeSeries, result.Stages)
+		app.Metrics.Merge(result.Endpoints, result.IntendedEndpoints)
+		app.SessionStats.Merge(result.Sessions)
+		app.NegativeStats.Merge(result.NegativeScenarios)
+		if result.AbortReason != "" && app.workerAbortReason == "" {
+			app.workerAbortReason = fmt.Sprintf("worker %d: %s", result.Worker+1, result.AbortReason)
+		}
+	}
+}
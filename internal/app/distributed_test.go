@@ -0,0 +1,154 @@
+package app
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-squad-5/quiz-load-test/internal/distributed"
+	"github.com/go-squad-5/quiz-load-test/internal/metrics"
+	"github.com/go-squad-5/quiz-load-test/internal/quizapi/mock"
+	"github.com/stretchr/testify/assert"
+	testifymock "github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_app_distributed_WorkerConfig(t *testing.T) {
+	cfg := &Config{BaseURL: "http://worker:8080", RunID: "local", NumUsers: 3, Seed: 7}
+	job := distributed.Job{RunID: "run", Worker: 1, Workers: 2, NumUsers: 11, ArrivalRate: 5, LoadProfile: "ramp(5, 10s)", Seed: 42, MetricsInterval: 10 * time.Second}
+
+	workerCfg, err := WorkerConfig(cfg, job)
+	require.NoError(t, err, "Expected the config of the worker")
+	assert.Equal(t, "http://worker:8080", workerCfg.BaseURL, "Expected the settings of the worker kept")
+	assert.Equal(t, "run-worker-2", workerCfg.RunID, "Expected the run id labelled with the worker")
+	assert.Equal(t, 5, workerCfg.NumUsers, "Expected the share of the users of the worker")
+	assert.InDelta(t, 2.5, workerCfg.ArrivalRate, 0.001, "Expected the share of the arrival rate of the worker")
+	assert.Equal(t, 2, workerCfg.LoadProfile.Segments[0].To, "Expected the share of the load profile of the worker")
+	assert.Equal(t, int64(43), workerCfg.Seed, "Expected the seed offset by the worker")
+	assert.Equal(t, 1, workerCfg.Worker, "Expected the index of the worker")
+	assert.Equal(t, 2, workerCfg.Workers, "Expected the number of workers")
+	assert.Equal(t, 10*time.Second, workerCfg.MetricsInterval, "Expected the interval of the run, to merge the time series")
+	assert.Equal(t, filepath.Join(tmpDirPath, "run-worker-2"), workerCfg.OutputDir, "Expected the outputs of the job in its own directory")
+	assert.Equal(t, "local", cfg.RunID, "Expected the config of the worker to be unchanged")
+}
+
+func Test_app_distributed_WorkerConfig_WhenInvalidProfile(t *testing.T) {
+	_, err := WorkerConfig(&Config{}, distributed.Job{Workers: 1, LoadProfile: "ramp("})
+	assert.Error(t, err, "Expected an invalid load profile to fail")
+}
+
+func Test_app_distributed_WorkerConfig_WhenInvalidRunID(t *testing.T) {
+	_, err := WorkerConfig(&Config{}, distributed.Job{RunID: "../run", Workers: 1})
+	assert.Error(t, err, "Expected a run id leading out of the output directory to fail")
+}
+
+func Test_app_distributed_WorkerConfig_WhenTwoWorkers(t *testing.T) {
+	cfg := NewTestApp().Config
+	cfg.OutputDir = t.TempDir()
+	job := distributed.Job{RunID: "run", Workers: 2, NumUsers: 4}
+
+	// both workers run their job in the same process, as two workers sharing a working directory would
+	apps := []*App{}
+	wg := &sync.WaitGroup{}
+	for worker := range job.Workers {
+		job.Worker = worker
+		workerCfg, err := WorkerConfig(cfg, job)
+		require.NoError(t, err, "Expected the config of the worker")
+		app := NewTestApp()
+		app.Config = workerCfg
+		mockApp, ok := app.QuizAPI.(*mock.MockQuizAPI)
+		require.True(t, ok, "Error while getting the mock quizapi")
+		mockApp.On("CreateSession", testifymock.Anything, testifymock.Anything).Return("", errors.New("connection refused"))
+		apps = append(apps, app)
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			app.Run()
+		}()
+	}
+	wg.Wait()
+
+	for worker, app := range apps {
+		dir := filepath.Join(cfg.OutputDir, "run-worker-"+string(rune('1'+worker)))
+		assert.Equal(t, dir, app.Config.OutputDir, "Expected the outputs of the job in its own directory")
+		assert.FileExists(t, filepath.Join(dir, "logs.txt"), "Expected the logs of the job")
+
+		data, err := os.ReadFile(filepath.Join(dir, "sessions.csv"))
+		require.NoError(t, err, "Expected the sessions csv file of the job")
+		rows, err := csv.NewReader(bytes.NewReader(data)).ReadAll()
+		require.NoError(t, err, "Expected a valid sessions csv file")
+		assert.Len(t, rows, 3, "Expected the header and a row per session of the job")
+		// the users are numbered across the workers, every worker has its own users
+		assert.Contains(t, string(data), EMAILS[worker], "Expected the sessions of the users of the job")
+		assert.NotContains(t, string(data), EMAILS[1-worker], "Expected no session of the other job")
+	}
+}
+
+func Test_app_distributed_WorkerConfig_WhenNoOutputDir(t *testing.T) {
+	cfg := NewTestApp().Config
+	// neither the output directory nor the directory of the job exist before the job runs
+	cfg.OutputDir = filepath.Join(t.TempDir(), "fresh-tmp")
+	workerCfg, err := WorkerConfig(cfg, distributed.Job{RunID: "run", Workers: 1, NumUsers: 1})
+	require.NoError(t, err, "Expected the config of the worker")
+
+	app := NewTestApp()
+	app.Config = workerCfg
+	mockApp, ok := app.QuizAPI.(*mock.MockQuizAPI)
+	require.True(t, ok, "Error while getting the mock quizapi")
+	mockApp.On("CreateSession", testifymock.Anything, testifymock.Anything).Return("", errors.New("connection refused"))
+
+	// a directory failing to be created panics in the results listener, crashing the worker
+	app.Run()
+
+	assert.FileExists(t, filepath.Join(cfg.OutputDir, "run-worker-1", "logs.txt"), "Expected the directories of the job to be created")
+}
+
+func Test_app_distributed_userIndex(t *testing.T) {
+	assert.Equal(t, 3, (&Config{}).userIndex(3), "Expected the index unchanged without workers")
+	cfg := &Config{Worker: 1, Workers: 3}
+	assert.Equal(t, []int{1, 4, 7}, []int{cfg.userIndex(0), cfg.userIndex(1), cfg.userIndex(2)}, "Expected the workers to take the users in turn")
+}
+
+func Test_app_distributed_MergeWorkerResults(t *testing.T) {
+	app := NewTestApp()
+	start := time.Now()
+
+	results := []*distributed.Result{}
+	sessions := []metrics.SessionResult{
+		{Status: "completed", Topic: "go", Score: 5},
+		{Status: "failed", Topic: "go", FailedStep: "start_quiz", Error: "timeout"},
+	}
+	for i, session := range sessions {
+		worker := NewTestApp()
+		worker.Config.Worker = i
+		worker.StartedAt = start.Add(time.Duration(i) * time.Second)
+		worker.FinishedAt = start.Add(time.Duration(10-i) * time.Second)
+		worker.Metrics.SetStage("base", worker.StartedAt)
+		worker.Metrics.Observe(metrics.Observation{Endpoint: "create_session", Latency: time.Millisecond})
+		worker.Metrics.Flush(worker.StartedAt.Add(time.Second))
+		worker.SessionStats.Add(session)
+		worker.NegativeStats.Add("double_submit", 409, true, false)
+		results = append(results, worker.WorkerResult())
+	}
+	results[1].AbortReason = "error rate above 10%"
+	app.MergeWorkerResults(results)
+
+	summary := app.Summary()
+	assert.True(t, start.Equal(summary.StartedAt), "Expected the run to start with the first worker")
+	assert.InDelta(t, 10, summary.Seconds, 0.001, "Expected the run to end with the last worker")
+	assert.Equal(t, uint64(2), summary.Requests, "Expected the requests of every worker")
+	assert.Equal(t, metrics.SessionCounts{Total: 2, Completed: 1, Failed: 1}, summary.Sessions, "Expected the sessions of every worker")
+	require.Len(t, summary.NegativeScenarios, 1, "Expected the negative scenarios of every worker")
+	assert.Equal(t, 2, summary.NegativeScenarios[0].Runs, "Expected the negative scenarios of every worker")
+	assert.Equal(t, "worker 2: error rate above 10%", summary.AbortReason, "Expected the abort reason of the aborted worker")
+	require.Len(t, summary.TimeSeries, 1, "Expected the first intervals of the workers merged")
+	assert.Equal(t, uint64(2), summary.TimeSeries[0].Requests, "Expected the requests of every worker in the interval")
+	require.Len(t, summary.Stages, 1, "Expected the stages of the workers merged")
+	assert.Equal(t, uint64(2), summary.Stages[0].Requests, "Expected the requests of the stage on every worker")
+}
@@ -0,0 +1,44 @@
+package app
+
+import (
+	"time"
+
+	"github.com/go-squad-5/quiz-load-test/internal/metrics"
+	"github.com/go-squad-5/quiz-load-test/internal/smtpsink"
+)
+
+// StartEmailSink receives the emails of the report server on the configured address,
+// the report server must be configured to send its emails to it
+func (app *App) StartEmailSink() error {
+	sink, err := smtpsink.Listen(app.Config.SMTPSinkAddr)
+	if err != nil {
+		return err
+	}
+	app.EmailSink = sink
+	app.Logger.Info("SMTP sink listening", "addr", sink.Addr())
+	return nil
+}
+
+// WaitForEmails waits for the emails of the accepted email report requests, at most the configured wait, then stops the sink
+func (app *App) WaitForEmails() {
+	app.Logger.Info("Waiting for the emails of the sessions...", "wait", app.Config.SMTPSinkWait)
+	if !app.EmailSink.Wait(app.Config.SMTPSinkWait) {
+		app.Logger.Warn("Some emails were not received in time", "wait", app.Config.SMTPSinkWait)
+	}
+	if err := app.EmailSink.Close(); err != nil {
+		app.Logger.Error("Failed to stop the smtp sink", "error", err)
+	}
+}
+
+func (app *App) emailDelivery() *metrics.EmailDeliveryStats {
+	latencies := []time.Duration{}
+	missing := []string{}
+	for _, delivery := range app.EmailSink.Deliveries() {
+		if delivery.Delivered() {
+			latencies = append(latencies, delivery.Latency())
+		} else {
+			missing = append(missing, delivery.SessionID)
+		}
+	}
+	return metrics.NewEmailDeliveryStats(latencies, missing, app.EmailSink.Unmatched())
+}
@@ -0,0 +1,49 @@
+package app
+
+import (
+	"net/smtp"
+	"testing"
+	"time"
+
+	"github.com/go-squad-5/quiz-load-test/internal/quizapi/mock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_app_email_StartEmailSink(t *testing.T) {
+	app := NewTestApp()
+	app.Config.SMTPSinkAddr = "127.0.0.1:0"
+	app.Config.SMTPSinkWait = time.Second
+
+	require.NoError(t, app.StartEmailSink(), "Expected the smtp sink to start")
+	require.NotNil(t, app.EmailSink, "Expected the smtp sink to be set")
+
+	mockApp, ok := app.QuizAPI.(*mock.MockQuizAPI)
+	require.True(t, ok, "Error while getting the mock quizapi")
+	for _, ssid := range []string{"1234", "5678"} {
+		mockApp.On("GetEmailReport", ssid).Return("Email report request accepted", nil)
+		session := NewSession("test@example.com", "go", nil)
+		session.SetSession(ssid)
+		_, err := app.callGetEmail(session)
+		require.NoError(t, err, "Expected the email request to be accepted")
+	}
+
+	message := []byte("Subject: Quiz report of the session 1234\r\n\r\nYour report is attached\r\n")
+	require.NoError(t, smtp.SendMail(app.EmailSink.Addr(), nil, "reports@example.com", []string{"test@example.com"}, message), "Expected the email to be received")
+
+	app.WaitForEmails()
+	delivery := app.Summary().EmailDelivery
+	require.NotNil(t, delivery, "Expected the email delivery in the summary")
+	assert.Equal(t, 2, delivery.Expected, "Expected an email per accepted request")
+	assert.Equal(t, 1, delivery.Delivered, "Expected the email of the session 1234")
+	assert.Equal(t, []string{"5678"}, delivery.MissingSessions, "Expected the email of the session 5678 to be missing")
+}
+
+func Test_app_email_StartEmailSink_WhenInvalidAddr(t *testing.T) {
+	app := NewTestApp()
+	app.Config.SMTPSinkAddr = "invalid-address"
+
+	require.Error(t, app.StartEmailSink(), "Expected an error for an invalid address")
+	assert.Nil(t, app.EmailSink, "Expected no smtp sink when it failed to start")
+	assert.Nil(t, app.Summary().EmailDelivery, "Expected no email delivery without the smtp sink")
+}
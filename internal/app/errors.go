@@ -1,6 +1,10 @@
 package app
 
-import "fmt"
+import (
+	"fmt"
+
+	"github.com/go-squad-5/quiz-load-test/internal/quizapi"
+)
 
 type SessionError struct {
 	Session *Session
@@ -21,18 +25,23 @@ func (e *StartSessionError) Error() string {
 		" on topic: ", e.Topic, " \n Error: ", e.err, "\n")
 }
 
+func (e *StartSessionError) Unwrap() error {
+	return e.err
+}
+
 func (app *App) ListenForErrors() {
 	defer app.ErrorListener.Done()
-	defer app.InfoLogger.Println("GO ROUTINE FINISHED for listening to errors")
+	defer app.Logger.Debug("GO ROUTINE FINISHED for listening to errors")
 	for err := range app.Errors {
 		switch e := err.(type) {
 		case *StartSessionError:
 			app.Results <- &Session{
-				ID:     "",
-				Email:  e.Email,
-				Topic:  e.Topic,
-				Status: STATUS_FAILED,
-				Error:  err,
+				ID:         "",
+				Email:      e.Email,
+				Topic:      e.Topic,
+				Status:     STATUS_FAILED,
+				Error:      err,
+				FailedStep: failedStep(quizapi.EndpointCreateSession, e.err),
 			}
 		case *SessionError:
 			app.Results <- e.Session
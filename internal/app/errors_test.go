@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"testing"
 
+	"github.com/go-squad-5/quiz-load-test/internal/quizapi"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -28,6 +29,12 @@ func Test_app_errors_StartSessionError_Error(t *testing.T) {
 	assert.Equalf(t, expected, err.Error(), "Expected error message '%s', but got '%s'", expected, err.Error())
 }
 
+func Test_app_errors_StartSessionError_Unwrap(t *testing.T) {
+	inner := fmt.Errorf("failed to create session, status code: 500")
+	err := &StartSessionError{Email: "test@example.com", Topic: "go", err: inner}
+	assert.ErrorIs(t, err, inner, "Expected start session error to wrap the api error")
+}
+
 func Test_app_errors_ListenForErrors_WhenStartSessionError(t *testing.T) {
 	email := "test@example.com"
 
@@ -45,6 +52,7 @@ func Test_app_errors_ListenForErrors_WhenStartSessionError(t *testing.T) {
 	assert.Equal(t, email, startResult.Email, "Expected email to match")
 	assert.Equal(t, "test-topic", startResult.Topic, "Expected topic to match")
 	assert.Equal(t, STATUS_FAILED, startResult.Status, "Expected session status to be failed")
+	assert.Equal(t, quizapi.EndpointCreateSession, startResult.FailedStep, "Expected failed step to be create_session")
 	require.NotNil(t, startResult.Error, "Expected error to be non-nil for start session error")
 
 	app.ErrorListener.Wait()
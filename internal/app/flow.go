@@ -0,0 +1,253 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/go-squad-5/quiz-load-test/internal/flow"
+	"github.com/go-squad-5/quiz-load-test/internal/quizapi"
+)
+
+// defaultFlow is run by the users when no flow file is configured
+var defaultFlow *flow.Flow = flow.Default()
+
+// errRunAborted is returned by the steps interrupted by the abort of the run, the session is abandoned rather than failed
+var errRunAborted = errors.New("run aborted")
+
+// userFlow returns the flow run by every user
+func (app *App) userFlow() *flow.Flow {
+	if app.Config.Flow == nil {
+		return defaultFlow
+	}
+	return app.Config.Flow
+}
+
+// runFlow runs the steps of the flow for the session, and returns the status the session ended with.
+// A failed session is already reported to the errors channel, the other sessions are left to the caller.
+// The session is abandoned at the next step once the run is aborted
+func (app *App) runFlow(f *flow.Flow, session *Session) STATUS {
+	for i, run := 0, 0; i < len(f.Steps); run++ {
+		if app.aborted() {
+			app.sessionLogger(session).Info("Session stopped, the run was aborted")
+			return STATUS_ABANDONED
+		}
+		if run == flow.MaxSteps {
+			app.failSession(session, STEP_FLOW, fmt.Errorf("the flow ran %d steps, a goto step may loop", flow.MaxSteps))
+			return STATUS_FAILED
+		}
+		step := f.Steps[i]
+		if step.Parallel {
+			end := f.ParallelGroup(i)
+			if err := app.runParallelSteps(f.Steps[i:end], session); err != nil {
+				return stepFailedStatus(err)
+			}
+			i = end
+			continue
+		}
+		if !step.If.Eval(app.flowVars(session)) {
+			i++
+			continue
+		}
+		switch step.Action {
+		case flow.Goto:
+			i = f.Index(step.To)
+			continue
+		case flow.Abandon:
+			return STATUS_ABANDONED
+		case flow.End:
+			return STATUS_COMPLETED
+		}
+		start := time.Now()
+		err := app.runStep(step, session)
+		app.advanceSchedule(session, []flow.Step{step}, time.Since(start))
+		if err != nil {
+			return stepFailedStatus(err)
+		}
+		i++
+	}
+	return STATUS_COMPLETED
+}
+
+// stepFailedStatus returns the status of the session whose step returned the error
+func stepFailedStatus(err error) STATUS {
+	if errors.Is(err, errRunAborted) {
+		return STATUS_ABANDONED
+	}
+	return STATUS_FAILED
+}
+
+// runParallelSteps runs the steps concurrently, and returns the errors of the failed steps once every step is over.
+// The steps leave the session alone on failure, so a session failing several steps is reported once
+func (app *App) runParallelSteps(steps []flow.Step, session *Session) error {
+	// the conditions are evaluated before any step runs, so the steps don't race on the session
+	run := make([]bool, len(steps))
+	for i, step := range steps {
+		run[i] = step.If.Eval(app.flowVars(session))
+	}
+
+	start := time.Now()
+	wg := &sync.WaitGroup{}
+	failedSteps := make([]string, len(steps))
+	errs := make([]error, len(steps))
+	for i, step := range steps {
+		if !run[i] {
+			continue
+		}
+		wg.Add(1)
+		app.sessionLogger(session).Debug("GO ROUTINE STARTED for the step", "step", step.Action)
+		go func() {
+			defer wg.Done()
+			defer app.sessionLogger(session).Debug("GO ROUTINE FINISHED for the step", "step", step.Action)
+			failedSteps[i], errs[i] = app.runParallelStep(step, session)
+		}()
+	}
+	wg.Wait()
+	ran := []flow.Step{}
+	for i, step := range steps {
+		if run[i] {
+			ran = append(ran, step)
+		}
+	}
+	app.advanceSchedule(session, ran, time.Since(start))
+
+	// the session fails at the first failed step of the flow, a step interrupted by the abort of the run doesn't fail it
+	failures := []error{}
+	aborted := false
+	for i, err := range errs {
+		switch {
+		case errors.Is(err, errRunAborted):
+			aborted = true
+		case err != nil:
+			if len(failures) == 0 {
+				app.failSession(session, failedSteps[i], err)
+			}
+			failures = append(failures, err)
+		}
+	}
+	if len(failures) == 0 && aborted {
+		return errRunAborted
+	}
+	return errors.Join(failures...)
+}
+
+// runParallelStep runs a step of a parallel group without failing the session, and returns the step the session
+// failed at with the error. The steps only set their own fields of the session, so they don't race
+func (app *App) runParallelStep(step flow.Step, session *Session) (string, error) {
+	switch step.Action {
+	case flow.GetReport:
+		report, timeTaken, err := app.getReport(session)
+		session.APIsTimeTaken.SetReportAPITime(timeTaken)
+		session.SetReport(report)
+		return failedStep(quizapi.EndpointGetReport, err), err
+	case flow.EmailReport:
+		timeTaken, failed, err := app.getEmailReport(session)
+		session.APIsTimeTaken.SetEmailAPITime(timeTaken)
+		return failed, err
+	}
+	// the flow is validated, only the report requests may run in parallel
+	return STEP_FLOW, fmt.Errorf("%s step can't run in parallel", step.Action)
+}
+
+// runStep runs a step sending a request or acting on the session, a failed step fails the session
+func (app *App) runStep(step flow.Step, session *Session) error {
+	switch step.Action {
+	case flow.CreateSession:
+		ssid, timeTaken, err := app.callCreateSession(session)
+		session.APIsTimeTaken.SetSessionCreationTime(timeTaken)
+		if err != nil {
+			return err
+		}
+		session.SetSession(ssid)
+		app.sessions.Store(ssid, session)
+	case flow.StartQuiz:
+		questions, timeTaken, err := app.callStartQuiz(session.ID, session.Topic, session)
+		session.APIsTimeTaken.SetStartQuizTime(timeTaken)
+		if err != nil {
+			return err
+		}
+		session.SetQuestions(questions)
+	case flow.Answer:
+		if session.Question == nil {
+			err := fmt.Errorf("no questions to answer, the quiz was not started")
+			app.failSession(session, STEP_MARK_ANSWERS, err)
+			return err
+		}
+		return app.markRandomAnswers(session.Question, session)
+	case flow.Submit:
+		score, timeTaken, err := app.callSubmitQuiz(session.ID, session)
+		session.APIsTimeTaken.SetSubmitQuizTime(timeTaken)
+		session.SetScore(score)
+		// compare the score with the answer key, a failed submit is already reported
+		if err == nil && app.Config.AnswerKey != nil {
+			err = app.verifyScore(session)
+		}
+		return err
+	case flow.GetReport:
+		report, timeTaken, err := app.callGetReport(session)
+		session.APIsTimeTaken.SetReportAPITime(timeTaken)
+		session.SetReport(report)
+		return err
+	case flow.EmailReport:
+		timeTaken, err := app.callGetEmail(session)
+		session.APIsTimeTaken.SetEmailAPITime(timeTaken)
+		return err
+	case flow.Sleep:
+		sleep := step.Duration
+		if step.Jitter > 0 {
+			sleep += time.Duration(app.rand.Float64() * float64(step.Jitter))
+		}
+		timer := time.NewTimer(sleep)
+		defer timer.Stop()
+		select {
+		case <-app.Abort.Aborted():
+			return errRunAborted
+		case <-timer.C:
+		}
+	case flow.Assert:
+		vars := app.flowVars(session)
+		if step.That.Eval(vars) {
+			return nil
+		}
+		// the actual value is left out of the error, so the sessions failing the same assertion are grouped
+		err := fmt.Errorf("assertion failed: %s", step.That)
+		app.sessionLogger(session).Error("Assertion failed", "assertion", step.That.String(), "value", vars[step.That.Variable])
+		app.failSession(session, STEP_ASSERT, err)
+		return err
+	}
+	return nil
+}
+
+// failSession reports the session as failed at the step
+func (app *App) failSession(session *Session, step string, err error) {
+	session.SetError(err)
+	session.SetFailedStep(step)
+	session.SetStatus(STATUS_FAILED)
+	session.SetEndTime(time.Now())
+	app.Errors <- &SessionError{
+		Session: session,
+	}
+}
+
+// flowVars returns the variables the conditions of the steps are evaluated against
+func (app *App) flowVars(session *Session) flow.Vars {
+	vars := flow.Vars{
+		"score":        strconv.Itoa(session.Score),
+		"questions":    strconv.Itoa(len(session.Question)),
+		"answers":      strconv.Itoa(len(session.Answers)),
+		"random":       strconv.FormatFloat(app.rand.Float64(), 'f', -1, 64),
+		"behaviour":    string(session.Behaviour),
+		"topic":        session.Topic,
+		"email_status": session.EmailStatus,
+	}
+	if t := session.APIsTimeTaken; t != nil {
+		vars["create_session.ms"] = strconv.FormatInt(t.SessionCreation, 10)
+		vars["start_quiz.ms"] = strconv.FormatInt(t.StartQuiz, 10)
+		vars["submit.ms"] = strconv.FormatInt(t.SubmitQuiz, 10)
+		vars["get_report.ms"] = strconv.FormatInt(t.ReportAPI, 10)
+		vars["email_report.ms"] = strconv.FormatInt(t.EmailAPI, 10)
+	}
+	return vars
+}
@@ -0,0 +1,186 @@
+package app
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/go-squad-5/quiz-load-test/internal/behaviour"
+	"github.com/go-squad-5/quiz-load-test/internal/flow"
+	"github.com/go-squad-5/quiz-load-test/internal/quizapi"
+	"github.com/go-squad-5/quiz-load-test/internal/quizapi/mock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestFlowApp(t *testing.T) (*App, *mock.MockQuizAPI) {
+	app := NewTestApp()
+	mockApp, ok := app.QuizAPI.(*mock.MockQuizAPI)
+	require.True(t, ok, "Error while getting the mock quizapi")
+	mockApp.On("CreateSession", "test@example.com", "math").Return("12345", nil)
+	mockApp.On("StartQuiz", "12345", "math").Return([]quizapi.Question{{ID: "q1", Question: "What is 2 + 2?", Options: []string{"4"}}}, nil)
+	return app, mockApp
+}
+
+func mustParseFlow(t *testing.T, data string) *flow.Flow {
+	f, err := flow.Parse([]byte(data))
+	require.NoError(t, err, "Expected a valid flow")
+	return f
+}
+
+// receiveSessionError returns the failed session reported by the flow, once the flow is over
+func receiveSessionError(t *testing.T, app *App) <-chan *Session {
+	failed := make(chan *Session, 1)
+	go func() {
+		err := <-app.Errors
+		sessionErr, ok := err.(*SessionError)
+		require.True(t, ok, "Expected error to be the session error")
+		failed <- sessionErr.Session
+	}()
+	return failed
+}
+
+func Test_app_flow_RunParallelSteps(t *testing.T) {
+	session := NewSession("test@example.com", "math", NewAPIsTimeTaken())
+	app := NewTestApp()
+	mockApp, ok := app.QuizAPI.(*mock.MockQuizAPI)
+	require.True(t, ok, "Error while getting the mock quizapi")
+	mockApp.On("GetReport", session.ID).After(time.Millisecond*100).Return("This is a test report", nil)
+	mockApp.On("GetEmailReport", session.ID).After(time.Millisecond*100).Return("", nil)
+
+	start := time.Now()
+	err := app.runParallelSteps(defaultFlow.Steps[5:], session)
+
+	require.NoError(t, err, "Expected both reports to be requested")
+	assert.Less(t, time.Since(start), 190*time.Millisecond, "Expected the reports to be requested at the same time")
+	assert.Equal(t, "This is a test report", session.Report, "Expected the report of the session")
+	assert.NotEmpty(t, session.APIsTimeTaken.ReportAPI, "Expected the time taken by the report request")
+	assert.NotEmpty(t, session.APIsTimeTaken.EmailAPI, "Expected the time taken by the email report request")
+}
+
+func Test_app_flow_RunParallelSteps_WhenSkipEmail(t *testing.T) {
+	session := NewSession("test@example.com", "math", NewAPIsTimeTaken())
+	session.SetBehaviour(behaviour.SkipEmail)
+	app := NewTestApp()
+	mockApp, ok := app.QuizAPI.(*mock.MockQuizAPI)
+	require.True(t, ok, "Error while getting the mock quizapi")
+	mockApp.On("GetReport", session.ID).Return("This is a test report", nil)
+
+	err := app.runParallelSteps(defaultFlow.Steps[5:], session)
+
+	require.NoError(t, err, "Expected the report to be requested")
+	assert.Equal(t, "This is a test report", session.Report, "Expected the report of the session")
+	mockApp.AssertNotCalled(t, "GetEmailReport", session.ID)
+	assert.Zero(t, session.APIsTimeTaken.EmailAPI, "Expected no email report request")
+}
+
+func Test_app_flow_RunParallelSteps_WhenBothFail(t *testing.T) {
+	session := NewSession("test@example.com", "math", NewAPIsTimeTaken())
+	session.SetSession("12345")
+	app := NewTestApp()
+	mockApp, ok := app.QuizAPI.(*mock.MockQuizAPI)
+	require.True(t, ok, "Error while getting the mock quizapi")
+	mockApp.On("GetReport", "12345").Return("", errors.New("status code: 500"))
+	mockApp.On("GetEmailReport", "12345").Return("", errors.New("status code: 503"))
+
+	failed := receiveSessionError(t, app)
+	err := app.runParallelSteps(defaultFlow.Steps[5:], session)
+
+	require.Error(t, err, "Expected both steps to fail")
+	assert.ErrorContains(t, err, "status code: 503", "Expected the errors of both steps")
+	result := <-failed
+	assert.Equal(t, quizapi.EndpointGetReport, result.FailedStep, "Expected the session failed at the first step of the flow")
+	assert.EqualError(t, result.Error, "status code: 500", "Expected the error of the first failed step")
+	select {
+	case <-app.Errors:
+		t.Error("Expected the session to be reported once")
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func Test_app_flow_RunFlow_WithGotoAndAssert(t *testing.T) {
+	app, mockApp := newTestFlowApp(t)
+	answers := []quizapi.Answer{{QuestionID: "q1", Answer: "4"}}
+	mockApp.On("SubmitQuiz", "12345", answers).Return(0, nil).Once()
+	mockApp.On("SubmitQuiz", "12345", answers).Return(1, nil).Once()
+	f := mustParseFlow(t, `{"steps": [
+		"create_session",
+		"start_quiz",
+		{"step": "answer", "name": "retry"},
+		"submit",
+		{"step": "goto", "to": "retry", "if": "score < 1"},
+		{"step": "assert", "that": "score == 1"},
+		{"step": "end"},
+		"get_report"
+	]}`)
+	session := NewSession("test@example.com", "math", NewAPIsTimeTaken())
+
+	status := app.runFlow(f, session)
+
+	assert.Equal(t, STATUS_COMPLETED, status, "Expected the flow to complete the session")
+	assert.Equal(t, 1, session.Score, "Expected the score of the second submit")
+	mockApp.AssertNumberOfCalls(t, "SubmitQuiz", 2)
+	mockApp.AssertNotCalled(t, "GetReport", "12345")
+	_, ok := app.sessions.Load("12345")
+	assert.True(t, ok, "Expected the session to be in progress until the user is done")
+}
+
+func Test_app_flow_RunFlow_WhenAssertionFails(t *testing.T) {
+	app, _ := newTestFlowApp(t)
+	f := mustParseFlow(t, `{"steps": ["create_session", "start_quiz", {"step": "assert", "that": "questions > 1"}, "answer"]}`)
+	session := NewSession("test@example.com", "math", NewAPIsTimeTaken())
+	failed := receiveSessionError(t, app)
+
+	status := app.runFlow(f, session)
+
+	assert.Equal(t, STATUS_FAILED, status, "Expected the session to fail")
+	assert.Same(t, session, <-failed, "Expected the failed session to be reported")
+	assert.EqualError(t, session.Error, "assertion failed: questions > 1", "Expected the failed assertion")
+	assert.Equal(t, STEP_ASSERT, session.FailedStep, "Expected the session to fail at the assert step")
+	assert.Empty(t, session.Answers, "Expected no step to run after the failed assertion")
+}
+
+func Test_app_flow_RunFlow_WhenAbandoned(t *testing.T) {
+	app, _ := newTestFlowApp(t)
+	f := mustParseFlow(t, `{"steps": ["create_session", "start_quiz", {"step": "abandon", "if": "questions < 5"}, "answer"]}`)
+	session := NewSession("test@example.com", "math", NewAPIsTimeTaken())
+
+	assert.Equal(t, STATUS_ABANDONED, app.runFlow(f, session), "Expected the session to be abandoned")
+	assert.Empty(t, session.Answers, "Expected no step to run after the session is abandoned")
+}
+
+func Test_app_flow_RunFlow_WhenAnswerWithoutQuestions(t *testing.T) {
+	app, _ := newTestFlowApp(t)
+	f := mustParseFlow(t, `{"steps": ["create_session", {"step": "start_quiz", "if": "topic == go"}, "answer"]}`)
+	session := NewSession("test@example.com", "math", NewAPIsTimeTaken())
+	failed := receiveSessionError(t, app)
+
+	assert.Equal(t, STATUS_FAILED, app.runFlow(f, session), "Expected the session to fail")
+	<-failed
+	assert.Equal(t, STEP_MARK_ANSWERS, session.FailedStep, "Expected the session to fail to answer the questions")
+}
+
+func Test_app_flow_RunFlow_WhenLooping(t *testing.T) {
+	app, _ := newTestFlowApp(t)
+	f := mustParseFlow(t, `{"steps": ["create_session", {"step": "end", "name": "loop", "if": "score > 0"}, {"step": "goto", "to": "loop"}]}`)
+	session := NewSession("test@example.com", "math", NewAPIsTimeTaken())
+	failed := receiveSessionError(t, app)
+
+	assert.Equal(t, STATUS_FAILED, app.runFlow(f, session), "Expected a looping flow to fail")
+	<-failed
+	assert.Equal(t, STEP_FLOW, session.FailedStep, "Expected the session to fail for running too many steps")
+}
+
+func Test_app_flow_FlowVars(t *testing.T) {
+	app := NewTestApp()
+	session := NewSession("test@example.com", "math", &APIsTimeTaken{SubmitQuiz: 120})
+	session.SetScore(4)
+	session.SetBehaviour(behaviour.RetrySubmit)
+
+	vars := app.flowVars(session)
+	assert.Equal(t, "4", vars["score"], "Expected the score of the session")
+	assert.Equal(t, "0", vars["questions"], "Expected no questions before the quiz is started")
+	assert.Equal(t, "retry_submit", vars["behaviour"], "Expected the behaviour profile of the session")
+	assert.Equal(t, "120", vars["submit.ms"], "Expected the latency of the submit")
+	assert.NotEmpty(t, vars["random"], "Expected a random number")
+}
@@ -0,0 +1,32 @@
+package app
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/go-squad-5/quiz-load-test/internal/history"
+	"github.com/go-squad-5/quiz-load-test/internal/metrics"
+)
+
+// runArtifacts are the files of the output directory copied into the run history
+var runArtifacts []string = []string{"logs.txt", "sessions.csv", "requests.csv", "timeseries.csv", "summary.json", "report.html"}
+
+// SaveRun saves the run with its seed, labels and summary in the history directory
+func (app *App) SaveRun(summary *metrics.Summary) (string, error) {
+	artifacts := []string{}
+	for _, name := range runArtifacts {
+		filePath := fmt.Sprintf("%s/%s", app.outputDir(), name)
+		if _, err := os.Stat(filePath); err == nil {
+			artifacts = append(artifacts, filePath)
+		}
+	}
+
+	run := &history.Run{
+		ID:        app.Config.RunID,
+		StartedAt: app.StartedAt,
+		Seed:      app.Config.Seed,
+		Labels:    app.Config.RunLabels,
+		Summary:   summary,
+	}
+	return history.NewStore(app.Config.HistoryDir).Save(run, artifacts...)
+}
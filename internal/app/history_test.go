@@ -0,0 +1,42 @@
+package app
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-squad-5/quiz-load-test/internal/history"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_app_history_SaveRun(t *testing.T) {
+	tmpDirPath = "./test" // change path for the test environment
+	defer func() {
+		if err := os.RemoveAll(tmpDirPath); err != nil && !os.IsNotExist(err) {
+			t.Fatalf("Error cleaning up the test files: %s", tmpDirPath)
+		}
+	}()
+
+	app := NewTestApp()
+	app.Config.RunID = "run-1"
+	app.Config.Seed = 7
+	app.Config.RunLabels = map[string]string{"commit": "3f2a1c"}
+	app.Config.HistoryDir = filepath.Join(tmpDirPath, "runs")
+	app.StartedAt = time.Now().Add(-time.Second)
+	app.FinishedAt = time.Now()
+	summary := app.Summary()
+	_, err := app.WriteSummaryJSON(summary)
+	require.NoError(t, err, "Expected the summary to be written")
+
+	runDir, err := app.SaveRun(summary)
+	require.NoError(t, err, "Expected the run to be saved")
+	assert.FileExists(t, filepath.Join(runDir, "summary.json"), "Expected the existing artifacts to be copied")
+	assert.NoFileExists(t, filepath.Join(runDir, "logs.txt"), "Expected missing artifacts to be skipped")
+
+	run, err := history.NewStore(app.Config.HistoryDir).Get("run-1")
+	require.NoError(t, err, "Expected the run in the history")
+	assert.Equal(t, int64(7), run.Seed, "Expected the seed of the run")
+	assert.Equal(t, "3f2a1c", run.Labels["commit"], "Expected the labels of the run")
+}
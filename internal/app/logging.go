@@ -0,0 +1,61 @@
+package app
+
+import (
+	"io"
+	"log/slog"
+	"os"
+	"sync"
+)
+
+const (
+	LogFormatText = "text"
+	LogFormatJSON = "json"
+)
+
+var (
+	// log files opened by the process by path, shared by the apps of the capacity trials and of the worker jobs
+	logFiles   = map[string]*os.File{}
+	logFilesMu sync.Mutex
+)
+
+// newLogger returns the logger of the app, writing the records from the configured level on
+// to the log file when set, or else to stdout unless the run is quiet. It writes to stdout when the log file can't be opened
+func newLogger(cfg *Config) (*slog.Logger, error) {
+	var output io.Writer = os.Stdout
+	var err error
+	if cfg.LogFile != "" {
+		var file *os.File
+		if file, err = openLogFile(cfg.LogFile); err == nil {
+			output = file
+		}
+	} else if cfg.Quiet {
+		output = io.Discard
+	}
+
+	options := &slog.HandlerOptions{Level: cfg.LogLevel}
+	var handler slog.Handler = slog.NewTextHandler(output, options)
+	if cfg.LogFormat == LogFormatJSON {
+		handler = slog.NewJSONHandler(output, options)
+	}
+	return slog.New(handler), err
+}
+
+// openLogFile opens the log file for appending, once per process
+func openLogFile(path string) (*os.File, error) {
+	logFilesMu.Lock()
+	defer logFilesMu.Unlock()
+	if file, ok := logFiles[path]; ok {
+		return file, nil
+	}
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	logFiles[path] = file
+	return file, nil
+}
+
+// sessionLogger returns a logger whose records carry the email, topic and ID of the session
+func (app *App) sessionLogger(session *Session) *slog.Logger {
+	return app.Logger.With("email", session.Email, "topic", session.Topic, "session_id", session.ID)
+}
@@ -0,0 +1,58 @@
+package app
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_app_logging_newLogger_WhenLogFile(t *testing.T) {
+	filePath := t.TempDir() + "/loadtester.log"
+	logger, err := newLogger(&Config{LogFile: filePath, LogFormat: LogFormatJSON, LogLevel: slog.LevelWarn, Quiet: true})
+	require.NoError(t, err, "Expected the log file to open")
+
+	logger.Info("Session completed")
+	logger.Warn("Retrying to submit quiz", "retry", 1)
+
+	data, err := os.ReadFile(filePath)
+	require.NoError(t, err, "Expected the log file to be written, even when quiet")
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	require.Len(t, lines, 1, "Expected the records below the level to be left out")
+	record := map[string]any{}
+	require.NoError(t, json.Unmarshal([]byte(lines[0]), &record), "Expected a json record")
+	assert.Equal(t, "WARN", record["level"], "Expected the level of the record")
+	assert.Equal(t, "Retrying to submit quiz", record["msg"], "Expected the message of the record")
+	assert.EqualValues(t, 1, record["retry"], "Expected the attributes of the record")
+
+	file, err := openLogFile(filePath)
+	require.NoError(t, err, "Expected the log file to open again")
+	assert.Same(t, logFiles[filePath], file, "Expected the log file to be opened once per process")
+}
+
+func Test_app_logging_newLogger_WhenInvalidLogFile(t *testing.T) {
+	logger, err := newLogger(&Config{LogFile: t.TempDir() + "/missing/loadtester.log"})
+	assert.Error(t, err, "Expected the log file not to open")
+	assert.NotNil(t, logger, "Expected a logger to stdout")
+}
+
+func Test_app_logging_sessionLogger(t *testing.T) {
+	app := NewTestApp()
+	var buf bytes.Buffer
+	app.Logger = slog.New(slog.NewTextHandler(&buf, nil))
+
+	session := NewSession("user@example.com", "go", NewAPIsTimeTaken())
+	session.SetSession("session-1")
+	app.sessionLogger(session).Info("Session completed", "score", 3)
+
+	output := buf.String()
+	assert.Contains(t, output, "email=user@example.com", "Expected the email of the session")
+	assert.Contains(t, output, "topic=go", "Expected the topic of the session")
+	assert.Contains(t, output, "session_id=session-1", "Expected the ID of the session")
+	assert.Contains(t, output, "score=3", "Expected the attributes of the record")
+}
@@ -0,0 +1,55 @@
+package app
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+
+	"github.com/go-squad-5/quiz-load-test/internal/metrics"
+	"github.com/go-squad-5/quiz-load-test/internal/quizapi"
+)
+
+// observeRequest is the QuizAPI observer, it records every request sent during the simulation
+func (app *App) observeRequest(info quizapi.RequestInfo) {
+	observation := metrics.Observation{
+		Time:       info.StartTime,
+		Endpoint:   info.Endpoint,
+		Topic:      info.Topic,
+		StatusCode: info.StatusCode,
+		Latency:    info.Duration,
+		Bytes:      info.Bytes,
+		Failed:     info.Err != nil,
+	}
+	// only the first requests of a session carry the topic
+	if observation.Topic == "" {
+		if session, ok := app.sessions.Load(info.SessionID); ok {
+			observation.Topic = session.(*Session).Topic
+		}
+	}
+
+	app.Metrics.Observe(observation)
+	app.Prometheus.Observe(observation)
+	app.Abort.ObserveRequest(info.Err)
+	app.recordExchange(info)
+	if app.requestsCSV != nil {
+		if err := app.requestsCSV.Write(getRequestRecord(info, observation.Topic)); err != nil {
+			app.Logger.Error("Failed to write the request to csv", "error", err)
+		}
+	}
+}
+
+// StartMetricsServer serves the prometheus metrics on /metrics of the configured address
+func (app *App) StartMetricsServer() error {
+	listener, err := net.Listen("tcp", app.Config.MetricsAddr)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", app.Config.MetricsAddr, err)
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("GET /metrics", app.Prometheus)
+	app.MetricsServer = &http.Server{Addr: listener.Addr().String(), Handler: mux}
+	go app.MetricsServer.Serve(listener)
+
+	app.Logger.Info("Prometheus metrics listening", "url", app.MetricsServer.Addr+"/metrics")
+	return nil
+}
@@ -0,0 +1,90 @@
+package app
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/go-squad-5/quiz-load-test/internal/quizapi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_app_metrics_ObserveRequest(t *testing.T) {
+	app := NewTestApp()
+
+	app.observeRequest(quizapi.RequestInfo{
+		Endpoint:   quizapi.EndpointStartQuiz,
+		StatusCode: 200,
+		StartTime:  time.Now(),
+		Duration:   100 * time.Millisecond,
+	})
+	app.observeRequest(quizapi.RequestInfo{
+		Endpoint:   quizapi.EndpointStartQuiz,
+		StatusCode: 500,
+		StartTime:  time.Now(),
+		Duration:   300 * time.Millisecond,
+		Err:        errors.New("failed to start quiz, status code: 500"),
+	})
+
+	snapshot := app.Metrics.Flush(time.Now())
+	require.Len(t, snapshot.Endpoints, len(quizapi.Endpoints), "Expected all the quiz api endpoints in the snapshot")
+	stats := snapshot.Endpoints[1]
+	assert.Equal(t, quizapi.EndpointStartQuiz, stats.Endpoint, "Expected endpoints in the order of a session")
+	assert.Equal(t, uint64(2), stats.Count, "Expected both requests to be recorded")
+	assert.Equal(t, uint64(1), stats.Errors, "Expected the failed request to be counted as error")
+}
+
+func Test_app_metrics_ObserveRequest_WhenTopicFromSession(t *testing.T) {
+	app := NewTestApp()
+	session := NewSession("test@example.com", "rust", nil)
+	session.SetSession("12345")
+	app.sessions.Store("12345", session)
+
+	app.observeRequest(quizapi.RequestInfo{
+		Endpoint:   quizapi.EndpointGetReport,
+		SessionID:  "12345",
+		StatusCode: 200,
+		Duration:   10 * time.Millisecond,
+	})
+
+	var buf bytes.Buffer
+	_, err := app.Prometheus.WriteTo(&buf)
+	require.NoError(t, err, "Expected the prometheus metrics to be written")
+	assert.Contains(t, buf.String(), `quiz_loadtest_requests_total{endpoint="get_report",status="200",topic="rust"} 1`, "Expected the topic of the session in progress")
+}
+
+func Test_app_metrics_StartMetricsServer(t *testing.T) {
+	app := NewTestApp()
+	app.Config.MetricsAddr = "127.0.0.1:0"
+	app.observeRequest(quizapi.RequestInfo{
+		Endpoint:   quizapi.EndpointCreateSession,
+		Topic:      "go",
+		StatusCode: 200,
+		Duration:   10 * time.Millisecond,
+	})
+
+	require.NoError(t, app.StartMetricsServer(), "Expected the metrics server to start")
+	require.NotNil(t, app.MetricsServer, "Expected the metrics server to be set")
+	defer app.MetricsServer.Close()
+
+	resp, err := http.Get("http://" + app.MetricsServer.Addr + "/metrics")
+	require.NoError(t, err, "Expected the metrics to be served")
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err, "Expected to read the metrics")
+	assert.Equal(t, http.StatusOK, resp.StatusCode, "Expected status 200 for the metrics")
+	assert.Contains(t, string(body), `quiz_loadtest_requests_total{endpoint="create_session",status="200",topic="go"} 1`, "Expected the observed request in the metrics")
+}
+
+func Test_app_metrics_StartMetricsServer_WhenInvalidAddr(t *testing.T) {
+	app := NewTestApp()
+	app.Config.MetricsAddr = "invalid-address"
+
+	require.Error(t, app.StartMetricsServer(), "Expected an error for an invalid address")
+	assert.Nil(t, app.MetricsServer, "Expected no metrics server when it failed to start")
+}
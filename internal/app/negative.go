@@ -0,0 +1,140 @@
+package app
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/go-squad-5/quiz-load-test/internal/negative"
+	"github.com/go-squad-5/quiz-load-test/internal/quizapi"
+)
+
+// pickNegativeScenario returns the negative scenario of a new session, or an empty scenario for the normal quiz flow
+func (app *App) pickNegativeScenario() negative.Scenario {
+	if app.Config.NegativeScenarios.Empty() {
+		return ""
+	}
+	return app.Config.NegativeScenarios.Pick(app.rand.Float64())
+}
+
+// runNegativeScenario sets up the session the scenario needs with normal requests, then sends the request misusing the api.
+// A failed set up is reported as a failed session, the response to the misuse is recorded in the negative stats only.
+func (app *App) runNegativeScenario(scenario negative.Scenario, session *Session) {
+	app.sessionLogger(session).Debug("Running negative scenario", "scenario", scenario)
+	reported := false
+	var problem error
+	defer func() {
+		if session.ID != "" {
+			app.sessions.Delete(session.ID)
+		}
+		// the requests of a failed set up are captured with the failed session, the others are taken here
+		if app.captures != nil && !reported && session.ID != "" {
+			captureFile, err := app.saveNegativeCapture(scenario, session, problem)
+			if err != nil {
+				app.sessionLogger(session).Error("Failed to save the capture of the negative scenario", "error", err)
+			} else if captureFile != "" {
+				app.sessionLogger(session).Info("Negative scenario captured", "scenario", scenario, "capture", captureFile)
+			}
+		}
+	}()
+
+	if scenario != negative.UnknownSession && !app.setUpNegativeSession(scenario, session) {
+		reported = true
+		return
+	}
+	err := app.sendMisuse(scenario, session)
+
+	// the misuse requests succeed with 200
+	statusCode := http.StatusOK
+	if err != nil {
+		statusCode = quizapi.StatusCode(err)
+	}
+	problem = scenario.Check(statusCode)
+	app.NegativeStats.Add(string(scenario), statusCode, problem == nil, problem != nil && negative.IsServerError(statusCode))
+	if problem == nil {
+		app.sessionLogger(session).Debug("Negative scenario rejected", "scenario", scenario, "status_code", statusCode)
+		return
+	}
+	if err != nil {
+		problem = fmt.Errorf("%w: %v", problem, err)
+	}
+	app.sessionLogger(session).Error("Negative scenario failed", "error", problem)
+}
+
+// setUpNegativeSession runs the normal requests of the session up to the step the scenario misuses,
+// it returns false if a request failed, the session is then reported as failed
+func (app *App) setUpNegativeSession(scenario negative.Scenario, session *Session) bool {
+	ssid, createTimeTaken, err := app.callCreateSession(session)
+	session.APIsTimeTaken.SetSessionCreationTime(createTimeTaken)
+	if err != nil {
+		return false
+	}
+	session.SetSession(ssid)
+	app.sessions.Store(ssid, session)
+	if scenario == negative.UnknownTopic {
+		return true
+	}
+
+	questions, startQuizTimeTaken, err := app.callStartQuiz(ssid, session.Topic, session)
+	session.APIsTimeTaken.SetStartQuizTime(startQuizTimeTaken)
+	if err != nil {
+		return false
+	}
+	session.SetQuestions(questions)
+	if scenario == negative.IncompleteReport {
+		return true
+	}
+
+	if err := app.markRandomAnswers(questions, session); err != nil {
+		return false
+	}
+	if scenario != negative.DoubleSubmit {
+		return true
+	}
+	score, submitTimeTaken, err := app.callSubmitQuiz(ssid, session)
+	session.APIsTimeTaken.SetSubmitQuizTime(submitTimeTaken)
+	session.SetScore(score)
+	return err == nil
+}
+
+// sendMisuse sends the request of the scenario which the server should reject
+func (app *App) sendMisuse(scenario negative.Scenario, session *Session) error {
+	var err error
+	switch scenario {
+	case negative.DoubleSubmit:
+		_, err = app.NegativeQuizAPI.SubmitQuiz(session.ID, session.Answers)
+	case negative.UnknownSession:
+		// a random id, unlikely to be a session of the server, kept on the session to be logged
+		session.SetSession(fmt.Sprintf("unknown-%d", app.rand.Intn(math.MaxInt32)))
+		_, err = app.NegativeQuizAPI.SubmitQuiz(session.ID, []quizapi.Answer{{QuestionID: "q1", Answer: "a"}})
+	case negative.UnknownTopic:
+		_, err = app.NegativeQuizAPI.StartQuiz(session.ID, negative.UnknownTopicName)
+	case negative.ForeignQuestions:
+		answers := make([]quizapi.Answer, len(session.Answers))
+		for i, answer := range session.Answers {
+			answers[i] = quizapi.Answer{QuestionID: "foreign-" + answer.QuestionID, Answer: answer.Answer}
+		}
+		_, err = app.NegativeQuizAPI.SubmitQuiz(session.ID, answers)
+	case negative.OversizedAnswers:
+		answers := slices.Clone(session.Answers)
+		answers[0].Answer = strings.Repeat("a", negative.OversizedAnswerSize)
+		_, err = app.NegativeQuizAPI.SubmitQuiz(session.ID, answers)
+	case negative.IncompleteReport:
+		_, err = app.NegativeQuizAPI.GetReport(session.ID)
+	default:
+		err = fmt.Errorf("unknown negative scenario %q", scenario)
+	}
+	return err
+}
+
+// observeNegativeRequest observes the misuse requests apart from the normal traffic, under the endpoint prefixed with "negative_".
+// The misuse is expected to be rejected, only the requests the server failed to handle are errors
+func (app *App) observeNegativeRequest(info quizapi.RequestInfo) {
+	info.Endpoint = "negative_" + info.Endpoint
+	if !negative.IsServerError(info.StatusCode) {
+		info.Err = nil
+	}
+	app.observeRequest(info)
+}
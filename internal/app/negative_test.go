@@ -0,0 +1,133 @@
+package app
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-squad-5/quiz-load-test/internal/capture"
+	"github.com/go-squad-5/quiz-load-test/internal/metrics"
+	"github.com/go-squad-5/quiz-load-test/internal/negative"
+	"github.com/go-squad-5/quiz-load-test/internal/quizapi"
+	"github.com/go-squad-5/quiz-load-test/internal/quizapi/mock"
+	"github.com/stretchr/testify/assert"
+	testifymock "github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestNegativeSession(t *testing.T, app *App) (*Session, *mock.MockQuizAPI) {
+	mockApp, ok := app.QuizAPI.(*mock.MockQuizAPI)
+	require.True(t, ok, "Error while getting the mock quizapi")
+	mockApp.On("CreateSession", "test@example.com", "math").Return("1234", nil)
+	mockApp.On("StartQuiz", "1234", "math").Return([]quizapi.Question{{ID: "q1", Question: "What is 2+2?", Options: []string{"4"}}}, nil)
+	return NewSession("test@example.com", "math", NewAPIsTimeTaken()), mockApp
+}
+
+func Test_app_negative_RunNegativeScenario_WhenRejected(t *testing.T) {
+	app := NewTestApp()
+	session, mockApp := newTestNegativeSession(t, app)
+	answers := []quizapi.Answer{{QuestionID: "q1", Answer: "4"}}
+	mockApp.On("SubmitQuiz", "1234", answers).Return(1, nil).Once()
+	mockApp.On("SubmitQuiz", "1234", answers).Return(0, &quizapi.StatusError{StatusCode: http.StatusConflict, Err: errors.New("already submitted")}).Once()
+
+	app.runNegativeScenario(negative.DoubleSubmit, session)
+
+	mockApp.AssertExpectations(t)
+	assert.Equal(t, []metrics.NegativeScenarioStats{
+		{Scenario: "double_submit", Runs: 1, Passed: 1, StatusCodes: map[int]int{http.StatusConflict: 1}},
+	}, app.NegativeStats.Scenarios(), "Expected the second submit to be rejected as expected")
+	_, ok := app.sessions.Load("1234")
+	assert.False(t, ok, "Expected the session not to be in progress once the scenario is over")
+}
+
+func Test_app_negative_RunNegativeScenario_WhenAccepted(t *testing.T) {
+	app := NewTestApp()
+	session, mockApp := newTestNegativeSession(t, app)
+	mockApp.On("SubmitQuiz", "1234", testifymock.MatchedBy(func(answers []quizapi.Answer) bool {
+		return len(answers) == 1 && answers[0].QuestionID == "foreign-q1"
+	})).Return(0, nil)
+	mockApp.On("GetReport", "1234").Return("", &quizapi.StatusError{StatusCode: http.StatusInternalServerError, Err: errors.New("report failed")})
+
+	app.runNegativeScenario(negative.ForeignQuestions, session)
+	app.runNegativeScenario(negative.IncompleteReport, NewSession("test@example.com", "math", NewAPIsTimeTaken()))
+
+	mockApp.AssertExpectations(t)
+	assert.Equal(t, []metrics.NegativeScenarioStats{
+		{Scenario: "foreign_questions", Runs: 1, Failed: 1, StatusCodes: map[int]int{http.StatusOK: 1}},
+		{Scenario: "incomplete_report", Runs: 1, Failed: 1, ServerErrors: 1, StatusCodes: map[int]int{http.StatusInternalServerError: 1}},
+	}, app.NegativeStats.Scenarios(), "Expected the accepted misuse and the server error to fail")
+}
+
+func Test_app_negative_RunNegativeScenario_WhenCaptured(t *testing.T) {
+	app := NewTestApp()
+	app.Config.OutputDir = t.TempDir()
+	app.captures = capture.NewRecorder(capture.Failures, 0)
+	session, mockApp := newTestNegativeSession(t, app)
+	mockApp.On("GetReport", "1234").Return("", &quizapi.StatusError{StatusCode: http.StatusBadRequest, Err: errors.New("quiz not submitted")}).Once()
+	mockApp.On("GetReport", "1234").Return("This is a report", nil).Once()
+	// the mock doesn't call the observer of the real quiz api, the requests of the session are recorded up front
+	recordRequests := func() {
+		app.recordExchange(quizapi.RequestInfo{SessionID: "1234", Exchange: &quizapi.Exchange{Endpoint: quizapi.EndpointCreateSession}})
+		app.recordExchange(quizapi.RequestInfo{SessionID: "1234", Exchange: &quizapi.Exchange{Endpoint: quizapi.EndpointGetReport}})
+	}
+
+	recordRequests()
+	app.runNegativeScenario(negative.IncompleteReport, session)
+	assert.Empty(t, app.captures.Take("1234"), "Expected the requests of the scenario to be forgotten once it is over")
+	assert.NoFileExists(t, filepath.Join(app.capturesDir(), "1234.json"), "Expected the rejected misuse not to be captured")
+
+	recordRequests()
+	app.runNegativeScenario(negative.IncompleteReport, NewSession("test@example.com", "math", NewAPIsTimeTaken()))
+	data, err := os.ReadFile(filepath.Join(app.capturesDir(), "1234.json"))
+	require.NoError(t, err, "Expected the accepted misuse to be captured")
+	saved := capture.Capture{}
+	require.NoError(t, json.Unmarshal(data, &saved), "Expected a valid capture")
+	assert.Equal(t, "incomplete_report", saved.Scenario, "Expected the scenario of the capture")
+	assert.Equal(t, "failed", saved.Status, "Expected the accepted misuse to fail")
+	assert.Len(t, saved.Exchanges, 2, "Expected the set up and misuse requests of the scenario")
+}
+
+func Test_app_negative_RunNegativeScenario_WhenSetUpFails(t *testing.T) {
+	app := NewTestApp()
+	mockApp, ok := app.QuizAPI.(*mock.MockQuizAPI)
+	require.True(t, ok, "Error while getting the mock quizapi")
+	mockApp.On("CreateSession", "test@example.com", "math").Return("", errors.New("status code: 503"))
+
+	go func() {
+		<-app.Errors
+	}()
+	app.runNegativeScenario(negative.UnknownTopic, NewSession("test@example.com", "math", NewAPIsTimeTaken()))
+
+	mockApp.AssertNotCalled(t, "StartQuiz", testifymock.Anything, testifymock.Anything)
+	assert.Empty(t, app.NegativeStats.Scenarios(), "Expected no run of the scenario when its session can't be created")
+}
+
+func Test_app_negative_PickNegativeScenario(t *testing.T) {
+	app := NewTestApp()
+	assert.Equal(t, negative.Scenario(""), app.pickNegativeScenario(), "Expected the normal quiz flow without scenarios")
+
+	app.Config.NegativeScenarios = negative.Mix{{Scenario: negative.UnknownSession, Ratio: 1}}
+	assert.Equal(t, negative.UnknownSession, app.pickNegativeScenario(), "Expected every session to run the scenario")
+}
+
+func Test_app_negative_ObserveNegativeRequest(t *testing.T) {
+	app := NewTestApp()
+	app.observeNegativeRequest(quizapi.RequestInfo{Endpoint: quizapi.EndpointSubmitQuiz, StatusCode: http.StatusNotFound, Err: errors.New("not found")})
+	app.observeNegativeRequest(quizapi.RequestInfo{Endpoint: quizapi.EndpointSubmitQuiz, StatusCode: http.StatusBadGateway, Err: errors.New("bad gateway")})
+
+	found := false
+	for _, totals := range app.Metrics.Totals() {
+		if totals.Endpoint == quizapi.EndpointSubmitQuiz {
+			assert.Equal(t, uint64(0), totals.Latency.Count, "Expected the misuse requests apart from the normal requests")
+		}
+		if totals.Endpoint == "negative_submit_quiz" {
+			found = true
+			assert.Equal(t, uint64(2), totals.Latency.Count, "Expected the misuse requests under their own endpoint")
+			assert.Equal(t, uint64(1), totals.Errors, "Expected only the server error to be an error")
+		}
+	}
+	assert.True(t, found, "Expected the misuse requests to be observed")
+}
@@ -0,0 +1,66 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/go-squad-5/quiz-load-test/internal/pdfcheck"
+	"github.com/go-squad-5/quiz-load-test/internal/quizapi"
+)
+
+// checkReport checks the report received for a session in progress, it is run by the quiz api before the report is discarded
+func (app *App) checkReport(sessionID string, data []byte) error {
+	value, ok := app.sessions.Load(sessionID)
+	if !ok {
+		return nil
+	}
+	return app.checkReportTexts(value.(*Session), data)
+}
+
+// checkReportTexts checks the report of the session contains the configured texts,
+// the placeholders {session_id}, {score}, {email} and {topic} are replaced with the values of the session
+func (app *App) checkReportTexts(session *Session, data []byte) error {
+	replacer := strings.NewReplacer(
+		"{session_id}", session.ID,
+		"{score}", strconv.Itoa(session.Score),
+		"{email}", session.Email,
+		"{topic}", session.Topic,
+	)
+	problems := []string{}
+	for _, text := range app.Config.ReportTexts {
+		if !pdfcheck.ContainsText(data, replacer.Replace(text)) {
+			// the text is reported before replacing the placeholders, to group the sessions with the same problem
+			problems = append(problems, fmt.Sprintf("missing text %q", text))
+		}
+	}
+	if len(problems) > 0 {
+		return &pdfcheck.Error{Problems: problems}
+	}
+	return nil
+}
+
+// isReportError reports whether the report was received but is invalid
+func isReportError(err error) bool {
+	var pdfErr *pdfcheck.Error
+	return errors.As(err, &pdfErr)
+}
+
+// isContractError reports whether the response was received but violates the contract of its endpoint
+func isContractError(err error) bool {
+	var contractErr *quizapi.ContractError
+	return errors.As(err, &contractErr)
+}
+
+// failedStep is the step a session failed at with the error of the endpoint,
+// an invalid response fails the validation step rather than the request
+func failedStep(endpoint string, err error) string {
+	switch {
+	case isContractError(err):
+		return STEP_VALIDATE_CONTRACT
+	case isReportError(err):
+		return STEP_VALIDATE_REPORT
+	}
+	return endpoint
+}
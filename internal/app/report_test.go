@@ -0,0 +1,90 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/go-squad-5/quiz-load-test/internal/pdfcheck"
+	"github.com/go-squad-5/quiz-load-test/internal/quizapi"
+	"github.com/go-squad-5/quiz-load-test/internal/quizapi/mock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_app_report_CheckReportTexts(t *testing.T) {
+	app := NewTestApp()
+	app.Config.ReportTexts = []string{"{session_id}", "Score: {score}", "Topic: {topic}"}
+	session := NewSession("test@example.com", "go", nil)
+	session.SetSession("1234")
+	session.SetScore(7)
+
+	report := []byte("%PDF-1.7 (Session 1234) (Score: 7) (Topic: go) %%EOF")
+	assert.NoError(t, app.checkReportTexts(session, report), "Expected the report to contain the texts of the session")
+
+	session.SetScore(8)
+	err := app.checkReportTexts(session, report)
+	require.Error(t, err, "Expected the report to miss the score of the session")
+	var pdfErr *pdfcheck.Error
+	require.True(t, errors.As(err, &pdfErr), "Expected a report correctness error")
+	assert.Equal(t, []string{`missing text "Score: {score}"`}, pdfErr.Problems, "Expected the missing text before replacing the placeholders")
+}
+
+func Test_app_report_CheckReport(t *testing.T) {
+	app := NewTestApp()
+	app.Config.ReportTexts = []string{"{session_id}"}
+	session := NewSession("test@example.com", "go", nil)
+	session.SetSession("1234")
+	app.sessions.Store("1234", session)
+
+	assert.NoError(t, app.checkReport("1234", []byte("%PDF-1.7 (Session 1234) %%EOF")), "Expected the report of the session to be valid")
+	err := app.checkReport("1234", []byte("%PDF-1.7 (Session 9999) %%EOF"))
+	assert.True(t, isReportError(err), "Expected the report without the session ID to be invalid")
+	assert.NoError(t, app.checkReport("5678", []byte("%PDF-1.7 %%EOF")), "Expected the report of an unknown session not to be checked")
+}
+
+func Test_app_report_CallGetReport_WhenInvalidPDF(t *testing.T) {
+	app := NewTestApp()
+	session := NewSession("test@example.com", "go", nil)
+	session.SetSession("1234")
+
+	mockApp, ok := app.QuizAPI.(*mock.MockQuizAPI)
+	require.True(t, ok, "Error while getting the mock quizapi")
+	mockApp.On("GetReport", "1234").Return("", &pdfcheck.Error{Problems: []string{"missing %PDF- header"}})
+
+	go func() {
+		<-app.Errors
+	}()
+	_, _, err := app.callGetReport(session)
+
+	require.Error(t, err, "Expected the invalid pdf error")
+	assert.Equal(t, STEP_VALIDATE_REPORT, session.FailedStep, "Expected the report correctness step, not the http request step")
+}
+
+func Test_app_report_FailedStep(t *testing.T) {
+	contractErr := &quizapi.ContractError{Endpoint: quizapi.EndpointStartQuiz, Violations: []string{"empty questions"}}
+	reportErr := &pdfcheck.Error{Problems: []string{"missing %PDF- header"}}
+
+	assert.Equal(t, STEP_VALIDATE_CONTRACT, failedStep(quizapi.EndpointStartQuiz, contractErr), "Expected the contract validation step")
+	assert.Equal(t, STEP_VALIDATE_CONTRACT, failedStep(quizapi.EndpointEmailStatus, fmt.Errorf("email report not sent: %w", contractErr)), "Expected the wrapped contract error to be found")
+	assert.Equal(t, STEP_VALIDATE_REPORT, failedStep(quizapi.EndpointGetReport, reportErr), "Expected the report validation step")
+	assert.Equal(t, quizapi.EndpointSubmitQuiz, failedStep(quizapi.EndpointSubmitQuiz, errors.New("status code: 500")), "Expected the endpoint for a failed request")
+}
+
+func Test_app_report_CallStartQuiz_WhenContractViolation(t *testing.T) {
+	app := NewTestApp()
+	session := NewSession("test@example.com", "math", nil)
+	session.SetSession("1234")
+
+	mockApp, ok := app.QuizAPI.(*mock.MockQuizAPI)
+	require.True(t, ok, "Error while getting the mock quizapi")
+	mockApp.On("StartQuiz", "1234", "math").Return([]quizapi.Question(nil), &quizapi.ContractError{Endpoint: quizapi.EndpointStartQuiz, Violations: []string{`duplicate ques_id "q1"`}})
+
+	go func() {
+		<-app.Errors
+	}()
+	_, _, err := app.callStartQuiz("1234", "math", session)
+
+	require.Error(t, err, "Expected the contract error")
+	assert.Equal(t, STEP_VALIDATE_CONTRACT, session.FailedStep, "Expected the contract validation step, not the http request step")
+}
@@ -1,36 +1,62 @@
 package app
 
 import (
+	"errors"
 	"fmt"
 	"os"
+	"path/filepath"
 	"strconv"
+	"strings"
+	"text/tabwriter"
 	"time"
+
+	"github.com/go-squad-5/quiz-load-test/internal/htmlreport"
+	"github.com/go-squad-5/quiz-load-test/internal/metrics"
 )
 
 func (app *App) ListenForResults() {
 	defer app.ResultListener.Done()
-	defer app.InfoLogger.Println("GO ROUTINE FINISHED for listening to results")
+	defer app.Logger.Debug("GO ROUTINE FINISHED for listening to results")
 
-	file := openResultsFile()
+	file := openResultsFile(app.outputDir())
 	defer file.Close()
+	sessionsCSV := openSessionsCSV(app.outputDir())
+	defer func() {
+		if err := sessionsCSV.Close(); err != nil {
+			app.Logger.Error("Failed to close the sessions csv file", "error", err)
+		}
+	}()
 
 	timetaken := []int64{}
 	// listen for results from the simulation and log them into the file
 	for result := range app.Results {
+		if app.captures != nil {
+			captureFile, err := app.saveCapture(result)
+			if err != nil {
+				app.sessionLogger(result).Error("Failed to save the capture of the session", "error", err)
+			}
+			result.CaptureFile = captureFile
+		}
+
 		// get the result log string
 		logString := getResultLog(result)
 
 		// aggregate the results time taken
 		timetaken = append(timetaken, result.EndTime-result.StartTime)
+		app.Prometheus.ObserveSession(string(result.Status))
+		app.SessionStats.Add(getSessionResult(result))
 
 		// write the log string to the file
 		_, err := file.WriteString(logString)
 		if err != nil {
 			panic("Failed to write to results file: " + err.Error())
 		}
+		if err := sessionsCSV.Write(getSessionRecord(result)); err != nil {
+			panic("Failed to write to sessions csv file: " + err.Error())
+		}
 	}
 
-	summary := getSummaryLog(timetaken, app.Config.NumUsers)
+	summary := getSummaryLog(app.outputDir(), timetaken, app.SessionStats.Counts().Total)
 	fmt.Print(summary)
 
 	// write the summary to the file
@@ -38,16 +64,41 @@ func (app *App) ListenForResults() {
 	if err != nil {
 		panic("Failed to write summary to results file: " + err.Error())
 	}
+
+	// write the metrics of every interval, to see when the degradation began
+	if timeSeries := app.Metrics.History(); len(timeSeries) > 0 {
+		if _, err := file.WriteString(getTimeSeriesLog(timeSeries, app.StartedAt)); err != nil {
+			panic("Failed to write time series to results file: " + err.Error())
+		}
+	}
 }
 
 var tmpDirPath string = "./tmp"
 
-func openResultsFile() *os.File {
-	// create a tmp directory if it doesn't exist
-	mustInitDir(tmpDirPath)
+// outputDir returns the directory the logs, csv files and reports of the run are written to
+func (app *App) outputDir() string {
+	if app.Config.OutputDir != "" {
+		return app.Config.OutputDir
+	}
+	return tmpDirPath
+}
+
+// OutputDirOf returns the directory of a run started by the configured run, e.g. the job of a worker or a capacity
+// trial, in the output directory so the runs don't overwrite each other
+func (cfg *Config) OutputDirOf(runID string) string {
+	outputDir := cfg.OutputDir
+	if outputDir == "" {
+		outputDir = tmpDirPath
+	}
+	return filepath.Join(outputDir, runID)
+}
+
+func openResultsFile(dirPath string) *os.File {
+	// create the output directory if it doesn't exist
+	mustInitDir(dirPath)
 
 	// open the results file
-	file, err := os.Create(fmt.Sprintf("%s/logs.txt", tmpDirPath))
+	file, err := os.Create(fmt.Sprintf("%s/logs.txt", dirPath))
 	if err != nil {
 		panic("Failed to create results file: " + err.Error())
 	}
@@ -66,6 +117,7 @@ func getResultLog(result *Session) string {
 	logString = logString + "User ID: " + result.UserID + "\n"
 	logString = logString + "Score: " + strconv.Itoa(result.Score) + "\n"
 	logString = logString + "Status: " + string(result.Status) + "\n"
+	logString = logString + "Behaviour: " + string(result.Behaviour) + "\n"
 	logString = logString + "Start Time: " + time.UnixMilli(result.StartTime).Format(time.RFC3339) + "\n"
 	logString = logString + "End Time: " + time.UnixMilli(result.EndTime).Format(time.RFC3339) + "\n"
 	logString = logString + "Time Taken: " + strconv.FormatInt(result.EndTime-result.StartTime, 10) + " ms\n"
@@ -73,6 +125,10 @@ func getResultLog(result *Session) string {
 	logString = logString + "Report: " + result.Report + "\n"
 	if result.Error != nil {
 		logString = logString + "Error: " + result.Error.Error() + "\n"
+		logString = logString + "Failed Step: " + result.FailedStep + "\n"
+	}
+	if result.CaptureFile != "" {
+		logString = logString + "Capture: " + result.CaptureFile + "\n"
 	}
 	if result.APIsTimeTaken != nil {
 		logString = logString + "APIs Time Taken:\n"
@@ -88,7 +144,8 @@ func getResultLog(result *Session) string {
 	return logString
 }
 
-func getSummaryLog(timetaken []int64, numOfUsers int) string {
+// getSummaryLog returns the summary of the sessions, with the files of the run in the output directory
+func getSummaryLog(dirPath string, timetaken []int64, numOfUsers int) string {
 	var totalTime int
 	for _, time := range timetaken {
 		totalTime += int(time)
@@ -99,8 +156,78 @@ func getSummaryLog(timetaken []int64, numOfUsers int) string {
 	summary := "-------------------RESULTS--------------------\n"
 	summary += "Total Sessions: " + strconv.Itoa(numOfUsers) + "\n"
 	summary += "Average Time Taken per session: " + strconv.FormatFloat(averageTime, 'f', 2, 64) + " milliseconds\n"
-	summary += "Check ./tmp/logs.txt for all logs\n"
+	summary += "Check " + dirPath + "/logs.txt for all logs\n"
+	summary += "Check " + dirPath + "/sessions.csv and " + dirPath + "/requests.csv for the sessions and requests data\n"
+	summary += "Check " + dirPath + "/timeseries.csv for the metrics of every interval\n"
+	summary += "Check " + dirPath + "/report.html for the run report\n"
 	summary += "-----------------------------------------------\n"
 
 	return summary
 }
+
+// getTimeSeriesLog returns a table of the metrics of every interval, with the p95 latency of every endpoint
+func getTimeSeriesLog(snapshots []metrics.Snapshot, startedAt time.Time) string {
+	var b strings.Builder
+	b.WriteString("-----------------TIME SERIES-------------------\n")
+	tw := tabwriter.NewWriter(&b, 0, 0, 2, ' ', 0)
+	header := "ELAPSED\tSTAGE\tUSERS\tREQUESTS\tERRORS\tRPS"
+	for _, stats := range snapshots[0].Endpoints {
+		header += "\t" + stats.Endpoint + " P95"
+	}
+	fmt.Fprintln(tw, header)
+	for _, snapshot := range snapshots {
+		stage := snapshot.Stage
+		if stage == "" {
+			stage = "-"
+		}
+		row := fmt.Sprintf("%.1fs\t%s\t%d\t%d\t%d\t%.2f/s",
+			snapshot.Time.Sub(startedAt).Seconds(), stage, snapshot.ActiveUsers, snapshot.Requests, snapshot.Errors, snapshot.RPS)
+		for _, stats := range snapshot.Endpoints[:min(len(snapshot.Endpoints), len(snapshots[0].Endpoints))] {
+			row += "\t" + strconv.FormatFloat(stats.P95, 'f', 1, 64) + "ms"
+		}
+		fmt.Fprintln(tw, row)
+	}
+	tw.Flush()
+	b.WriteString("-----------------------------------------------\n")
+	return b.String()
+}
+
+// getSessionResult returns the outcome of the session, the error is unwrapped
+// so sessions failing for the same reason are grouped together
+func getSessionResult(session *Session) metrics.SessionResult {
+	result := metrics.SessionResult{
+		SessionID:  session.ID,
+		Email:      session.Email,
+		Status:     string(session.Status),
+		Topic:      session.Topic,
+		Score:      session.Score,
+		Duration:   time.Duration(session.EndTime-session.StartTime) * time.Millisecond,
+		FailedStep: session.FailedStep,
+		Behaviour:  string(session.Behaviour),
+	}
+	if session.Error != nil {
+		err := session.Error
+		if unwrapped := errors.Unwrap(err); unwrapped != nil {
+			err = unwrapped
+		}
+		result.Error = err.Error()
+	}
+	return result
+}
+
+// WriteHTMLReport writes the run summary as a single html file in the output directory
+func (app *App) WriteHTMLReport(summary *metrics.Summary) (string, error) {
+	mustInitDir(app.outputDir())
+
+	filePath := fmt.Sprintf("%s/report.html", app.outputDir())
+	file, err := os.Create(filePath)
+	if err != nil {
+		return "", fmt.Errorf("failed to create html report file: %w", err)
+	}
+	defer file.Close()
+
+	if err := htmlreport.Write(file, summary); err != nil {
+		return "", err
+	}
+	return filePath, nil
+}
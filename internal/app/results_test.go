@@ -4,9 +4,11 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"path/filepath"
 	"testing"
 	"time"
 
+	"github.com/go-squad-5/quiz-load-test/internal/metrics"
 	"github.com/go-squad-5/quiz-load-test/internal/quizapi"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -27,7 +29,7 @@ func Test_app_results_OpenResultsFile_WhenDirExist(t *testing.T) {
 		require.Nilf(t, r, "Expected openResultsFile() to successfully open results file, but resulted in panic. %v", r)
 	}()
 
-	file := openResultsFile()
+	file := openResultsFile(tmpDirPath)
 	defer file.Close()
 
 	require.NotNil(t, file)
@@ -52,7 +54,7 @@ func Test_app_results_OpenResultsFile_WhenDirNotExist(t *testing.T) {
 		t.Fatalf("Error while remove existing tmp dir for test")
 	}
 
-	file := openResultsFile()
+	file := openResultsFile(tmpDirPath)
 	defer file.Close()
 
 	require.NotNil(t, file)
@@ -60,25 +62,17 @@ func Test_app_results_OpenResultsFile_WhenDirNotExist(t *testing.T) {
 }
 
 func Test_app_results_OpenResultsFile_WhenInvalidDirPath(t *testing.T) {
-	tmpDirPath = "../..\\0/tmp"
-	expectedFilePath := fmt.Sprintf("%s/logs.txt", tmpDirPath)
-
-	defer func() {
-		if err := os.Remove(expectedFilePath); err != nil && !os.IsNotExist(err) {
-			t.Fatalf("Error cleaning up the test file: %s", expectedFilePath)
-		}
-	}()
+	// a directory can't be created under a file
+	parent := filepath.Join(t.TempDir(), "file")
+	require.NoError(t, os.WriteFile(parent, nil, 0644), "Error while creating the file for the test")
+	tmpDirPath = filepath.Join(parent, "tmp")
 
 	defer func() {
 		r := recover()
 		require.NotNilf(t, r, "Expected openResultsFile() to fail to open results file, but resulted in no panic. %v", r)
 	}()
 
-	if err := os.RemoveAll(tmpDirPath); err != nil && !os.IsNotExist(err) {
-		t.Fatalf("Error while removing existing tmp dir for test")
-	}
-
-	file := openResultsFile()
+	file := openResultsFile(tmpDirPath)
 	defer file.Close()
 
 	assert.Nil(t, file, "Expected openResultsFile() to return a nil file pointer, but got non-nil.")
@@ -174,6 +168,8 @@ func Test_app_results_GetResultLog_ErrorStartSession(t *testing.T) {
 		},
 	}
 
+	result.CaptureFile = "./tmp/captures/create_session-1.json"
+
 	logString := getResultLog(result)
 	require.NotEmpty(t, logString, "Expected getResultLog() to return a non-empty string")
 	assert.Contains(t, logString, "Error while starting", "Expected log to contain Error indicator")
@@ -187,6 +183,8 @@ func Test_app_results_GetResultLog_ErrorStartSession(t *testing.T) {
 	assert.Contains(t, logString, fmt.Sprintf("%v", result.Answers), "Expected log to contain answers")
 	assert.Contains(t, logString, result.Report, "Expected log to contain report path")
 	assert.Contains(t, logString, "Error: Test error", "Expected log to contain error message")
+	assert.Contains(t, logString, "Failed Step: ", "Expected log to contain the failed step")
+	assert.Contains(t, logString, "Capture: "+result.CaptureFile, "Expected log to contain the capture file")
 	assert.Contains(t, logString, fmt.Sprintf("Session Creation: %d ms", result.APIsTimeTaken.SessionCreation), "Expected log to contain session creation time")
 	assert.Contains(t, logString, fmt.Sprintf("Start Quiz: %d ms", result.APIsTimeTaken.StartQuiz), "Expected log to contain start quiz time")
 	assert.Contains(t, logString, fmt.Sprintf("Submit Quiz: %d ms", result.APIsTimeTaken.SubmitQuiz), "Expected log to contain submit quiz time")
@@ -239,16 +237,42 @@ func Test_app_results_GetResultLog_WhenNoAPIsTimeTaken(t *testing.T) {
 	assert.Contains(t, logString, "APIs Time Taken: Not available", "Expected log to indicate APIs time taken is not available")
 }
 
+func Test_app_results_OutputDirOf(t *testing.T) {
+	assert.Equal(t, filepath.Join(tmpDirPath, "run-users-10"), (&Config{}).OutputDirOf("run-users-10"), "Expected the directory of the run in the tmp directory")
+	assert.Equal(t, filepath.Join("out", "run-users-10"), (&Config{OutputDir: "out"}).OutputDirOf("run-users-10"), "Expected the directory of the run in the output directory")
+}
+
 func Test_app_results_GetSummaryLog(t *testing.T) {
 	timetaken := []int64{1000, 2000, 1500, 3000}
 	numOfUsers := 4
 	expectedAvgTime := int64(1875)
 
-	summaryLog := getSummaryLog(timetaken, numOfUsers)
+	summaryLog := getSummaryLog("./tmp/run-worker-1", timetaken, numOfUsers)
 
 	require.NotEmpty(t, summaryLog, "Expected getSummaryLog() to return a non-empty string")
 	assert.Contains(t, summaryLog, fmt.Sprintf("%d", numOfUsers), "Expected log to contain number of users")
 	assert.Contains(t, summaryLog, fmt.Sprintf("%d", expectedAvgTime), "Expected log to contain average time taken")
+	assert.Contains(t, summaryLog, "Check ./tmp/run-worker-1/logs.txt", "Expected the files in the output directory")
+	assert.Contains(t, summaryLog, "Check ./tmp/run-worker-1/report.html", "Expected the files in the output directory")
+}
+
+func Test_app_results_GetTimeSeriesLog(t *testing.T) {
+	start := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	snapshots := []metrics.Snapshot{
+		{Time: start.Add(time.Second), Seconds: 1, Requests: 10, RPS: 10, ActiveUsers: 5, Endpoints: []metrics.EndpointStats{
+			{Endpoint: "create_session", Count: 10, P95: 12.5},
+		}},
+		{Time: start.Add(2 * time.Second), Seconds: 1, Requests: 4, Errors: 2, RPS: 4, ActiveUsers: 8, Stage: "spike", Endpoints: []metrics.EndpointStats{
+			{Endpoint: "create_session", Count: 4, Errors: 2, P95: 950},
+		}},
+	}
+
+	timeSeriesLog := getTimeSeriesLog(snapshots, start)
+
+	assert.Contains(t, timeSeriesLog, "TIME SERIES", "Expected the time series title")
+	assert.Contains(t, timeSeriesLog, "create_session P95", "Expected a p95 column per endpoint")
+	assert.Regexp(t, `1\.0s\s+-\s+5\s+10\s+0\s+10\.00/s\s+12\.5ms`, timeSeriesLog, "Expected the first interval")
+	assert.Regexp(t, `2\.0s\s+spike\s+8\s+4\s+2\s+4\.00/s\s+950\.0ms`, timeSeriesLog, "Expected the interval of the stage")
 }
 
 func Test_app_results_ListenForResults(t *testing.T) {
@@ -270,6 +294,7 @@ func Test_app_results_ListenForResults(t *testing.T) {
 	}
 
 	app := NewTestApp()
+	app.Metrics.Flush(time.Now())
 	app.ResultListener.Add(1)
 	go app.ListenForResults()
 
@@ -290,4 +315,50 @@ func Test_app_results_ListenForResults(t *testing.T) {
 	require.NotEmpty(t, logString, "Expected results file to contain log data")
 	assert.Contains(t, logString, "Session ID: "+ssid, "Expected log to contain session ID")
 	assert.Contains(t, logString, "RESULTS", "Expected log to contain summary")
+	assert.Contains(t, logString, "TIME SERIES", "Expected log to contain the time series")
+}
+
+func Test_app_results_GetSessionResult(t *testing.T) {
+	completed := &Session{ID: "ssid-1", Email: "test@example.com", Topic: "go", Score: 7, Status: STATUS_COMPLETED, StartTime: 1000, EndTime: 1250}
+	result := getSessionResult(completed)
+	assert.Equal(t, "ssid-1", result.SessionID, "Expected the session id")
+	assert.Equal(t, "test@example.com", result.Email, "Expected the session email")
+	assert.Equal(t, 250*time.Millisecond, result.Duration, "Expected the session duration")
+	assert.Equal(t, "completed", result.Status, "Expected the session status")
+	assert.Equal(t, "go", result.Topic, "Expected the session topic")
+	assert.Equal(t, 7, result.Score, "Expected the session score")
+	assert.Empty(t, result.Error, "Expected no error for a completed session")
+
+	failed := &Session{
+		Topic:      "go",
+		Status:     STATUS_FAILED,
+		FailedStep: quizapi.EndpointCreateSession,
+		Error:      &StartSessionError{Email: "test@example.com", Topic: "go", err: errors.New("connection refused")},
+	}
+	result = getSessionResult(failed)
+	assert.Equal(t, quizapi.EndpointCreateSession, result.FailedStep, "Expected the failed step")
+	assert.Equal(t, "connection refused", result.Error, "Expected the unwrapped error message")
+}
+
+func Test_app_results_WriteHTMLReport(t *testing.T) {
+	tmpDirPath = "./test" // change path for the test environment
+	defer func() {
+		if err := os.RemoveAll(tmpDirPath); err != nil && !os.IsNotExist(err) {
+			t.Fatalf("Error cleaning up the test files: %s", tmpDirPath)
+		}
+	}()
+
+	app := NewTestApp()
+	app.SessionStats.Add(getSessionResult(&Session{Topic: "go", Score: 3, Status: STATUS_COMPLETED}))
+	app.StartedAt = time.Now().Add(-time.Second)
+	app.FinishedAt = time.Now()
+
+	filePath, err := app.WriteHTMLReport(app.Summary())
+	require.NoError(t, err, "Expected the html report to be written")
+	assert.Equal(t, fmt.Sprintf("%s/report.html", tmpDirPath), filePath, "Expected the report in the tmp directory")
+
+	content, err := os.ReadFile(filePath)
+	require.NoError(t, err, "Expected to read the html report")
+	assert.Contains(t, string(content), "<html", "Expected an html document")
+	assert.Contains(t, string(content), "NUM_USERS", "Expected the run configuration in the report")
 }
@@ -3,6 +3,8 @@ package app
 import (
 	"time"
 
+	"github.com/go-squad-5/quiz-load-test/internal/behaviour"
+	"github.com/go-squad-5/quiz-load-test/internal/metrics"
 	"github.com/go-squad-5/quiz-load-test/internal/quizapi"
 )
 
@@ -13,8 +15,31 @@ const (
 	STATUS_STARTED   STATUS = "started"
 	STATUS_COMPLETED STATUS = "completed"
 	STATUS_FAILED    STATUS = "failed"
+	// the user left after starting the quiz, as its behaviour profile intended
+	STATUS_ABANDONED STATUS = metrics.StatusAbandoned
 )
 
+// STEP_MARK_ANSWERS is the failed step of sessions which failed to answer the questions,
+// the other steps are named after the quiz api endpoints
+const STEP_MARK_ANSWERS = "mark_answers"
+
+// STEP_VALIDATE_REPORT is the failed step of sessions which received an invalid report,
+// the report request itself succeeded
+const STEP_VALIDATE_REPORT = "validate_report"
+
+// STEP_VALIDATE_CONTRACT is the failed step of sessions which received a response violating the contract of its endpoint,
+// the request itself succeeded
+const STEP_VALIDATE_CONTRACT = "validate_contract"
+
+// STEP_ASSERT is the failed step of sessions failing an assert step of their flow
+const STEP_ASSERT = "assert"
+
+// STEP_FLOW is the failed step of sessions whose flow ran more steps than allowed
+const STEP_FLOW = "flow"
+
+// STEP_VERIFY_SCORE is the failed step of sessions scored differently than the answer key
+const STEP_VERIFY_SCORE = "verify_score"
+
 type APIsTimeTaken struct {
 	SessionCreation int64
 	StartQuiz       int64
@@ -60,8 +85,21 @@ type Session struct {
 	Report        string
 	Status        STATUS
 	Error         error
+	FailedStep    string
 	CreatedAt     int64
 	APIsTimeTaken *APIsTimeTaken
+	// intended start of the session at the arrival rate, zero when the users are not scheduled
+	ScheduledAt time.Time
+	// delay of the session behind its schedule, its late start plus the time its earlier steps took over their typical duration
+	Lag time.Duration
+	// last status of the email report polled from the report server, empty unless polled
+	EmailStatus string
+	// time from the email report request to the email sent in ms, zero unless the email was sent
+	EmailProcessingTime int64
+	// path of the user through the quiz flow
+	Behaviour behaviour.Profile
+	// file the requests and responses of the session are saved to, empty unless captured
+	CaptureFile string
 }
 
 func NewSession(email, topic string, aPIsTimeTaken *APIsTimeTaken) *Session {
@@ -77,6 +115,7 @@ func NewSession(email, topic string, aPIsTimeTaken *APIsTimeTaken) *Session {
 		Score:         0,
 		CreatedAt:     time.Now().UnixMilli(),
 		APIsTimeTaken: aPIsTimeTaken,
+		Behaviour:     behaviour.Complete,
 	}
 }
 
@@ -84,6 +123,21 @@ func (s *Session) SetSession(ssid string) {
 	s.ID = ssid
 }
 
+// SetScheduledAt sets the intended start of the session, the session is expected to start right after
+func (s *Session) SetScheduledAt(scheduledAt time.Time) {
+	s.ScheduledAt = scheduledAt
+	s.Lag = max(time.Since(scheduledAt), 0)
+}
+
+// AddLag delays the schedule of the later steps of the session
+func (s *Session) AddLag(lag time.Duration) {
+	s.Lag += lag
+}
+
+func (s *Session) SetBehaviour(profile behaviour.Profile) {
+	s.Behaviour = profile
+}
+
 func (s *Session) SetStatus(status STATUS) {
 	s.Status = status
 }
@@ -112,6 +166,18 @@ func (s *Session) SetError(err error) {
 	s.Error = err
 }
 
+func (s *Session) SetFailedStep(step string) {
+	s.FailedStep = step
+}
+
+func (s *Session) SetEmailStatus(status string) {
+	s.EmailStatus = status
+}
+
+func (s *Session) SetEmailProcessingTime(timetaken int64) {
+	s.EmailProcessingTime = timetaken
+}
+
 func (s *Session) SetQuestions(questions []quizapi.Question) {
 	s.Question = questions
 }
@@ -69,6 +69,12 @@ func Test_app_session_SetError(t *testing.T) {
 	assert.Equal(t, err.Error(), session.Error.Error(), "Session Error message should match the input error message")
 }
 
+func Test_app_session_SetFailedStep(t *testing.T) {
+	session := NewSession("", "", nil)
+	session.SetFailedStep(quizapi.EndpointSubmitQuiz)
+	require.Equal(t, quizapi.EndpointSubmitQuiz, session.FailedStep, "Session FailedStep should be set correctly")
+}
+
 func Test_app_session_SetStartTime(t *testing.T) {
 	session := NewSession("", "", nil)
 	startTime := int64(1633072800000) // Example timestamp
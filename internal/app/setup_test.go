@@ -2,23 +2,28 @@ package app
 
 import (
 	"log"
+	"log/slog"
 	"os"
 	"sync"
+	"time"
 
+	"github.com/go-squad-5/quiz-load-test/internal/abort"
+	"github.com/go-squad-5/quiz-load-test/internal/metrics"
+	"github.com/go-squad-5/quiz-load-test/internal/quizapi"
 	"github.com/go-squad-5/quiz-load-test/internal/quizapi/mock"
 )
 
 func NewTestApp() *App {
-	infoLog := log.New(os.Stdout, "INFO\t", log.Ltime)
-	errorLog := log.New(os.Stdout, "ERROR\t", log.Ltime)
-	debugLog := log.New(os.Stdout, "DEBUG\t", log.Ltime)
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug}))
 	resultLog := log.New(os.Stdout, "RESULT\t", log.Ltime)
 	cfg := Config{
 		BaseURL:             "http://localhost:8080",
 		ReportServerBaseURL: "http://localhost:8070",
 		NumUsers:            10,
+		MetricsInterval:     time.Second,
 	}
 	quizApi := &mock.MockQuizAPI{}
+	collector := metrics.NewCollector(quizapi.Endpoints...)
 	return &App{
 		Config:         &cfg,
 		Wait:           &sync.WaitGroup{},
@@ -26,10 +31,16 @@ func NewTestApp() *App {
 		Errors:         make(chan error),
 		ResultListener: &sync.WaitGroup{},
 		ErrorListener:  &sync.WaitGroup{},
-		InfoLogger:     infoLog,
-		ErrorLogger:    errorLog,
-		DebugLogger:    debugLog,
+		Logger:         logger,
 		ResultLogger:   resultLog,
 		QuizAPI:        quizApi,
+		Metrics:        collector,
+		Prometheus:     metrics.NewPrometheusExporter(collector.ActiveUsers),
+		SessionStats:   metrics.NewSessionStats(),
+		NegativeStats:  metrics.NewNegativeStats(),
+		Abort:          abort.NewMonitor(abort.Rules{}),
+		rand:           newLockedRand(1),
+		// the misuse requests of the negative scenarios are expected on the same mock
+		NegativeQuizAPI: quizApi,
 	}
 }
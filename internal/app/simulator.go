@@ -1,81 +1,184 @@
 package app
 
 import (
+	"errors"
 	"fmt"
-	"math/rand"
+	"strings"
 	"sync"
 	"time"
 
+	"github.com/go-squad-5/quiz-load-test/internal/flow"
+	"github.com/go-squad-5/quiz-load-test/internal/metrics"
 	"github.com/go-squad-5/quiz-load-test/internal/quizapi"
 )
 
+// loadProfileTick is how often the number of virtual users is adjusted to the load profile
+const loadProfileTick = 100 * time.Millisecond
+
+// StartSimulation starts all the users at once, or at the configured arrival rate.
+// With a load profile it follows the stages of the profile until they are over.
 func (app *App) StartSimulation() {
+	app.StartedAt = time.Now()
+	if !app.Config.LoadProfile.Empty() {
+		app.runLoadProfile()
+		return
+	}
+	var interval time.Duration
+	if app.Config.ArrivalRate > 0 {
+		interval = time.Duration(float64(time.Second) / app.Config.ArrivalRate)
+	}
 	for i := range app.Config.NumUsers {
+		// wait until the start time of the user, so the rate doesn't drift with slow iterations
+		timer := time.NewTimer(time.Until(app.StartedAt.Add(time.Duration(i) * interval)))
+		select {
+		case <-app.Abort.Aborted():
+			timer.Stop()
+			app.Logger.Error("Run aborted, not starting the remaining users", "users", app.Config.NumUsers-i, "reason", app.Abort.Reason())
+			return
+		case <-timer.C:
+		}
 		numEmails, numTopics := getNumberOfEmailsAndTopics()
-		email := EMAILS[i%numEmails]
-		topic := TOPICS[i%numTopics]
+		user := app.Config.userIndex(i)
+		email := EMAILS[user%numEmails]
+		topic := TOPICS[user%numTopics]
 		app.Wait.Add(1)
-		app.InfoLogger.Println("GO ROUTINE started for user simulation: ", email, "on topic:", topic)
-		go app.SimulateUser(email, topic)
+		app.Logger.Debug("GO ROUTINE STARTED for user simulation", "email", email, "topic", topic)
+		if interval > 0 {
+			go app.simulateScheduledUser(email, topic, app.StartedAt.Add(time.Duration(i)*interval))
+		} else {
+			go app.SimulateUser(email, topic)
+		}
+	}
+}
+
+// runLoadProfile keeps the number of virtual users at the target of the load profile,
+// and labels the requests with the stage in progress
+func (app *App) runLoadProfile() {
+	profile := app.Config.LoadProfile
+	app.Logger.Info("Following the load profile", "load_profile", profile.String(), "duration", profile.Duration())
+
+	ticker := time.NewTicker(loadProfileTick)
+	defer ticker.Stop()
+	// stop channels of the running virtual users, in the order they started
+	users := []chan struct{}{}
+	numEmails, numTopics := getNumberOfEmailsAndTopics()
+	started := 0
+	stage := ""
+	for {
+		now := time.Now()
+		target, current, done := profile.At(now.Sub(app.StartedAt))
+		if !done && current != stage {
+			stage = current
+			app.Metrics.SetStage(stage, now)
+			app.Logger.Info("Load profile stage started", "stage", stage, "users", target)
+		}
+		for len(users) < target {
+			stop := make(chan struct{})
+			users = append(users, stop)
+			user := app.Config.userIndex(started)
+			email := EMAILS[user%numEmails]
+			topic := TOPICS[user%numTopics]
+			started++
+			app.Wait.Add(1)
+			go app.runVirtualUser(email, topic, stop)
+		}
+		// the stopped users finish their session in progress
+		for len(users) > target {
+			close(users[len(users)-1])
+			users = users[:len(users)-1]
+		}
+		if done {
+			app.Logger.Info("Load profile finished, waiting for the sessions in progress")
+			return
+		}
+
+		select {
+		case <-app.Abort.Aborted():
+			for _, stop := range users {
+				close(stop)
+			}
+			app.Logger.Error("Run aborted, stopping the virtual users", "users", len(users), "stage", stage, "reason", app.Abort.Reason())
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// runVirtualUser simulates sessions one after the other until the user is stopped
+func (app *App) runVirtualUser(email, topic string, stop <-chan struct{}) {
+	defer app.Wait.Done()
+	for {
+		select {
+		case <-stop:
+			return
+		default:
+		}
+		app.Wait.Add(1)
+		app.SimulateUser(email, topic)
 	}
 }
 
 func (app *App) SimulateUser(email, topic string) {
+	app.simulateScheduledUser(email, topic, time.Time{})
+}
+
+// simulateScheduledUser simulates a user which should have started at the scheduled time,
+// the latency of its requests is also measured from their intended start
+func (app *App) simulateScheduledUser(email, topic string, scheduledAt time.Time) {
+	app.Metrics.UserStarted()
+	defer app.Metrics.UserFinished()
 	defer func() {
 		if r := recover(); r != nil {
-			app.ErrorLogger.Println("Recovered from panic in user", email, ":", r)
+			app.Logger.Error("Recovered from panic in user", "email", email, "topic", topic, "panic", r)
 		}
-		app.InfoLogger.Println("GO ROUTINE FINISHED for user simulation:", email, "on topic:", topic)
+		app.Logger.Debug("GO ROUTINE FINISHED for user simulation", "email", email, "topic", topic)
 		app.Wait.Done()
 	}()
-	app.InfoLogger.Printf("Simulating user action for email: %s, topic: %s\n", email, topic)
+	app.Logger.Debug("Simulating user action", "email", email, "topic", topic)
 
 	// create session struct
 	aPIsTimeTaken := NewAPIsTimeTaken()
 	session := NewSession(email, topic, aPIsTimeTaken)
-
-	ssid, createTimeTaken, err := app.callCreateSession(email, topic)
-	aPIsTimeTaken.SetSessionCreationTime(createTimeTaken)
-	if err != nil {
-		return
+	if !scheduledAt.IsZero() {
+		session.SetScheduledAt(scheduledAt)
 	}
-	session.SetSession(ssid)
 
-	questions, startQuizTimeTaken, err := app.callStartQuiz(ssid, topic, session)
-	aPIsTimeTaken.SetStartQuizTime(startQuizTimeTaken)
-	if err != nil {
+	// a share of the sessions misuse the api instead of running the quiz
+	if scenario := app.pickNegativeScenario(); scenario != "" {
+		app.runNegativeScenario(scenario, session)
 		return
 	}
-	session.SetQuestions(questions)
+	session.SetBehaviour(app.pickBehaviour())
+	defer func() {
+		if session.ID != "" {
+			app.sessions.Delete(session.ID)
+		}
+	}()
 
-	// mark random answers to questions
-	if err := app.markRandomAnswers(questions, session); err != nil {
-		return
+	// run the steps of the user flow, a failed session is already reported
+	switch app.runFlow(app.userFlow(), session) {
+	case STATUS_ABANDONED:
+		app.abandonSession(session)
+	case STATUS_COMPLETED:
+		session.SetEndTime(time.Now())
+		session.SetStatus(STATUS_COMPLETED)
+		app.sessionLogger(session).Info("Session completed", "score", session.Score)
+		app.Results <- session
 	}
-
-	score, submitTimeTaken, err := app.callSubmitQuiz(ssid, session)
-	aPIsTimeTaken.SetSubmitQuizTime(submitTimeTaken)
-	session.SetScore(score)
-
-	// call report and email apis concurrently
-	app.callReportAndEmailAPIs(session)
-
-	// end session
-	session.SetEndTime(time.Now())
-	session.SetStatus(STATUS_COMPLETED)
-
-	app.InfoLogger.Printf("Session completed for email: %s, topic: %s, session ID: %s, score: %d\n Sending session data to the results channel to log.", email, topic, ssid, score)
-	app.Results <- session
 }
 
-func (app *App) callCreateSession(email, topic string) (string, int64, error) {
-	app.InfoLogger.Println("Sending Request to create session for email:", email, "on topic:", topic)
+func (app *App) callCreateSession(session *Session) (string, int64, error) {
+	if session == nil {
+		return "", 0, fmt.Errorf("sesssion should be non-nil value")
+	}
+	email, topic := session.Email, session.Topic
+	app.Logger.Debug("Sending Request to create session", "email", email, "topic", topic)
 	createStart := time.Now()
 	ssid, err := app.QuizAPI.CreateSession(email, topic)
 	createEnd := time.Now()
-	app.InfoLogger.Printf("Session created for email: %s, topic: %s, session ID: %s\n", email, topic, ssid)
+	app.observeIntended(session, quizapi.EndpointCreateSession, createStart, createEnd, err)
 	if err != nil {
-		app.ErrorLogger.Printf("Error creating session for email: %s, topic: %s, error: %v\n", email, topic, err)
+		app.Logger.Error("Error creating session", "email", email, "topic", topic, "error", err)
 		app.Errors <- &StartSessionError{
 			Email: email,
 			Topic: topic,
@@ -83,6 +186,7 @@ func (app *App) callCreateSession(email, topic string) (string, int64, error) {
 		}
 		return "", getTimeDiff(createStart, createEnd), err
 	}
+	app.Logger.Debug("Session created", "email", email, "topic", topic, "session_id", ssid)
 	return ssid, getTimeDiff(createStart, createEnd), nil
 }
 
@@ -90,14 +194,15 @@ func (app *App) callStartQuiz(ssid, topic string, session *Session) ([]quizapi.Q
 	if session == nil {
 		return nil, 0, fmt.Errorf("sesssion should be non-nil value")
 	}
-	app.InfoLogger.Println("Sending Request to start quiz for session ID:", ssid, "on topic:", topic)
+	app.sessionLogger(session).Debug("Sending Request to start quiz")
 	startQuizStart := time.Now()
 	questions, err := app.QuizAPI.StartQuiz(ssid, topic)
 	startQuizEnd := time.Now()
-	app.InfoLogger.Printf("Got questions for session ID: %s, topic: %s, questions: %d\n", ssid, topic, len(questions))
+	app.observeIntended(session, quizapi.EndpointStartQuiz, startQuizStart, startQuizEnd, err)
 	if err != nil {
-		app.ErrorLogger.Printf("Error starting quiz for session ID: %s, topic: %s, error: %v\n", ssid, topic, err)
+		app.sessionLogger(session).Error("Error starting quiz", "error", err)
 		session.SetError(err)
+		session.SetFailedStep(failedStep(quizapi.EndpointStartQuiz, err))
 		session.SetStatus(STATUS_FAILED)
 		session.SetEndTime(time.Now())
 		app.Errors <- &SessionError{
@@ -105,7 +210,7 @@ func (app *App) callStartQuiz(ssid, topic string, session *Session) ([]quizapi.Q
 		}
 		return nil, getTimeDiff(startQuizStart, startQuizEnd), err
 	}
-	app.InfoLogger.Printf("Quiz started for session ID: %s, topic: %s, questions: %d\n", ssid, topic, len(questions))
+	app.sessionLogger(session).Debug("Quiz started", "questions", len(questions))
 	return questions, getTimeDiff(startQuizStart, startQuizEnd), nil
 }
 
@@ -120,8 +225,9 @@ func (app *App) markRandomAnswers(questions []quizapi.Question, session *Session
 	for _, question := range questions {
 		numOptions := len(question.Options)
 		if numOptions == 0 {
-			app.ErrorLogger.Println("No options available for question ID:", question.ID)
+			app.sessionLogger(session).Error("No options available for the question", "question_id", question.ID)
 			session.SetError(fmt.Errorf("no options available for question ID: %s", question.ID))
+			session.SetFailedStep(STEP_MARK_ANSWERS)
 			session.SetStatus(STATUS_FAILED)
 			session.SetEndTime(time.Now())
 			app.Errors <- &SessionError{
@@ -129,7 +235,7 @@ func (app *App) markRandomAnswers(questions []quizapi.Question, session *Session
 			}
 			return session.Error
 		}
-		index := rand.Intn(numOptions) // random index
+		index := app.rand.Intn(numOptions) // random index
 		answers = append(answers, quizapi.Answer{
 			QuestionID: question.ID,
 			Answer:     question.Options[index],
@@ -139,78 +245,83 @@ func (app *App) markRandomAnswers(questions []quizapi.Question, session *Session
 	return nil
 }
 
+// verifyScore compares the score of the session with the score expected from the answer key,
+// so a scoring service returning wrong scores under load fails the sessions
+func (app *App) verifyScore(session *Session) error {
+	expected, missing := app.Config.AnswerKey.Score(session.Answers)
+	if len(missing) > 0 {
+		app.sessionLogger(session).Warn("Score not verified, questions missing from the answer key", "questions", strings.Join(missing, ", "))
+		return nil
+	}
+	if session.Score == expected {
+		return nil
+	}
+
+	err := fmt.Errorf("score %d, expected %d from the answer key", session.Score, expected)
+	app.sessionLogger(session).Error("Wrong score", "error", err)
+	session.SetError(err)
+	session.SetFailedStep(STEP_VERIFY_SCORE)
+	session.SetStatus(STATUS_FAILED)
+	session.SetEndTime(time.Now())
+	app.Errors <- &SessionError{
+		Session: session,
+	}
+	return err
+}
+
 func (app *App) callSubmitQuiz(ssid string, session *Session) (int, int64, error) {
 	if session == nil {
 		return 0, 0, fmt.Errorf("sesssion should be non-nil value")
 	}
-	app.InfoLogger.Println("Sending Request to submit quiz for session ID:", ssid)
+	app.sessionLogger(session).Debug("Sending Request to submit quiz")
 	submitStart := time.Now()
 	score, err := app.QuizAPI.SubmitQuiz(ssid, session.Answers)
+	for attempt := 1; err != nil && shouldRetrySubmit(session, err, attempt); attempt++ {
+		app.sessionLogger(session).Warn("Retrying to submit quiz", "retry", attempt, "error", err)
+		time.Sleep(submitRetryDelay)
+		score, err = app.QuizAPI.SubmitQuiz(ssid, session.Answers)
+	}
+	// the time taken includes the retries, as waited by the user
 	submitEnd := time.Now()
-	app.InfoLogger.Printf("Quiz submitted for session ID: %s, score: %d\n", ssid, score)
+	app.observeIntended(session, quizapi.EndpointSubmitQuiz, submitStart, submitEnd, err)
 	if err != nil {
-		app.ErrorLogger.Printf("Error submitting quiz for session ID: %s, error: %v\n", ssid, err)
+		app.sessionLogger(session).Error("Error submitting quiz", "error", err)
 		session.SetStatus(STATUS_FAILED)
 		session.SetError(err)
+		session.SetFailedStep(failedStep(quizapi.EndpointSubmitQuiz, err))
 		session.SetEndTime(submitEnd)
 		app.Errors <- &SessionError{
 			Session: session,
 		}
 		return 0, getTimeDiff(submitStart, submitEnd), err
 	}
+	app.sessionLogger(session).Debug("Quiz submitted", "score", score)
 	return score, getTimeDiff(submitStart, submitEnd), nil
 }
 
-func (app *App) callReportAndEmailAPIs(session *Session) {
-	if session == nil {
-		panic("session should be non-nil value")
-	}
-
-	wg := &sync.WaitGroup{}
-
-	wg.Add(1)
-	app.InfoLogger.Println("GO ROUTINE Started to get report for session ID:", session.ID)
-	go func() {
-		defer wg.Done()
-		defer app.InfoLogger.Println("GO ROUTINE FINISHED for getting report for session ID:", session.ID)
-		// Get the report for the session
-		report, reportTimeTaken, _ := app.callGetReport(session)
-		session.APIsTimeTaken.SetReportAPITime(reportTimeTaken)
-		session.SetReport(report)
-	}()
-
-	wg.Add(1)
-	app.InfoLogger.Println("GO ROUTINE Started to get email report for session ID:", session.ID)
-	go func() {
-		defer wg.Done()
-		defer app.InfoLogger.Println("GO ROUTINE FINISHED for getting email report for session ID:", session.ID)
-		// Do email request
-		timeTaken, _ := app.callGetEmail(session)
-		session.APIsTimeTaken.SetEmailAPITime(timeTaken)
-	}()
-
-	wg.Wait()
-}
-
 func (app *App) callGetReport(session *Session) (string, int64, error) {
 	if session == nil {
 		return "", 0, fmt.Errorf("sesssion should be non-nil value")
 	}
-	app.InfoLogger.Println("Sending Request to get report for session ID:", session.ID)
+	report, timeTaken, err := app.getReport(session)
+	if err != nil {
+		app.failSession(session, failedStep(quizapi.EndpointGetReport, err), err)
+	}
+	return report, timeTaken, err
+}
+
+// getReport requests the report of the session, a failed request is left to the caller to report
+func (app *App) getReport(session *Session) (string, int64, error) {
+	app.sessionLogger(session).Debug("Sending Request to get report")
 	reportStart := time.Now()
 	report, err := app.QuizAPI.GetReport(session.ID)
 	reportEnd := time.Now()
-	app.InfoLogger.Printf("Report received for session ID: %s, report: %+v\n", session.ID, report)
+	app.observeIntended(session, quizapi.EndpointGetReport, reportStart, reportEnd, err)
 	if err != nil {
-		session.SetError(err)
-		session.SetStatus(STATUS_FAILED)
-		session.SetEndTime(time.Now())
-		app.ErrorLogger.Printf("Error getting report for session ID: %s, error: %v\n", session.ID, err)
-		app.Errors <- &SessionError{
-			Session: session,
-		}
+		app.sessionLogger(session).Error("Error getting report", "error", err)
 		return "", getTimeDiff(reportStart, reportEnd), err
 	}
+	app.sessionLogger(session).Debug("Report received", "report", report)
 	return report, getTimeDiff(reportStart, reportEnd), nil
 }
 
@@ -218,21 +329,141 @@ func (app *App) callGetEmail(session *Session) (int64, error) {
 	if session == nil {
 		return 0, fmt.Errorf("sesssion should be non-nil value")
 	}
-	app.InfoLogger.Println("Sending Request to get email report for session ID:", session.ID)
+	timeTaken, step, err := app.getEmailReport(session)
+	if err != nil && !errors.Is(err, errRunAborted) {
+		app.failSession(session, step, err)
+	}
+	return timeTaken, err
+}
+
+// getEmailReport requests the email report of the session and polls its status when enabled, and returns the time
+// taken by the request. A failed request or poll is left to the caller to report, with the step the session failed at
+func (app *App) getEmailReport(session *Session) (int64, string, error) {
+	app.sessionLogger(session).Debug("Sending Request to get email report")
 	emailStart := time.Now()
 	_, err := app.QuizAPI.GetEmailReport(session.ID)
 	emailEnd := time.Now()
-	app.InfoLogger.Printf("Email Request Successful for session ID: %s\n", session.ID)
+	app.observeIntended(session, quizapi.EndpointEmailReport, emailStart, emailEnd, err)
 	if err != nil {
-		session.SetError(err)
-		session.SetStatus(STATUS_FAILED)
-		session.SetEndTime(emailEnd)
-		app.Errors <- &SessionError{
-			Session: session,
+		app.sessionLogger(session).Error("Error getting email report", "error", err)
+		return getTimeDiff(emailStart, emailEnd), quizapi.EndpointEmailReport, err
+	}
+	app.sessionLogger(session).Debug("Email Request Successful")
+	if app.EmailSink != nil {
+		app.EmailSink.Expect(session.ID, session.Email, emailEnd)
+	}
+	if app.Config.EmailStatusInterval > 0 {
+		if err := app.pollEmailStatus(session, emailStart); err != nil {
+			return getTimeDiff(emailStart, emailEnd), failedStep(quizapi.EndpointEmailStatus, err), err
+		}
+	}
+	return getTimeDiff(emailStart, emailEnd), "", nil
+}
+
+// pollEmailStatus polls the status of the accepted email report until the email is sent or failed, the timeout expires
+// or the run is aborted. The processing time is measured from the email report request, to load test the whole asynchronous pipeline.
+func (app *App) pollEmailStatus(session *Session, requestedAt time.Time) error {
+	deadline := requestedAt.Add(app.Config.EmailStatusTimeout)
+	ticker := time.NewTicker(app.Config.EmailStatusInterval)
+	defer ticker.Stop()
+
+	var err error
+	for {
+		select {
+		case <-app.Abort.Aborted():
+			return errRunAborted
+		case <-ticker.C:
+		}
+		status, pollErr := app.QuizAPI.GetEmailReportStatus(session.ID)
+		polledAt := time.Now()
+		if pollErr == nil {
+			session.SetEmailStatus(status.Status)
+			if status.Completed() {
+				session.SetEmailProcessingTime(getTimeDiff(requestedAt, polledAt))
+				app.sessionLogger(session).Debug("Email sent", "status", status.Status)
+				return nil
+			}
+			if status.Failed() {
+				err = fmt.Errorf("email report %s: %s", status.Status, status.Message)
+				break
+			}
+		}
+		if isContractError(pollErr) {
+			err = pollErr
+			break
+		}
+		// a failed poll is retried until the timeout, the server may be overloaded
+		if polledAt.After(deadline) {
+			if pollErr != nil {
+				err = fmt.Errorf("email report not sent within %s: %w", app.Config.EmailStatusTimeout, pollErr)
+			} else {
+				err = fmt.Errorf("email report not sent within %s, last status: %s", app.Config.EmailStatusTimeout, status.Status)
+			}
+			break
 		}
-		return getTimeDiff(emailStart, emailEnd), err
 	}
-	return getTimeDiff(emailStart, emailEnd), nil
+
+	app.sessionLogger(session).Error("Error polling email status", "error", err)
+	return err
+}
+
+// observeIntended records the latency of a request of a scheduled session measured from its intended start.
+// The requests of a session behind its schedule are delayed by its lag, as a user arriving on schedule
+// would have waited for them, so stalls of the server are not hidden by users waiting on their earlier requests.
+func (app *App) observeIntended(session *Session, endpoint string, start, end time.Time, err error) {
+	if session.ScheduledAt.IsZero() {
+		return
+	}
+	app.Metrics.ObserveIntended(metrics.Observation{
+		Time:     start,
+		Endpoint: endpoint,
+		Topic:    session.Topic,
+		Latency:  end.Sub(start) + session.Lag,
+		Failed:   err != nil && !isReportError(err) && !isContractError(err),
+	})
+}
+
+// advanceSchedule records the duration of the steps of a scheduled session, and delays the schedule of its later steps
+// by the time the steps took over their typical duration. A session held up by a stall then measures its later requests
+// from when a user on schedule would have sent them, rather than from when it could send them
+func (app *App) advanceSchedule(session *Session, steps []flow.Step, took time.Duration) {
+	if session.ScheduledAt.IsZero() {
+		return
+	}
+	actions := []string{}
+	for _, step := range steps {
+		if step.Action.Request() {
+			actions = append(actions, string(step.Action))
+		}
+	}
+	// the other steps, e.g. a sleep, don't depend on the server
+	if len(actions) == 0 {
+		return
+	}
+	typical := app.schedule.observe(strings.Join(actions, "+"), took)
+	session.AddLag(max(took-typical, 0))
+}
+
+// stepDurations holds the durations of the steps of the scheduled sessions by step
+type stepDurations struct {
+	mu    sync.Mutex
+	steps map[string]*metrics.Histogram
+}
+
+// observe records the duration of the step, and returns the typical duration of the step, the median so far
+func (d *stepDurations) observe(step string, took time.Duration) time.Duration {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.steps == nil {
+		d.steps = map[string]*metrics.Histogram{}
+	}
+	durations, ok := d.steps[step]
+	if !ok {
+		durations = metrics.NewHistogram()
+		d.steps[step] = durations
+	}
+	durations.Record(float64(took.Microseconds()) / 1000)
+	return time.Duration(durations.Quantile(0.5) * float64(time.Millisecond))
 }
 
 // NOTE: FakeUserAction can be used instead of SimulateUser to run the application
@@ -2,9 +2,14 @@ package app
 
 import (
 	"errors"
+	"fmt"
+	"syscall"
 	"testing"
 	"time"
 
+	"github.com/go-squad-5/quiz-load-test/internal/abort"
+	"github.com/go-squad-5/quiz-load-test/internal/answerkey"
+	"github.com/go-squad-5/quiz-load-test/internal/metrics"
 	"github.com/go-squad-5/quiz-load-test/internal/quizapi"
 	"github.com/go-squad-5/quiz-load-test/internal/quizapi/mock"
 	"github.com/stretchr/testify/assert"
@@ -27,7 +32,7 @@ func Test_app_simulator_CallCreateSession_WhenSuccess(t *testing.T) {
 		After(time.Millisecond*100). // 100 ms
 		Return(expectedSsid, nil)
 
-	ssid, timeTaken, err := app.callCreateSession(email, topic)
+	ssid, timeTaken, err := app.callCreateSession(NewSession(email, topic, NewAPIsTimeTaken()))
 	require.NoError(t, err, "Expected create session call to return no error")
 	mockApp.AssertExpectations(t)
 	assert.GreaterOrEqual(t, int64(timeTaken), int64(100), "Expected create session api call time to be at least 1 second")
@@ -62,7 +67,7 @@ func Test_app_simulator_CallCreateSession_WhenFailure(t *testing.T) {
 		require.Truef(t, ok, "Expected error to be the start session error")
 	}()
 
-	ssid, timeTaken, err := app.callCreateSession(email, topic)
+	ssid, timeTaken, err := app.callCreateSession(NewSession(email, topic, NewAPIsTimeTaken()))
 
 	mockApp.AssertExpectations(t)
 	require.Error(t, err, "Expected an error when failure case")
@@ -72,6 +77,88 @@ func Test_app_simulator_CallCreateSession_WhenFailure(t *testing.T) {
 	app.Wait.Wait()
 }
 
+func Test_app_simulator_CallCreateSession_WhenNilSession(t *testing.T) {
+	app := NewTestApp()
+	_, _, err := app.callCreateSession(nil)
+	require.Error(t, err, "Expected callCreateSession to return error when passing nil session value")
+}
+
+func Test_app_simulator_CallCreateSession_WhenScheduledLate(t *testing.T) {
+	email := "test@example.com"
+	topic := "math"
+
+	app := NewTestApp()
+	mockApp, ok := app.QuizAPI.(*mock.MockQuizAPI)
+	require.True(t, ok, "Error while getting the mock quizapi")
+	mockApp.On("CreateSession", email, topic).After(50*time.Millisecond).Return("12345", nil)
+
+	session := NewSession(email, topic, NewAPIsTimeTaken())
+	session.SetScheduledAt(time.Now().Add(-200 * time.Millisecond))
+	_, _, err := app.callCreateSession(session)
+	require.NoError(t, err, "Expected create session call to return no error")
+
+	assert.GreaterOrEqual(t, session.Lag, 200*time.Millisecond, "Expected the delay of the session start")
+	intended := app.Metrics.IntendedTotals()
+	require.NotEmpty(t, intended, "Expected the request measured from its intended start")
+	assert.Equal(t, quizapi.EndpointCreateSession, intended[0].Endpoint, "Expected the endpoint of the request")
+	assert.GreaterOrEqual(t, intended[0].Latency.Max, 250.0, "Expected the latency to include the start lag")
+}
+
+func Test_app_simulator_StartSimulation_WhenServerStalls(t *testing.T) {
+	app := NewTestApp()
+	// every user has its own session, the last user to start waits on a stalled start quiz
+	app.Config.NumUsers = len(EMAILS)
+	app.Config.ArrivalRate = 100
+	app.Results = make(chan *Session, app.Config.NumUsers)
+	app.Config.Flow = mustParseFlow(t, `{"steps": ["create_session", "start_quiz", "answer", "submit"]}`)
+
+	mockApp, ok := app.QuizAPI.(*mock.MockQuizAPI)
+	require.True(t, ok, "Error while getting the mock quizapi")
+	for i := range app.Config.NumUsers {
+		ssid := fmt.Sprintf("session-%d", i)
+		topic := TOPICS[i%len(TOPICS)]
+		stall := 5 * time.Millisecond
+		if i == app.Config.NumUsers-1 {
+			stall = 300 * time.Millisecond
+		}
+		mockApp.On("CreateSession", EMAILS[i], topic).After(5*time.Millisecond).Return(ssid, nil)
+		mockApp.On("StartQuiz", ssid, topic).After(stall).Return([]quizapi.Question{{ID: "q1", Options: []string{"3"}}}, nil)
+		mockApp.On("SubmitQuiz", ssid, []quizapi.Answer{{QuestionID: "q1", Answer: "3"}}).After(5*time.Millisecond).Return(1, nil)
+	}
+
+	app.StartSimulation()
+	app.Wait.Wait()
+	close(app.Results)
+	for result := range app.Results {
+		assert.Equal(t, STATUS_COMPLETED, result.Status, "Expected every session to complete")
+	}
+
+	p99 := func(totals []metrics.EndpointTotals, endpoint string) float64 {
+		for _, total := range totals {
+			if total.Endpoint == endpoint {
+				return total.Latency.Quantile(0.99)
+			}
+		}
+		t.Fatalf("Expected requests to %s", endpoint)
+		return 0
+	}
+	sent := p99(app.Metrics.Totals(), quizapi.EndpointSubmitQuiz)
+	intended := p99(app.Metrics.IntendedTotals(), quizapi.EndpointSubmitQuiz)
+	assert.Less(t, sent, 100.0, "Expected the submit requests sent at once")
+	assert.Greater(t, intended-sent, 200.0, "Expected the submit held up by the stall measured from its intended start")
+}
+
+func Test_app_simulator_CallCreateSession_WhenNotScheduled(t *testing.T) {
+	app := NewTestApp()
+	mockApp, ok := app.QuizAPI.(*mock.MockQuizAPI)
+	require.True(t, ok, "Error while getting the mock quizapi")
+	mockApp.On("CreateSession", "test@example.com", "math").Return("12345", nil)
+
+	_, _, err := app.callCreateSession(NewSession("test@example.com", "math", NewAPIsTimeTaken()))
+	require.NoError(t, err, "Expected create session call to return no error")
+	assert.Empty(t, app.Metrics.IntendedTotals(), "Expected no latency from the intended start without a schedule")
+}
+
 func Test_app_simulator_CallStartQuiz_Success(t *testing.T) {
 	ssid := "12345"
 	topic := "math"
@@ -405,45 +492,106 @@ func Test_app_simulator_CallGetEmail_WhenError(t *testing.T) {
 	call.Unset()
 }
 
-func Test_app_simulator_CallGetEmail_WhenNilSession(t *testing.T) {
-	app := NewTestApp()
-	_, err := app.callGetEmail(nil)
-	require.Error(t, err, "Expected callGetEmail to return error when passing nil session value")
-}
-
-func Test_app_simulator_CallReportAndEmailAPIs(t *testing.T) {
-	apisTimeTaken := &APIsTimeTaken{}
-	session := NewSession("test@example.com", "math", apisTimeTaken)
+func Test_app_simulator_CallGetEmail_WhenEmailSent(t *testing.T) {
+	session := NewSession("test@example.com", "math", nil)
+	session.SetSession("1234")
 
 	app := NewTestApp()
+	app.Config.EmailStatusInterval = 10 * time.Millisecond
+	app.Config.EmailStatusTimeout = time.Second
 
 	mockApp, ok := app.QuizAPI.(*mock.MockQuizAPI)
-	if !ok {
-		t.Fatal("Error while getting the mock quizapi")
+	require.True(t, ok, "Error while getting the mock quizapi")
+	mockApp.On("GetEmailReport", "1234").Return("Email report request accepted", nil)
+	mockApp.On("GetEmailReportStatus", "1234").Return(quizapi.EmailReportStatus{Status: "processing"}, nil).Twice()
+	mockApp.On("GetEmailReportStatus", "1234").Return(quizapi.EmailReportStatus{Status: "sent"}, nil).Once()
+
+	_, err := app.callGetEmail(session)
+	require.NoError(t, err, "Expected the email to be sent")
+	mockApp.AssertExpectations(t)
+	assert.Equal(t, "sent", session.EmailStatus, "Expected the final email status on the session")
+	assert.GreaterOrEqual(t, session.EmailProcessingTime, int64(30), "Expected the processing time to cover the three polls")
+}
+
+func Test_app_simulator_CallGetEmail_WhenEmailFailed(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  quizapi.EmailReportStatus
+		err     error
+		message string
+	}{
+		{"failed status", quizapi.EmailReportStatus{Status: "failed", Message: "smtp server unavailable"}, nil, "email report failed: smtp server unavailable"},
+		{"timeout", quizapi.EmailReportStatus{Status: "processing"}, nil, "not sent within 50ms, last status: processing"},
+		{"poll error", quizapi.EmailReportStatus{}, errors.New("status code: 500"), "not sent within 50ms: status code: 500"},
 	}
 
-	mockApp.On("GetReport", session.ID).
-		After(time.Millisecond*100).
-		Return("This is a test report", nil)
-	mockApp.On("GetEmailReport", session.ID).
-		After(time.Millisecond*100).
-		Return("", nil)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			session := NewSession("test@example.com", "math", nil)
+			session.SetSession("1234")
+
+			app := NewTestApp()
+			app.Config.EmailStatusInterval = 10 * time.Millisecond
+			app.Config.EmailStatusTimeout = 50 * time.Millisecond
+
+			mockApp, ok := app.QuizAPI.(*mock.MockQuizAPI)
+			require.True(t, ok, "Error while getting the mock quizapi")
+			mockApp.On("GetEmailReport", "1234").Return("Email report request accepted", nil)
+			mockApp.On("GetEmailReportStatus", "1234").Return(tt.status, tt.err)
+
+			go func() {
+				<-app.Errors
+			}()
+			_, err := app.callGetEmail(session)
+
+			require.Error(t, err, "Expected the email not to be sent")
+			assert.Contains(t, err.Error(), tt.message, "Expected the reason in the error")
+			assert.Equal(t, quizapi.EndpointEmailStatus, session.FailedStep, "Expected the email status step to fail")
+			assert.Equal(t, STATUS_FAILED, session.Status, "Expected the session to fail")
+			assert.Zero(t, session.EmailProcessingTime, "Expected no processing time when the email was not sent")
+		})
+	}
+}
 
-	app.callReportAndEmailAPIs(session)
+func Test_app_simulator_VerifyScore(t *testing.T) {
+	app := NewTestApp()
+	app.Config.AnswerKey = answerkey.Key{"q1": "4", "q2": "Paris"}
+	session := NewSession("test@example.com", "math", nil)
+	session.SetSession("1234")
+	session.SetAnswers([]quizapi.Answer{{QuestionID: "q1", Answer: "4"}, {QuestionID: "q2", Answer: "London"}})
 
-	require.NotEmpty(t, session.Report, "Expected session to have a non-nil report after calling callReportAndEmailAPIs")
-	require.NotEmpty(t, session.APIsTimeTaken.ReportAPI, "Expected session to have a non-nil ReportAPI time after calling callReportAndEmailAPIs")
-	require.NotEmpty(t, session.APIsTimeTaken.EmailAPI, "Expected session to have a non-nil EmailAPI time after calling callReportAndEmailAPIs")
+	session.SetScore(1)
+	require.NoError(t, app.verifyScore(session), "Expected the score of the answer key")
+
+	session.SetAnswers([]quizapi.Answer{{QuestionID: "q1", Answer: "4"}, {QuestionID: "q9", Answer: "x"}})
+	session.SetScore(2)
+	require.NoError(t, app.verifyScore(session), "Expected the score not to be verified for questions missing from the key")
+	assert.NoError(t, session.Error, "Expected the session not to fail")
 }
 
-func Test_app_simulator_callReportAndEmailAPIs_WhenNilSession(t *testing.T) {
+func Test_app_simulator_VerifyScore_WhenWrongScore(t *testing.T) {
 	app := NewTestApp()
-	defer func() {
-		if r := recover(); r == nil {
-			t.Errorf("Expected callReportAndEmailAPIs to panic when passing nil session")
-		}
+	app.Config.AnswerKey = answerkey.Key{"q1": "4", "q2": "Paris"}
+	session := NewSession("test@example.com", "math", nil)
+	session.SetSession("1234")
+	session.SetAnswers([]quizapi.Answer{{QuestionID: "q1", Answer: "4"}, {QuestionID: "q2", Answer: "Paris"}})
+	session.SetScore(1)
+
+	go func() {
+		<-app.Errors
 	}()
-	app.callReportAndEmailAPIs(nil)
+	err := app.verifyScore(session)
+
+	require.Error(t, err, "Expected the wrong score to fail the session")
+	assert.Equal(t, "score 1, expected 2 from the answer key", err.Error(), "Expected the score and the expected score")
+	assert.Equal(t, STEP_VERIFY_SCORE, session.FailedStep, "Expected the verify score step to fail")
+	assert.Equal(t, STATUS_FAILED, session.Status, "Expected the session to fail")
+}
+
+func Test_app_simulator_CallGetEmail_WhenNilSession(t *testing.T) {
+	app := NewTestApp()
+	_, err := app.callGetEmail(nil)
+	require.Error(t, err, "Expected callGetEmail to return error when passing nil session value")
 }
 
 func Test_app_simulator_SimulateUser_WhenSuccess(t *testing.T) {
@@ -520,6 +668,46 @@ func Test_app_simulator_SimulateUser_WhenSuccess(t *testing.T) {
 	mockApp.AssertExpectations(t)
 }
 
+func Test_app_simulator_SimulateUser_WhenEmailStatusFailed(t *testing.T) {
+	email := "test@example.com"
+	topic := "math"
+
+	app := NewTestApp()
+	app.Config.EmailStatusInterval = 10 * time.Millisecond
+	app.Config.EmailStatusTimeout = time.Second
+
+	mockApp, ok := app.QuizAPI.(*mock.MockQuizAPI)
+	require.True(t, ok, "Error while getting the mock quizapi")
+	mockApp.On("CreateSession", email, topic).Return("12345", nil)
+	mockApp.On("StartQuiz", "12345", topic).Return([]quizapi.Question{{ID: "q1", Options: []string{"3"}}}, nil)
+	mockApp.On("SubmitQuiz", "12345", []quizapi.Answer{{QuestionID: "q1", Answer: "3"}}).Return(10, nil)
+	mockApp.On("GetReport", "12345").Return("This is a test report", nil)
+	mockApp.On("GetEmailReport", "12345").Return("Email report request accepted", nil)
+	mockApp.On("GetEmailReportStatus", "12345").Return(quizapi.EmailReportStatus{Status: "failed", Message: "smtp server unavailable"}, nil)
+
+	app.Wait.Add(1)
+	go app.SimulateUser(email, topic)
+
+	select {
+	case err := <-app.Errors:
+		sessionErr, ok := err.(*SessionError)
+		require.True(t, ok, "Expected a session error")
+		assert.Equal(t, STATUS_FAILED, sessionErr.Session.Status, "Expected the session to fail")
+		assert.Equal(t, quizapi.EndpointEmailStatus, sessionErr.Session.FailedStep, "Expected the email status step to fail")
+	case <-app.Results:
+		t.Fatal("Expected the failed session to be sent to the errors channel")
+	case <-time.After(time.Second):
+		t.Fatal("Expected the failed session to be reported")
+	}
+	app.Wait.Wait()
+
+	select {
+	case result := <-app.Results:
+		t.Errorf("Expected the failed session not to be reported again as %s", result.Status)
+	default:
+	}
+}
+
 func Test_app_simulator_StartSimulation(t *testing.T) {
 	app := NewTestApp() // default 10 users
 
@@ -595,3 +783,53 @@ func Test_app_simulator_StartSimulation(t *testing.T) {
 	count := <-done
 	require.Equalf(t, app.Config.NumUsers, count, "Expected to get %d results, but got %d", app.Config.NumUsers, done)
 }
+
+func Test_app_simulator_StartSimulation_WhenAborted(t *testing.T) {
+	app := NewTestApp() // default 10 users, all started at once
+	app.Config.EmailStatusInterval = 10 * time.Millisecond
+	app.Config.EmailStatusTimeout = time.Minute
+	rules, err := abort.Parse("connection_refused>1")
+	require.NoError(t, err, "Expected valid abort rules")
+	app.Abort = abort.NewMonitor(rules)
+
+	mockApp, ok := app.QuizAPI.(*mock.MockQuizAPI)
+	require.True(t, ok, "Error while getting the mock quizapi")
+	for i := range app.Config.NumUsers {
+		topic := TOPICS[i%len(TOPICS)]
+		mockApp.On("CreateSession", EMAILS[i%len(EMAILS)], topic).Return("12345", nil)
+		mockApp.On("StartQuiz", "12345", topic).Return([]quizapi.Question{{ID: "q1", Options: []string{"3"}}}, nil)
+	}
+	mockApp.On("SubmitQuiz", "12345", []quizapi.Answer{{QuestionID: "q1", Answer: "3"}}).Return(10, nil)
+	mockApp.On("GetReport", "12345").Return("This is a test report", nil)
+	mockApp.On("GetEmailReport", "12345").Return("Email report request accepted", nil)
+	// the email is never sent, the sessions poll until the timeout unless the run is aborted
+	mockApp.On("GetEmailReportStatus", "12345").Return(quizapi.EmailReportStatus{Status: "processing"}, nil)
+
+	// ensure no session is reported as failed
+	close(app.Errors)
+	defer func() {
+		r := recover()
+		require.Nilf(t, r, "Expected no panic, but got: %v", r)
+	}()
+
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+		for range 2 {
+			app.Abort.ObserveRequest(syscall.ECONNREFUSED)
+		}
+	}()
+
+	start := time.Now()
+	app.StartSimulation()
+	app.Wait.Wait()
+	close(app.Results)
+
+	assert.Less(t, time.Since(start), 2*time.Second, "Expected the sessions in progress to stop once the run is aborted")
+	count := 0
+	for result := range app.Results {
+		count++
+		assert.Equal(t, STATUS_ABANDONED, result.Status, "Expected the sessions in progress to be abandoned")
+		assert.Equal(t, "processing", result.EmailStatus, "Expected the session stopped while polling the email status")
+	}
+	assert.Equal(t, app.Config.NumUsers, count, "Expected every session in the results")
+}
@@ -0,0 +1,36 @@
+package app
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/go-squad-5/quiz-load-test/internal/junit"
+	"github.com/go-squad-5/quiz-load-test/internal/metrics"
+	"github.com/go-squad-5/quiz-load-test/internal/thresholds"
+)
+
+// CheckThresholds evaluates the configured thresholds against the run and logs every check
+func (app *App) CheckThresholds(summary *metrics.Summary) []thresholds.Check {
+	checks := thresholds.Evaluate(app.Config.Thresholds, summary)
+	// the checks are results of the run, printed even when quiet
+	for _, check := range checks {
+		app.ResultLogger.Println("Threshold", check.Message())
+	}
+	return checks
+}
+
+// WriteJUnitReport writes the checks, and the failed sessions if enabled, to the configured junit file
+func (app *App) WriteJUnitReport(summary *metrics.Summary, checks []thresholds.Check) error {
+	if err := os.MkdirAll(filepath.Dir(app.Config.JUnitFile), 0755); err != nil {
+		return fmt.Errorf("failed to create junit report directory: %w", err)
+	}
+
+	file, err := os.Create(app.Config.JUnitFile)
+	if err != nil {
+		return fmt.Errorf("failed to create junit report file: %w", err)
+	}
+	defer file.Close()
+
+	return junit.Write(file, summary, checks, app.Config.JUnitSessions)
+}
@@ -0,0 +1,49 @@
+package app
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-squad-5/quiz-load-test/internal/thresholds"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_app_thresholds_CheckThresholds(t *testing.T) {
+	app := NewTestApp()
+	parsed, err := thresholds.Parse("failed_sessions==0,session_failure_rate<50%")
+	require.NoError(t, err, "Expected valid thresholds")
+	app.Config.Thresholds = parsed
+	app.SessionStats.Add(getSessionResult(&Session{Topic: "go", Status: STATUS_COMPLETED}))
+	app.SessionStats.Add(getSessionResult(&Session{Topic: "go", Status: STATUS_FAILED, Error: assert.AnError}))
+
+	checks := app.CheckThresholds(app.Summary())
+	require.Len(t, checks, 2, "Expected a check for every threshold")
+	assert.False(t, checks[0].Passed, "Expected the failed session to fail the check")
+	assert.False(t, checks[1].Passed, "Expected a 50% failure rate to fail the check")
+}
+
+func Test_app_thresholds_WriteJUnitReport(t *testing.T) {
+	dir := "./test"
+	defer func() {
+		if err := os.RemoveAll(dir); err != nil && !os.IsNotExist(err) {
+			t.Fatalf("Error cleaning up the test files: %s", dir)
+		}
+	}()
+
+	app := NewTestApp()
+	app.Config.JUnitFile = filepath.Join(dir, "ci", "junit.xml")
+	app.Config.JUnitSessions = true
+	app.SessionStats.Add(getSessionResult(&Session{ID: "ssid", Topic: "go", Status: STATUS_FAILED, FailedStep: "start_quiz", Error: assert.AnError}))
+	app.StartedAt = time.Now().Add(-time.Second)
+	app.FinishedAt = time.Now()
+
+	err := app.WriteJUnitReport(app.Summary(), nil)
+	require.NoError(t, err, "Expected the junit report to be written")
+
+	content, err := os.ReadFile(app.Config.JUnitFile)
+	require.NoError(t, err, "Expected to read the junit report")
+	assert.Contains(t, string(content), `<testsuite name="sessions.start_quiz"`, "Expected the failed session grouped by step")
+}
@@ -1,7 +1,9 @@
 package app
 
 import (
+	"math/rand"
 	"os"
+	"sync"
 	"time"
 )
 
@@ -65,10 +67,10 @@ func getTimeDiff(t1, t2 time.Time) int64 {
 	return int64(t2.Sub(t1).Milliseconds())
 }
 
-// creates a given directory if it doesn't exist
+// creates a given directory and its parents if they don't exist
 func mustInitDir(dirPath string) {
 	if _, err := os.Stat(dirPath); err != nil && os.IsNotExist(err) {
-		err := os.Mkdir(dirPath, 0755)
+		err := os.MkdirAll(dirPath, 0755)
 		if err != nil {
 			panic("Failed to create directory: " + err.Error())
 		}
@@ -76,3 +78,25 @@ func mustInitDir(dirPath string) {
 		panic("Failed to check dir stat")
 	}
 }
+
+// lockedRand is a seeded random source safe for concurrent use
+type lockedRand struct {
+	mu   sync.Mutex
+	rand *rand.Rand
+}
+
+func newLockedRand(seed int64) *lockedRand {
+	return &lockedRand{rand: rand.New(rand.NewSource(seed))}
+}
+
+func (r *lockedRand) Intn(n int) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.rand.Intn(n)
+}
+
+func (r *lockedRand) Float64() float64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.rand.Float64()
+}
@@ -0,0 +1,100 @@
+package behaviour
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/go-squad-5/quiz-load-test/internal/compare"
+)
+
+// Profile is the path a virtual user takes through the quiz flow
+type Profile string
+
+const (
+	// complete the quiz and request the report and the email report
+	Complete Profile = "complete"
+	// start the quiz and leave without submitting it
+	Abandon Profile = "abandon"
+	// complete the quiz and request the report, but not the email report
+	SkipEmail Profile = "skip_email"
+	// complete the quiz, submitting it again when the submit fails
+	RetrySubmit Profile = "retry_submit"
+)
+
+// Profiles lists the profiles in the order they are described in the README
+var Profiles []Profile = []Profile{Complete, Abandon, SkipEmail, RetrySubmit}
+
+// Weight is the relative weight of a profile among the users
+type Weight struct {
+	Profile Profile
+	Weight  float64
+}
+
+// Mix is the weight of each profile, the users are spread across the profiles in proportion to their weights
+type Mix []Weight
+
+func (m Mix) Empty() bool {
+	return len(m) == 0
+}
+
+func (m Mix) String() string {
+	weights := make([]string, len(m))
+	for i, weight := range m {
+		weights[i] = string(weight.Profile) + "=" + strconv.FormatFloat(weight.Weight, 'f', -1, 64)
+	}
+	return strings.Join(weights, ",")
+}
+
+// Pick returns the profile of a user from a random value in [0, 1), every user completes the quiz when the mix is empty
+func (m Mix) Pick(value float64) Profile {
+	total := 0.0
+	for _, weight := range m {
+		total += weight.Weight
+	}
+	cumulative := 0.0
+	for _, weight := range m {
+		cumulative += weight.Weight
+		if value*total < cumulative {
+			return weight.Profile
+		}
+	}
+	if len(m) > 0 {
+		// value*total may round up to the total
+		return m[len(m)-1].Profile
+	}
+	return Complete
+}
+
+// ParseMix parses comma separated weights by profile, as numbers or percentages,
+// e.g. "complete=70%,abandon=15%,skip_email=10%,retry_submit=5%" or "complete=14,abandon=3,skip_email=2,retry_submit=1"
+func ParseMix(value string) (Mix, error) {
+	mix := Mix{}
+	for _, part := range strings.Split(value, ",") {
+		if strings.TrimSpace(part) == "" {
+			continue
+		}
+		name, weightValue, found := strings.Cut(part, "=")
+		if !found {
+			return nil, fmt.Errorf("invalid profile weight %q, expected profile=weight", strings.TrimSpace(part))
+		}
+		profile := Profile(strings.TrimSpace(name))
+		if !slices.Contains(Profiles, profile) {
+			return nil, fmt.Errorf("unknown profile %q", profile)
+		}
+		if slices.ContainsFunc(mix, func(weight Weight) bool { return weight.Profile == profile }) {
+			return nil, fmt.Errorf("duplicate profile %q", profile)
+		}
+		// a percentage is parsed as a fraction, only the weights relative to each other matter
+		weight, err := compare.ParseRatio(strings.TrimSpace(weightValue))
+		if err != nil {
+			return nil, fmt.Errorf("invalid weight of profile %q: %w", profile, err)
+		}
+		mix = append(mix, Weight{Profile: profile, Weight: weight})
+	}
+	if len(mix) > 0 && !slices.ContainsFunc(mix, func(weight Weight) bool { return weight.Weight > 0 }) {
+		return nil, fmt.Errorf("every profile weighs 0, expected a profile with a positive weight")
+	}
+	return mix, nil
+}
@@ -0,0 +1,52 @@
+package behaviour
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_behaviour_ParseMix(t *testing.T) {
+	mix, err := ParseMix("complete=70%, abandon=15%,skip_email=10%,retry_submit=5%")
+	require.NoError(t, err, "Expected a valid mix")
+	assert.Equal(t, Mix{
+		{Profile: Complete, Weight: 0.7},
+		{Profile: Abandon, Weight: 0.15},
+		{Profile: SkipEmail, Weight: 0.1},
+		{Profile: RetrySubmit, Weight: 0.05},
+	}, mix, "Expected the weights in the given order")
+	assert.Equal(t, "complete=0.7,abandon=0.15,skip_email=0.1,retry_submit=0.05", mix.String(), "Expected the mix as fractions")
+
+	mix, err = ParseMix("complete=3,abandon=1,skip_email=0")
+	require.NoError(t, err, "Expected weights as numbers")
+	assert.Equal(t, Mix{{Profile: Complete, Weight: 3}, {Profile: Abandon, Weight: 1}, {Profile: SkipEmail, Weight: 0}}, mix, "Expected the weights as numbers")
+
+	mix, err = ParseMix("")
+	require.NoError(t, err, "Expected no error without profiles")
+	assert.True(t, mix.Empty(), "Expected no profiles")
+}
+
+func Test_behaviour_ParseMix_WhenInvalid(t *testing.T) {
+	for _, value := range []string{
+		"complete",
+		"complete=mostly",
+		"complete=-1",
+		"rage_quit=5%",
+		"complete=50%,complete=50%",
+		"complete=0,abandon=0",
+	} {
+		_, err := ParseMix(value)
+		assert.Error(t, err, "Expected an error for %q", value)
+	}
+}
+
+func Test_behaviour_Mix_Pick(t *testing.T) {
+	mix := Mix{{Profile: Complete, Weight: 3}, {Profile: Abandon, Weight: 0}, {Profile: SkipEmail, Weight: 1}}
+
+	assert.Equal(t, Complete, mix.Pick(0), "Expected the first profile for the lowest values")
+	assert.Equal(t, Complete, mix.Pick(0.7), "Expected the first profile within its weight")
+	assert.Equal(t, SkipEmail, mix.Pick(0.8), "Expected the profile after the weight of the first, skipping the profiles weighing 0")
+	assert.Equal(t, SkipEmail, mix.Pick(0.9999999999999999), "Expected the last profile for the highest values")
+	assert.Equal(t, Complete, Mix{}.Pick(0.5), "Expected every user to complete the quiz without profiles")
+}
@@ -0,0 +1,164 @@
+package capacity
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/go-squad-5/quiz-load-test/internal/metrics"
+	"github.com/go-squad-5/quiz-load-test/internal/thresholds"
+)
+
+const (
+	// StrategyStep increases the load by a fixed step until the slo is violated
+	StrategyStep = "step"
+	// StrategyBinary doubles the load until the slo is violated, then bisects between the last passing and the failing load
+	StrategyBinary = "binary"
+)
+
+// Trial is the outcome of a simulation at a given load
+type Trial struct {
+	Load    int
+	Passed  bool
+	Checks  []thresholds.Check
+	Summary *metrics.Summary
+}
+
+// Runner runs a simulation at the given load and evaluates the slo against it
+type Runner func(load int) (Trial, error)
+
+type Search struct {
+	Strategy string
+	// first load to try
+	Start int
+	// load increase of the step strategy
+	Step int
+	// highest load to try
+	Max int
+	// the binary search stops when the passing and the failing loads are this close
+	Precision int
+}
+
+func (s Search) Validate() error {
+	if s.Strategy != StrategyStep && s.Strategy != StrategyBinary {
+		return fmt.Errorf("invalid strategy %q, expected %s or %s", s.Strategy, StrategyStep, StrategyBinary)
+	}
+	if s.Start <= 0 || s.Max < s.Start {
+		return fmt.Errorf("invalid load range %d to %d", s.Start, s.Max)
+	}
+	if s.Strategy == StrategyStep && s.Step <= 0 {
+		return fmt.Errorf("invalid step %d, must be positive", s.Step)
+	}
+	if s.Strategy == StrategyBinary && s.Precision <= 0 {
+		return fmt.Errorf("invalid precision %d, must be positive", s.Precision)
+	}
+	return nil
+}
+
+type Result struct {
+	Trials []Trial
+	// highest load which passed the slo, 0 when none did
+	MaxSustainable int
+	// lowest load which violated the slo, 0 when none did
+	Limit int
+}
+
+// Run tries increasing loads until the slo is violated or the max load is reached
+func (s Search) Run(runner Runner) (*Result, error) {
+	if err := s.Validate(); err != nil {
+		return nil, err
+	}
+
+	result := &Result{Trials: []Trial{}}
+	try := func(load int) (bool, error) {
+		trial, err := runner(load)
+		if err != nil {
+			return false, fmt.Errorf("failed to run the simulation with load %d: %w", load, err)
+		}
+		trial.Load = load
+		result.Trials = append(result.Trials, trial)
+		if trial.Passed {
+			result.MaxSustainable = max(result.MaxSustainable, load)
+		} else if result.Limit == 0 || load < result.Limit {
+			result.Limit = load
+		}
+		return trial.Passed, nil
+	}
+
+	if s.Strategy == StrategyStep {
+		// the last step is cut short to try the max load, when the steps don't land on it
+		for load := s.Start; ; load = min(load+s.Step, s.Max) {
+			passed, err := try(load)
+			if err != nil || !passed || load == s.Max {
+				return result, err
+			}
+		}
+	}
+
+	// grow exponentially to find a failing load
+	load := s.Start
+	for {
+		passed, err := try(load)
+		if err != nil {
+			return result, err
+		}
+		if !passed {
+			break
+		}
+		if load == s.Max {
+			return result, nil
+		}
+		load = min(load*2, s.Max)
+	}
+
+	// bisect between the highest passing and the lowest failing loads
+	for result.Limit-result.MaxSustainable > s.Precision {
+		mid := (result.MaxSustainable + result.Limit) / 2
+		if mid <= 0 {
+			break
+		}
+		if _, err := try(mid); err != nil {
+			return result, err
+		}
+	}
+	return result, nil
+}
+
+// Write prints every trial of the search and the max sustainable load
+func (r *Result) Write(w io.Writer, unit string) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "LOAD (%s)\tSESSIONS\tFAILED\tRPS\tERROR RATE\tSLO\n", unit)
+	for _, trial := range r.Trials {
+		sessions, failed, rps, errorRate := 0, 0, 0.0, 0.0
+		if trial.Summary != nil {
+			sessions, failed = trial.Summary.Sessions.Total, trial.Summary.Sessions.Failed
+			rps, errorRate = trial.Summary.RPS, trial.Summary.ErrorRate
+		}
+		slo := "passed"
+		if !trial.Passed {
+			failures := []string{}
+			for _, check := range trial.Checks {
+				if !check.Passed {
+					failures = append(failures, check.Message())
+				}
+			}
+			slo = "violated: " + strings.Join(failures, "; ")
+		}
+		fmt.Fprintf(tw, "%d\t%d\t%d\t%.2f/s\t%.2f%%\t%s\n", trial.Load, sessions, failed, rps, errorRate*100, slo)
+	}
+	if err := tw.Flush(); err != nil {
+		return fmt.Errorf("failed to write capacity search: %w", err)
+	}
+
+	var err error
+	switch {
+	case r.MaxSustainable == 0:
+		_, err = fmt.Fprintf(w, "\nThe slo is violated at the lowest load tried, %d %s\n", r.Limit, unit)
+	case r.Limit == 0:
+		_, err = fmt.Fprintf(w, "\nThe slo is met up to the max load tried, %d %s\n", r.MaxSustainable, unit)
+	default:
+		_, err = fmt.Fprintf(w, "\nMax sustainable load: %d %s, the slo is violated at %d %s\n", r.MaxSustainable, unit, r.Limit, unit)
+	}
+	return err
+}
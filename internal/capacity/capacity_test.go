@@ -0,0 +1,109 @@
+package capacity
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/go-squad-5/quiz-load-test/internal/metrics"
+	"github.com/go-squad-5/quiz-load-test/internal/thresholds"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// runnerWithLimit passes the slo for loads below the limit, and records the loads tried
+func runnerWithLimit(limit int, loads *[]int) Runner {
+	return func(load int) (Trial, error) {
+		*loads = append(*loads, load)
+		return Trial{Passed: load < limit, Summary: &metrics.Summary{}}, nil
+	}
+}
+
+func Test_capacity_Search_Step(t *testing.T) {
+	loads := []int{}
+	search := Search{Strategy: StrategyStep, Start: 10, Step: 10, Max: 100}
+
+	result, err := search.Run(runnerWithLimit(45, &loads))
+	require.NoError(t, err, "Expected the search to run")
+	assert.Equal(t, []int{10, 20, 30, 40, 50}, loads, "Expected the load to increase until the slo is violated")
+	assert.Equal(t, 40, result.MaxSustainable, "Expected the highest passing load")
+	assert.Equal(t, 50, result.Limit, "Expected the failing load")
+	assert.Len(t, result.Trials, 5, "Expected every trial")
+}
+
+func Test_capacity_Search_Step_WhenMaxReached(t *testing.T) {
+	loads := []int{}
+	search := Search{Strategy: StrategyStep, Start: 10, Step: 20, Max: 50}
+
+	result, err := search.Run(runnerWithLimit(1000, &loads))
+	require.NoError(t, err, "Expected the search to run")
+	assert.Equal(t, []int{10, 30, 50}, loads, "Expected the search to stop at the max load")
+	assert.Equal(t, 50, result.MaxSustainable, "Expected the max load to pass")
+	assert.Equal(t, 0, result.Limit, "Expected no failing load")
+}
+
+func Test_capacity_Search_Step_WhenStepsMissMax(t *testing.T) {
+	loads := []int{}
+	search := Search{Strategy: StrategyStep, Start: 10, Step: 30, Max: 50}
+
+	result, err := search.Run(runnerWithLimit(1000, &loads))
+	require.NoError(t, err, "Expected the search to run")
+	assert.Equal(t, []int{10, 40, 50}, loads, "Expected the max load tried after the last step")
+	assert.Equal(t, 50, result.MaxSustainable, "Expected the max load to pass")
+}
+
+func Test_capacity_Search_Binary(t *testing.T) {
+	loads := []int{}
+	search := Search{Strategy: StrategyBinary, Start: 10, Max: 1000, Precision: 5}
+
+	result, err := search.Run(runnerWithLimit(100, &loads))
+	require.NoError(t, err, "Expected the search to run")
+	assert.Equal(t, []int{10, 20, 40, 80, 160, 120, 100, 90, 95}, loads, "Expected the load to double then bisect")
+	assert.Equal(t, 95, result.MaxSustainable, "Expected the highest passing load")
+	assert.Equal(t, 100, result.Limit, "Expected the lowest failing load")
+}
+
+func Test_capacity_Search_Binary_WhenMaxReached(t *testing.T) {
+	loads := []int{}
+	search := Search{Strategy: StrategyBinary, Start: 10, Max: 50, Precision: 5}
+
+	result, err := search.Run(runnerWithLimit(1000, &loads))
+	require.NoError(t, err, "Expected the search to run")
+	assert.Equal(t, []int{10, 20, 40, 50}, loads, "Expected the load to be capped at the max load")
+	assert.Equal(t, 50, result.MaxSustainable, "Expected the max load to pass")
+}
+
+func Test_capacity_Search_WhenRunnerFails(t *testing.T) {
+	search := Search{Strategy: StrategyStep, Start: 10, Step: 10, Max: 100}
+	_, err := search.Run(func(load int) (Trial, error) {
+		return Trial{}, errors.New("server unreachable")
+	})
+	assert.ErrorContains(t, err, "server unreachable", "Expected the runner error")
+}
+
+func Test_capacity_Search_Validate(t *testing.T) {
+	assert.Error(t, Search{Strategy: "linear", Start: 1, Step: 1, Max: 2}.Validate(), "Expected an unknown strategy to be invalid")
+	assert.Error(t, Search{Strategy: StrategyStep, Start: 0, Step: 1, Max: 2}.Validate(), "Expected a zero start to be invalid")
+	assert.Error(t, Search{Strategy: StrategyStep, Start: 5, Step: 1, Max: 2}.Validate(), "Expected a max below the start to be invalid")
+	assert.Error(t, Search{Strategy: StrategyStep, Start: 1, Max: 2}.Validate(), "Expected a zero step to be invalid")
+	assert.Error(t, Search{Strategy: StrategyBinary, Start: 1, Max: 2}.Validate(), "Expected a zero precision to be invalid")
+	assert.NoError(t, Search{Strategy: StrategyBinary, Start: 1, Max: 2, Precision: 1}.Validate(), "Expected a valid search")
+}
+
+func Test_capacity_Result_Write(t *testing.T) {
+	threshold, err := thresholds.ParseThreshold("p95<500ms")
+	require.NoError(t, err, "Expected a valid threshold")
+	result := &Result{
+		Trials: []Trial{
+			{Load: 10, Passed: true, Summary: &metrics.Summary{}},
+			{Load: 20, Checks: []thresholds.Check{{Threshold: threshold, Actual: 700}}, Summary: &metrics.Summary{}},
+		},
+		MaxSustainable: 10,
+		Limit:          20,
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, result.Write(&buf, "users"), "Expected the result to be written")
+	assert.Contains(t, buf.String(), "violated: p95<500ms failed, actual 700.0ms", "Expected the violated slo of the trial")
+	assert.Contains(t, buf.String(), "Max sustainable load: 10 users", "Expected the max sustainable load")
+}
@@ -0,0 +1,126 @@
+package capture
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/go-squad-5/quiz-load-test/internal/quizapi"
+	"github.com/go-squad-5/quiz-load-test/internal/sampler"
+)
+
+// Mode tells which sessions have their requests and responses saved
+type Mode string
+
+const (
+	Off      Mode = "off"
+	Failures Mode = "failures"
+	// the failed sessions and a sample of the other sessions
+	Sample Mode = "sample"
+	All    Mode = "all"
+)
+
+func ParseMode(value string) (Mode, error) {
+	switch mode := Mode(value); mode {
+	case Off, Failures, Sample, All:
+		return mode, nil
+	}
+	return "", fmt.Errorf("unknown capture mode %q, expected off, failures, sample or all", value)
+}
+
+// Enabled reports whether any session is captured, an unset mode is off
+func (m Mode) Enabled() bool {
+	return m != "" && m != Off
+}
+
+// Capture is the requests and responses of a session, saved to reproduce the session
+type Capture struct {
+	SessionID  string             `json:"session_id"`
+	Email      string             `json:"email"`
+	Topic      string             `json:"topic"`
+	Status     string             `json:"status"`
+	FailedStep string             `json:"failed_step,omitempty"`
+	Scenario   string             `json:"scenario,omitempty"`
+	Error      string             `json:"error,omitempty"`
+	Exchanges  []quizapi.Exchange `json:"exchanges"`
+}
+
+// Recorder keeps the requests and responses of the sessions in progress, until their session is over
+type Recorder struct {
+	mu        sync.Mutex
+	mode      Mode
+	sample    *sampler.Sampler
+	exchanges map[string][]quizapi.Exchange
+}
+
+// NewRecorder returns a recorder for the mode, sampleRate is the fraction of the other sessions saved by the sample mode
+func NewRecorder(mode Mode, sampleRate float64) *Recorder {
+	return &Recorder{
+		mode:      mode,
+		sample:    sampler.New(sampleRate),
+		exchanges: map[string][]quizapi.Exchange{},
+	}
+}
+
+// Add records the exchange of the session with the key, in the order the requests were sent
+func (r *Recorder) Add(key string, exchange quizapi.Exchange) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.exchanges[key] = append(r.exchanges[key], exchange)
+}
+
+// Take returns and forgets the exchanges of the session with the key
+func (r *Recorder) Take(key string) []quizapi.Exchange {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	exchanges := r.exchanges[key]
+	delete(r.exchanges, key)
+	return exchanges
+}
+
+// TakeFirst returns and forgets the first exchange recorded with the key, for the keys shared by several sessions
+func (r *Recorder) TakeFirst(key string) []quizapi.Exchange {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	exchanges := r.exchanges[key]
+	if len(exchanges) == 0 {
+		return nil
+	}
+	if len(exchanges) == 1 {
+		delete(r.exchanges, key)
+	} else {
+		r.exchanges[key] = exchanges[1:]
+	}
+	return exchanges[:1]
+}
+
+// Keep reports whether the requests of a finished session are saved
+func (r *Recorder) Keep(failed bool) bool {
+	switch r.mode {
+	case All:
+		return true
+	case Failures:
+		return failed
+	case Sample:
+		return failed || r.sample.Next()
+	}
+	return false
+}
+
+// Save writes the capture as json to the file name in the directory, and returns the path of the file
+func Save(dir, name string, capture Capture) (string, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create the captures directory: %w", err)
+	}
+	data, err := json.MarshalIndent(capture, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("failed to encode the capture: %w", err)
+	}
+	filePath := filepath.Join(dir, name)
+	if err := os.WriteFile(filePath, data, 0644); err != nil {
+		return "", fmt.Errorf("failed to write the capture: %w", err)
+	}
+	return filePath, nil
+}
@@ -0,0 +1,84 @@
+package capture
+
+import (
+	"encoding/json"
+	"os"
+	"testing"
+
+	"github.com/go-squad-5/quiz-load-test/internal/quizapi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_capture_ParseMode(t *testing.T) {
+	for _, value := range []string{"off", "failures", "sample", "all"} {
+		mode, err := ParseMode(value)
+		require.NoError(t, err, "Expected %s to be a capture mode", value)
+		assert.Equal(t, Mode(value), mode, "Expected the parsed mode")
+	}
+	_, err := ParseMode("errors")
+	assert.Error(t, err, "Expected an unknown mode to fail")
+
+	assert.False(t, Mode("").Enabled(), "Expected an unset mode to be off")
+	assert.False(t, Off.Enabled(), "Expected the off mode not to capture")
+	assert.True(t, Failures.Enabled(), "Expected the failures mode to capture")
+}
+
+func Test_capture_Recorder_Take(t *testing.T) {
+	r := NewRecorder(All, 0)
+	r.Add("s1", quizapi.Exchange{Endpoint: quizapi.EndpointCreateSession})
+	r.Add("s1", quizapi.Exchange{Endpoint: quizapi.EndpointStartQuiz})
+	r.Add("s2", quizapi.Exchange{Endpoint: quizapi.EndpointCreateSession})
+
+	exchanges := r.Take("s1")
+	require.Len(t, exchanges, 2, "Expected the exchanges of the session")
+	assert.Equal(t, quizapi.EndpointStartQuiz, exchanges[1].Endpoint, "Expected the exchanges in the order they were added")
+	assert.Empty(t, r.Take("s1"), "Expected the exchanges to be forgotten once taken")
+	assert.Len(t, r.Take("s2"), 1, "Expected the exchanges of the other session")
+}
+
+func Test_capture_Recorder_TakeFirst(t *testing.T) {
+	r := NewRecorder(All, 0)
+	r.Add("user", quizapi.Exchange{URL: "first"})
+	r.Add("user", quizapi.Exchange{URL: "second"})
+
+	assert.Equal(t, []quizapi.Exchange{{URL: "first"}}, r.TakeFirst("user"), "Expected the first exchange")
+	assert.Equal(t, []quizapi.Exchange{{URL: "second"}}, r.TakeFirst("user"), "Expected the next exchange")
+	assert.Empty(t, r.TakeFirst("user"), "Expected no exchange once all are taken")
+}
+
+func Test_capture_Recorder_Keep(t *testing.T) {
+	assert.True(t, NewRecorder(All, 0).Keep(false), "Expected the all mode to keep every session")
+	assert.True(t, NewRecorder(Failures, 0).Keep(true), "Expected the failures mode to keep the failed sessions")
+	assert.False(t, NewRecorder(Failures, 0).Keep(false), "Expected the failures mode not to keep the other sessions")
+
+	r := NewRecorder(Sample, 0.25)
+	kept := 0
+	for range 100 {
+		if r.Keep(false) {
+			kept++
+		}
+	}
+	assert.Equal(t, 25, kept, "Expected the sample rate of the other sessions to be kept")
+	assert.True(t, r.Keep(true), "Expected the sample mode to keep the failed sessions")
+}
+
+func Test_capture_Save(t *testing.T) {
+	dir := t.TempDir() + "/captures"
+	capture := Capture{
+		SessionID: "s1",
+		Status:    "failed",
+		Error:     "status code: 500",
+		Exchanges: []quizapi.Exchange{{Endpoint: quizapi.EndpointSubmitQuiz, StatusCode: 500, ResponseBody: "boom"}},
+	}
+
+	filePath, err := Save(dir, "s1.json", capture)
+	require.NoError(t, err, "Expected the capture to be saved")
+	assert.Equal(t, dir+"/s1.json", filePath, "Expected the capture in the directory")
+
+	data, err := os.ReadFile(filePath)
+	require.NoError(t, err, "Expected the capture file to be written")
+	saved := Capture{}
+	require.NoError(t, json.Unmarshal(data, &saved), "Expected the capture as json")
+	assert.Equal(t, capture, saved, "Expected the saved capture")
+}
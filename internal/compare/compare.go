@@ -0,0 +1,284 @@
+package compare
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/go-squad-5/quiz-load-test/internal/metrics"
+)
+
+// Tolerances are the changes allowed between the baseline and the current run before a
+// statistically significant change is a regression
+type Tolerances struct {
+	// relative increase of the latency percentiles, e.g. 0.1 for 10%
+	Latency float64
+	// relative decrease of the requests per second
+	Throughput float64
+	// absolute increase of the error rate, e.g. 0.01 for one percentage point
+	ErrorRate float64
+	// significance level of the statistical tests
+	Alpha float64
+}
+
+var DefaultTolerances Tolerances = Tolerances{
+	Latency:    0.10,
+	Throughput: 0.10,
+	ErrorRate:  0.01,
+	Alpha:      0.05,
+}
+
+const allEndpoints = "all"
+
+// Diff is the change of a metric between the baseline and the current run
+type Diff struct {
+	Endpoint   string
+	Metric     string
+	Baseline   float64
+	Current    float64
+	Change     float64
+	PValue     float64
+	Regression bool
+	Improved   bool
+}
+
+type Result struct {
+	Diffs []Diff
+	// configuration values which differ between the runs, by environment variable name
+	ConfigChanges map[string][2]string
+}
+
+func (r *Result) HasRegression() bool {
+	for _, diff := range r.Diffs {
+		if diff.Regression {
+			return true
+		}
+	}
+	return false
+}
+
+// ReadSummary reads a run summary written as json
+func ReadSummary(filePath string) (*metrics.Summary, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open summary: %w", err)
+	}
+	defer file.Close()
+
+	summary := &metrics.Summary{}
+	if err := json.NewDecoder(file).Decode(summary); err != nil {
+		return nil, fmt.Errorf("failed to decode summary %s: %w", filePath, err)
+	}
+	return summary, nil
+}
+
+// Compare diffs the per-endpoint percentiles, throughput and error rates of two runs
+func Compare(baseline, current *metrics.Summary, tolerances Tolerances) *Result {
+	result := &Result{Diffs: []Diff{}, ConfigChanges: map[string][2]string{}}
+
+	result.Diffs = append(result.Diffs,
+		throughputDiff(allEndpoints, baseline.Requests, baseline.Seconds, current.Requests, current.Seconds, tolerances),
+		errorRateDiff(allEndpoints, baseline.Errors, baseline.Requests, current.Errors, current.Requests, tolerances),
+	)
+
+	for _, stats := range current.Endpoints {
+		base, ok := findEndpoint(baseline, stats.Endpoint)
+		if !ok || base.Count == 0 || stats.Count == 0 {
+			continue
+		}
+		baseHist := histogram(baseline, stats.Endpoint)
+		currentHist := histogram(current, stats.Endpoint)
+		// the mean is tested with the means, the percentiles with the requests above them, a tail regression
+		// may leave the mean unchanged
+		meanPValue := welchTTest(baseHist.Mean(), baseHist.StdDev(), baseHist.Count, currentHist.Mean(), currentHist.StdDev(), currentHist.Count)
+
+		for _, latency := range []struct {
+			metric            string
+			baseline, current float64
+			pValue            float64
+		}{
+			{"mean", base.Mean, stats.Mean, meanPValue},
+			{"p50", base.P50, stats.P50, quantileTest(baseHist, currentHist, 0.50)},
+			{"p95", base.P95, stats.P95, quantileTest(baseHist, currentHist, 0.95)},
+			{"p99", base.P99, stats.P99, quantileTest(baseHist, currentHist, 0.99)},
+		} {
+			result.Diffs = append(result.Diffs, latencyDiff(stats.Endpoint, latency.metric, latency.baseline, latency.current, latency.pValue, tolerances))
+		}
+		result.Diffs = append(result.Diffs,
+			throughputDiff(stats.Endpoint, base.Count, baseline.Seconds, stats.Count, current.Seconds, tolerances),
+			errorRateDiff(stats.Endpoint, base.Errors, base.Count, stats.Errors, stats.Count, tolerances),
+		)
+	}
+
+	for key, value := range current.Config {
+		if baseValue := baseline.Config[key]; baseValue != value {
+			result.ConfigChanges[key] = [2]string{baseValue, value}
+		}
+	}
+	for key, value := range baseline.Config {
+		if _, ok := current.Config[key]; !ok {
+			result.ConfigChanges[key] = [2]string{value, ""}
+		}
+	}
+	return result
+}
+
+func findEndpoint(summary *metrics.Summary, endpoint string) (metrics.EndpointStats, bool) {
+	for _, stats := range summary.Endpoints {
+		if stats.Endpoint == endpoint {
+			return stats, true
+		}
+	}
+	return metrics.EndpointStats{}, false
+}
+
+func histogram(summary *metrics.Summary, endpoint string) *metrics.Histogram {
+	if hist, ok := summary.Histograms[endpoint]; ok && hist != nil {
+		return hist
+	}
+	return metrics.NewHistogram()
+}
+
+func relativeChange(baseline, current float64) float64 {
+	if baseline == 0 {
+		return 0
+	}
+	return (current - baseline) / baseline
+}
+
+// latencyDiff is a regression when the latency increased beyond the tolerance and the change is significant
+func latencyDiff(endpoint, metric string, baseline, current, pValue float64, tolerances Tolerances) Diff {
+	change := relativeChange(baseline, current)
+	significant := pValue < tolerances.Alpha
+	return Diff{
+		Endpoint:   endpoint,
+		Metric:     metric,
+		Baseline:   baseline,
+		Current:    current,
+		Change:     change,
+		PValue:     pValue,
+		Regression: significant && change > tolerances.Latency,
+		Improved:   significant && change < -tolerances.Latency,
+	}
+}
+
+func throughputDiff(endpoint string, baseCount uint64, baseSeconds float64, count uint64, seconds float64, tolerances Tolerances) Diff {
+	baseline, current := 0.0, 0.0
+	if baseSeconds > 0 {
+		baseline = float64(baseCount) / baseSeconds
+	}
+	if seconds > 0 {
+		current = float64(count) / seconds
+	}
+	change := relativeChange(baseline, current)
+	pValue := poissonRateTest(baseCount, baseSeconds, count, seconds)
+	significant := pValue < tolerances.Alpha
+	return Diff{
+		Endpoint:   endpoint,
+		Metric:     "rps",
+		Baseline:   baseline,
+		Current:    current,
+		Change:     change,
+		PValue:     pValue,
+		Regression: significant && change < -tolerances.Throughput,
+		Improved:   significant && change > tolerances.Throughput,
+	}
+}
+
+// errorRateDiff compares the absolute change of the error rates
+func errorRateDiff(endpoint string, baseErrors, baseRequests, errors, requests uint64, tolerances Tolerances) Diff {
+	baseline, current := 0.0, 0.0
+	if baseRequests > 0 {
+		baseline = float64(baseErrors) / float64(baseRequests)
+	}
+	if requests > 0 {
+		current = float64(errors) / float64(requests)
+	}
+	change := current - baseline
+	pValue := twoProportionZTest(baseErrors, baseRequests, errors, requests)
+	significant := pValue < tolerances.Alpha
+	return Diff{
+		Endpoint:   endpoint,
+		Metric:     "error_rate",
+		Baseline:   baseline,
+		Current:    current,
+		Change:     change,
+		PValue:     pValue,
+		Regression: significant && change > tolerances.ErrorRate,
+		Improved:   significant && change < -tolerances.ErrorRate,
+	}
+}
+
+// Write prints the comparison as a table
+func (r *Result) Write(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "ENDPOINT\tMETRIC\tBASELINE\tCURRENT\tCHANGE\tP-VALUE\tRESULT")
+	for _, diff := range r.Diffs {
+		status := "ok"
+		if diff.Regression {
+			status = "REGRESSION"
+		} else if diff.Improved {
+			status = "improved"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%.4f\t%s\n",
+			diff.Endpoint, diff.Metric, formatValue(diff.Metric, diff.Baseline), formatValue(diff.Metric, diff.Current),
+			formatChange(diff), diff.PValue, status)
+	}
+	if err := tw.Flush(); err != nil {
+		return fmt.Errorf("failed to write comparison: %w", err)
+	}
+
+	if len(r.ConfigChanges) > 0 {
+		keys := make([]string, 0, len(r.ConfigChanges))
+		for key := range r.ConfigChanges {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		fmt.Fprintln(w, "\nConfiguration changed between the runs:")
+		for _, key := range keys {
+			fmt.Fprintf(w, "  %s: %q -> %q\n", key, r.ConfigChanges[key][0], r.ConfigChanges[key][1])
+		}
+	}
+
+	if r.HasRegression() {
+		_, err := fmt.Fprintln(w, "\nRegression detected")
+		return err
+	}
+	_, err := fmt.Fprintln(w, "\nNo regression detected")
+	return err
+}
+
+func formatValue(metric string, value float64) string {
+	switch metric {
+	case "error_rate":
+		return strconv.FormatFloat(value*100, 'f', 2, 64) + "%"
+	case "rps":
+		return strconv.FormatFloat(value, 'f', 2, 64) + "/s"
+	}
+	return strconv.FormatFloat(value, 'f', 1, 64) + "ms"
+}
+
+func formatChange(diff Diff) string {
+	if diff.Metric == "error_rate" {
+		return fmt.Sprintf("%+.2fpp", diff.Change*100)
+	}
+	return fmt.Sprintf("%+.1f%%", diff.Change*100)
+}
+
+// ParseRatio parses a fraction, e.g. "0.1", or a percentage, e.g. "10%"
+func ParseRatio(value string) (float64, error) {
+	percent := strings.HasSuffix(value, "%")
+	ratio, err := strconv.ParseFloat(strings.TrimSuffix(value, "%"), 64)
+	if err != nil || ratio < 0 {
+		return 0, fmt.Errorf("invalid ratio %q, expected a fraction or a percentage", value)
+	}
+	if percent {
+		ratio /= 100
+	}
+	return ratio, nil
+}
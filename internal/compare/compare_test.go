@@ -0,0 +1,168 @@
+package compare
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-squad-5/quiz-load-test/internal/metrics"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testSummary builds a run of 1000 start_quiz requests in 10 seconds, around the given latency
+func testSummary(latency float64, errors uint64) *metrics.Summary {
+	hist := metrics.NewHistogram()
+	for i := 0; i < 1000; i++ {
+		hist.Record(latency + float64(i%21) - 10)
+	}
+	summary := &metrics.Summary{
+		Seconds:    10,
+		Config:     map[string]string{"NUM_USERS": "100"},
+		Requests:   hist.Count,
+		Errors:     errors,
+		ErrorRate:  float64(errors) / float64(hist.Count),
+		Endpoints:  []metrics.EndpointStats{metrics.NewEndpointStats("start_quiz", hist, errors, 10)},
+		Histograms: map[string]*metrics.Histogram{"start_quiz": hist},
+	}
+	return summary
+}
+
+func findDiff(t *testing.T, result *Result, endpoint, metric string) Diff {
+	for _, diff := range result.Diffs {
+		if diff.Endpoint == endpoint && diff.Metric == metric {
+			return diff
+		}
+	}
+	t.Fatalf("Expected a %s diff for %s", metric, endpoint)
+	return Diff{}
+}
+
+func Test_compare_Compare_WhenNoChange(t *testing.T) {
+	result := Compare(testSummary(100, 5), testSummary(100, 5), DefaultTolerances)
+
+	assert.False(t, result.HasRegression(), "Expected no regression between identical runs")
+	assert.Empty(t, result.ConfigChanges, "Expected no configuration change")
+	assert.Len(t, result.Diffs, 2+6, "Expected run diffs and the diffs of every endpoint")
+}
+
+func Test_compare_Compare_WhenLatencyRegressed(t *testing.T) {
+	result := Compare(testSummary(100, 5), testSummary(130, 5), DefaultTolerances)
+
+	diff := findDiff(t, result, "start_quiz", "p95")
+	assert.True(t, diff.Regression, "Expected a 30% latency increase to be a regression")
+	assert.InDelta(t, 0.3, diff.Change, 0.05, "Expected the relative change")
+	assert.Less(t, diff.PValue, 0.05, "Expected a significant difference")
+	assert.True(t, result.HasRegression(), "Expected the run to regress")
+}
+
+func Test_compare_Compare_WhenTailRegressed(t *testing.T) {
+	baseline, current := testSummary(100, 5), testSummary(100, 5)
+	// the slowest requests take twice as long while the others are a little faster, the mean is unchanged
+	tail := metrics.NewHistogram()
+	for i := 0; i < 1000; i++ {
+		switch {
+		case i%50 == 0:
+			tail.Record(200)
+		default:
+			tail.Record(99 + float64(i%21) - 10)
+		}
+	}
+	current.Endpoints = []metrics.EndpointStats{metrics.NewEndpointStats("start_quiz", tail, 5, 10)}
+	current.Histograms = map[string]*metrics.Histogram{"start_quiz": tail}
+	result := Compare(baseline, current, DefaultTolerances)
+
+	assert.False(t, findDiff(t, result, "start_quiz", "mean").Regression, "Expected the mean not to regress")
+	diff := findDiff(t, result, "start_quiz", "p99")
+	assert.Greater(t, diff.Change, 0.5, "Expected the p99 to increase")
+	assert.Less(t, diff.PValue, 0.05, "Expected a significant tail change")
+	assert.True(t, diff.Regression, "Expected the tail regression with an unchanged mean")
+}
+
+func Test_compare_Compare_WhenLatencyWithinTolerance(t *testing.T) {
+	result := Compare(testSummary(100, 5), testSummary(105, 5), DefaultTolerances)
+
+	diff := findDiff(t, result, "start_quiz", "mean")
+	assert.Less(t, diff.PValue, 0.05, "Expected a significant difference")
+	assert.False(t, diff.Regression, "Expected a 5% increase within the 10% tolerance")
+}
+
+func Test_compare_Compare_WhenLatencyImproved(t *testing.T) {
+	result := Compare(testSummary(130, 5), testSummary(100, 5), DefaultTolerances)
+
+	diff := findDiff(t, result, "start_quiz", "p50")
+	assert.True(t, diff.Improved, "Expected a lower latency to be an improvement")
+	assert.False(t, result.HasRegression(), "Expected no regression")
+}
+
+func Test_compare_Compare_WhenErrorRateRegressed(t *testing.T) {
+	result := Compare(testSummary(100, 5), testSummary(100, 50), DefaultTolerances)
+
+	diff := findDiff(t, result, "all", "error_rate")
+	assert.True(t, diff.Regression, "Expected a 0.5% to 5% error rate to be a regression")
+	assert.InDelta(t, 0.045, diff.Change, 0.0001, "Expected the absolute change of the error rate")
+
+	lenient := DefaultTolerances
+	lenient.ErrorRate = 0.1
+	assert.False(t, Compare(testSummary(100, 5), testSummary(100, 50), lenient).HasRegression(), "Expected no regression within the tolerance")
+}
+
+func Test_compare_Compare_WhenThroughputRegressed(t *testing.T) {
+	current := testSummary(100, 5)
+	current.Seconds = 20
+
+	result := Compare(testSummary(100, 5), current, DefaultTolerances)
+	diff := findDiff(t, result, "start_quiz", "rps")
+	assert.True(t, diff.Regression, "Expected half the throughput to be a regression")
+	assert.InDelta(t, -0.5, diff.Change, 0.0001, "Expected the relative change of the throughput")
+}
+
+func Test_compare_Compare_WhenConfigChanged(t *testing.T) {
+	current := testSummary(100, 5)
+	current.Config = map[string]string{"NUM_USERS": "200", "SEED": "1"}
+
+	result := Compare(testSummary(100, 5), current, DefaultTolerances)
+	assert.Equal(t, [2]string{"100", "200"}, result.ConfigChanges["NUM_USERS"], "Expected the changed value")
+	assert.Equal(t, [2]string{"", "1"}, result.ConfigChanges["SEED"], "Expected the added value")
+}
+
+func Test_compare_Result_Write(t *testing.T) {
+	var buf bytes.Buffer
+	result := Compare(testSummary(100, 5), testSummary(130, 5), DefaultTolerances)
+	require.NoError(t, result.Write(&buf), "Expected the comparison to be written")
+
+	assert.Contains(t, buf.String(), "ENDPOINT", "Expected the table header")
+	assert.Contains(t, buf.String(), "REGRESSION", "Expected the regression to be flagged")
+	assert.Contains(t, buf.String(), "Regression detected", "Expected the comparison outcome")
+}
+
+func Test_compare_ReadSummary(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "summary.json")
+	content, err := json.Marshal(testSummary(100, 5))
+	require.NoError(t, err, "Expected the summary to be encoded")
+	require.NoError(t, os.WriteFile(filePath, content, 0644), "Expected the summary to be written")
+
+	summary, err := ReadSummary(filePath)
+	require.NoError(t, err, "Expected the summary to be read")
+	assert.Equal(t, uint64(1000), summary.Histograms["start_quiz"].Count, "Expected the histograms to be decoded")
+
+	_, err = ReadSummary(filepath.Join(t.TempDir(), "missing.json"))
+	assert.Error(t, err, "Expected an error for a missing summary")
+}
+
+func Test_compare_ParseRatio(t *testing.T) {
+	ratio, err := ParseRatio("10%")
+	require.NoError(t, err, "Expected a valid percentage")
+	assert.InDelta(t, 0.1, ratio, 1e-9, "Expected the percentage as a fraction")
+
+	ratio, err = ParseRatio("0.05")
+	require.NoError(t, err, "Expected a valid fraction")
+	assert.Equal(t, 0.05, ratio, "Expected the fraction")
+
+	_, err = ParseRatio("ten")
+	assert.Error(t, err, "Expected an error for an invalid ratio")
+	_, err = ParseRatio("-1")
+	assert.Error(t, err, "Expected an error for a negative ratio")
+}
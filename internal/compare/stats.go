@@ -0,0 +1,124 @@
+package compare
+
+import (
+	"math"
+
+	"github.com/go-squad-5/quiz-load-test/internal/metrics"
+)
+
+// welchTTest returns the two-sided p-value of the difference between two means with unequal variances
+func welchTTest(mean1, stddev1 float64, n1 uint64, mean2, stddev2 float64, n2 uint64) float64 {
+	if n1 < 2 || n2 < 2 {
+		return 1
+	}
+	v1 := stddev1 * stddev1 / float64(n1)
+	v2 := stddev2 * stddev2 / float64(n2)
+	if v1+v2 == 0 {
+		if mean1 == mean2 {
+			return 1
+		}
+		return 0
+	}
+	t := (mean1 - mean2) / math.Sqrt(v1+v2)
+	// Welch–Satterthwaite degrees of freedom
+	df := (v1 + v2) * (v1 + v2) / (v1*v1/float64(n1-1) + v2*v2/float64(n2-1))
+	return regularizedIncompleteBeta(df/2, 0.5, df/(df+t*t))
+}
+
+// twoProportionZTest returns the two-sided p-value of the difference between two proportions
+func twoProportionZTest(x1, n1, x2, n2 uint64) float64 {
+	if n1 == 0 || n2 == 0 {
+		return 1
+	}
+	p1 := float64(x1) / float64(n1)
+	p2 := float64(x2) / float64(n2)
+	pooled := float64(x1+x2) / float64(n1+n2)
+	se := math.Sqrt(pooled * (1 - pooled) * (1/float64(n1) + 1/float64(n2)))
+	if se == 0 {
+		if p1 == p2 {
+			return 1
+		}
+		return 0
+	}
+	return 2 * normalSurvival(math.Abs(p1-p2)/se)
+}
+
+// quantileTest returns the two-sided p-value of the difference between the q quantiles of two latency histograms,
+// by comparing the share of the requests of each run above the quantile of the first run
+func quantileTest(hist1, hist2 *metrics.Histogram, q float64) float64 {
+	if hist1.Count == 0 || hist2.Count == 0 {
+		return 1
+	}
+	threshold := hist1.Quantile(q)
+	return twoProportionZTest(hist1.CountAbove(threshold), hist1.Count, hist2.CountAbove(threshold), hist2.Count)
+}
+
+// poissonRateTest returns the two-sided p-value of the difference between two event rates
+func poissonRateTest(count1 uint64, seconds1 float64, count2 uint64, seconds2 float64) float64 {
+	if seconds1 <= 0 || seconds2 <= 0 {
+		return 1
+	}
+	rate1 := float64(count1) / seconds1
+	rate2 := float64(count2) / seconds2
+	se := math.Sqrt(float64(count1)/(seconds1*seconds1) + float64(count2)/(seconds2*seconds2))
+	if se == 0 {
+		return 1
+	}
+	return 2 * normalSurvival(math.Abs(rate1-rate2)/se)
+}
+
+// normalSurvival returns P(Z > z) for a standard normal Z
+func normalSurvival(z float64) float64 {
+	return 0.5 * math.Erfc(z/math.Sqrt2)
+}
+
+// regularizedIncompleteBeta returns I_x(a, b), evaluated with the continued fraction of Numerical Recipes
+func regularizedIncompleteBeta(a, b, x float64) float64 {
+	if x <= 0 {
+		return 0
+	}
+	if x >= 1 {
+		return 1
+	}
+	lgab, _ := math.Lgamma(a + b)
+	lga, _ := math.Lgamma(a)
+	lgb, _ := math.Lgamma(b)
+	front := math.Exp(lgab - lga - lgb + a*math.Log(x) + b*math.Log(1-x))
+	if x < (a+1)/(a+b+2) {
+		return front * betaContinuedFraction(a, b, x) / a
+	}
+	return 1 - front*betaContinuedFraction(b, a, 1-x)/b
+}
+
+func betaContinuedFraction(a, b, x float64) float64 {
+	const maxIterations, epsilon, tiny = 300, 1e-12, 1e-300
+
+	c := 1.0
+	d := 1 - (a+b)*x/(a+1)
+	if math.Abs(d) < tiny {
+		d = tiny
+	}
+	d = 1 / d
+	h := d
+	for m := 1; m <= maxIterations; m++ {
+		fm := float64(m)
+		numerator := fm * (b - fm) * x / ((a + 2*fm - 1) * (a + 2*fm))
+		for i := 0; i < 2; i++ {
+			d = 1 + numerator*d
+			if math.Abs(d) < tiny {
+				d = tiny
+			}
+			c = 1 + numerator/c
+			if math.Abs(c) < tiny {
+				c = tiny
+			}
+			d = 1 / d
+			h *= d * c
+			numerator = -(a + fm) * (a + b + fm) * x / ((a + 2*fm) * (a + 2*fm + 1))
+		}
+		if math.Abs(d*c-1) < epsilon {
+			break
+		}
+	}
+	return h
+}
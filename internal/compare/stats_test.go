@@ -0,0 +1,52 @@
+package compare
+
+import (
+	"math"
+	"testing"
+
+	"github.com/go-squad-5/quiz-load-test/internal/metrics"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_compare_stats_RegularizedIncompleteBeta(t *testing.T) {
+	assert.InDelta(t, 0.5, regularizedIncompleteBeta(2, 2, 0.5), 1e-9, "Expected the symmetric beta at its center")
+	assert.InDelta(t, 0.1808, regularizedIncompleteBeta(2, 3, 0.2), 1e-9, "Expected I_0.2(2, 3)")
+	assert.Equal(t, float64(0), regularizedIncompleteBeta(2, 3, 0), "Expected 0 at x = 0")
+	assert.Equal(t, float64(1), regularizedIncompleteBeta(2, 3, 1), "Expected 1 at x = 1")
+}
+
+func Test_compare_stats_WelchTTest(t *testing.T) {
+	// t = 2.228 with 10 degrees of freedom is the two-sided 5% critical value
+	p := welchTTest(2.228, math.Sqrt(3), 6, 0, math.Sqrt(3), 6)
+	assert.InDelta(t, 0.05, p, 0.001, "Expected the p-value of the t statistic")
+
+	assert.Equal(t, float64(1), welchTTest(10, 2, 100, 10, 2, 100), "Expected no difference between equal means")
+	assert.Equal(t, float64(1), welchTTest(10, 2, 1, 20, 2, 100), "Expected no test with a single sample")
+	assert.Less(t, welchTTest(100, 10, 1000, 120, 10, 1000), 0.001, "Expected a significant difference")
+}
+
+func Test_compare_stats_TwoProportionZTest(t *testing.T) {
+	assert.InDelta(t, 0.0325, twoProportionZTest(40, 400, 60, 400), 0.001, "Expected the p-value of a 10% to 15% change")
+	assert.Equal(t, float64(1), twoProportionZTest(0, 100, 0, 100), "Expected no difference without errors")
+	assert.Equal(t, float64(1), twoProportionZTest(0, 0, 1, 10), "Expected no test without requests")
+}
+
+func Test_compare_stats_QuantileTest(t *testing.T) {
+	base, same, slower := metrics.NewHistogram(), metrics.NewHistogram(), metrics.NewHistogram()
+	for i := 0; i < 1000; i++ {
+		base.Record(float64(10 + i%100))
+		same.Record(float64(10 + (i+50)%100))
+		slower.Record(float64(10 + i%100))
+		if i%10 == 0 {
+			slower.Record(500)
+		}
+	}
+	assert.Greater(t, quantileTest(base, same, 0.95), 0.5, "Expected no significant change of the same distribution")
+	assert.Less(t, quantileTest(base, slower, 0.95), 0.001, "Expected a significant change of the tail")
+	assert.Equal(t, float64(1), quantileTest(base, metrics.NewHistogram(), 0.95), "Expected no test without requests")
+}
+
+func Test_compare_stats_PoissonRateTest(t *testing.T) {
+	assert.Less(t, poissonRateTest(1000, 10, 800, 10), 0.001, "Expected a significant throughput drop")
+	assert.Greater(t, poissonRateTest(100, 10, 98, 10), 0.5, "Expected no significant throughput change")
+}
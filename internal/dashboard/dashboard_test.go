@@ -0,0 +1,82 @@
+package dashboard
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-squad-5/quiz-load-test/internal/metrics"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_dashboard_Index(t *testing.T) {
+	s := NewServer(":0", metrics.NewCollector())
+	server := httptest.NewServer(s.Handler())
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "/")
+	require.NoError(t, err, "Expected no error while getting the dashboard page")
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err, "Expected to read the dashboard page")
+	assert.Equal(t, http.StatusOK, resp.StatusCode, "Expected status 200 for the dashboard page")
+	assert.Contains(t, resp.Header.Get("Content-Type"), "text/html", "Expected an html page")
+	assert.Contains(t, string(body), "EventSource", "Expected the page to subscribe to the events stream")
+}
+
+func Test_dashboard_Events(t *testing.T) {
+	collector := metrics.NewCollector("create_session")
+	s := NewServer(":0", collector)
+	server := httptest.NewServer(s.Handler())
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "/events")
+	require.NoError(t, err, "Expected no error while subscribing to the events")
+	defer resp.Body.Close()
+	assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"), "Expected a server-sent events stream")
+
+	collector.Observe(metrics.Observation{Endpoint: "create_session", Latency: 20 * time.Millisecond})
+	collector.Start(10 * time.Millisecond)
+
+	reader := bufio.NewReader(resp.Body)
+	line := ""
+	for !strings.HasPrefix(line, "data: ") {
+		line, err = reader.ReadString('\n')
+		require.NoError(t, err, "Expected to read an event from the stream")
+	}
+
+	var snapshot metrics.Snapshot
+	require.NoError(t, json.Unmarshal([]byte(strings.TrimPrefix(line, "data: ")), &snapshot), "Expected the event data to be a snapshot")
+	require.Len(t, snapshot.Endpoints, 1, "Expected the snapshot to contain the endpoint")
+	assert.Equal(t, "create_session", snapshot.Endpoints[0].Endpoint, "Expected the endpoint name in the snapshot")
+
+	// the stream ends when the collector stops
+	collector.Stop()
+	rest, err := io.ReadAll(reader)
+	require.NoError(t, err, "Expected the stream to end without error")
+	assert.Contains(t, string(rest), "event: end", "Expected an end event when the collector stops")
+}
+
+func Test_dashboard_StartAndShutdown(t *testing.T) {
+	s := NewServer("127.0.0.1:0", metrics.NewCollector())
+	require.NoError(t, s.Start(), "Expected the server to start on a random port")
+
+	resp, err := http.Get("http://" + s.Addr() + "/")
+	require.NoError(t, err, "Expected the started server to serve the dashboard")
+	resp.Body.Close()
+
+	require.NoError(t, s.Shutdown(context.Background()), "Expected the server to shutdown")
+}
+
+func Test_dashboard_Start_WhenInvalidAddr(t *testing.T) {
+	s := NewServer("invalid-address", metrics.NewCollector())
+	assert.Error(t, s.Start(), "Expected an error when listening on an invalid address")
+}
@@ -0,0 +1,157 @@
+package distributed
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// statusTimeout is the time given to a worker to answer its status
+const statusTimeout = 5 * time.Second
+
+// DefaultJobMargin is the time given to the sessions of a job to end after its last user started
+const DefaultJobMargin = 5 * time.Minute
+
+// Coordinator sends the jobs of a distributed run to its workers
+type Coordinator struct {
+	// base urls of the workers, an address without scheme is served over http, e.g. localhost:7071
+	Workers []string
+	Client  *http.Client
+	// a worker fails unless it answers within the duration of its job plus the margin, so a hung worker doesn't block the run
+	Margin time.Duration
+}
+
+func NewCoordinator(workers []string) *Coordinator {
+	urls := make([]string, len(workers))
+	for i, worker := range workers {
+		worker = strings.TrimSuffix(strings.TrimSpace(worker), "/")
+		if !strings.Contains(worker, "://") {
+			worker = "http://" + worker
+		}
+		urls[i] = worker
+	}
+	// the run request is answered once the job is over, it is bounded by the deadline of the job rather than the client
+	return &Coordinator{Workers: urls, Client: &http.Client{}, Margin: DefaultJobMargin}
+}
+
+// Run checks that every worker is idle, sends every worker the job with its index, and waits for their results
+// in the order of the workers. The run fails if any worker fails, the summary of the other workers would understate the load,
+// so the jobs of the other workers are cancelled at the first failure
+func (c *Coordinator) Run(ctx context.Context, job Job) ([]*Result, error) {
+	if len(c.Workers) == 0 {
+		return nil, fmt.Errorf("no workers to run the job")
+	}
+	duration, err := job.Duration()
+	if err != nil {
+		return nil, err
+	}
+	if err := c.checkWorkers(ctx); err != nil {
+		return nil, err
+	}
+
+	// the workers start a job without a start time at once
+	startAt := job.StartAt
+	if startAt.IsZero() {
+		startAt = time.Now()
+	}
+	ctx, cancel := context.WithDeadline(ctx, startAt.Add(duration+c.Margin))
+	defer cancel()
+
+	results := make([]*Result, len(c.Workers))
+	failed := &sync.Once{}
+	var failure error
+	wg := &sync.WaitGroup{}
+	for i, worker := range c.Workers {
+		workerJob := job
+		workerJob.Worker = i
+		workerJob.Workers = len(c.Workers)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			result, err := c.runJob(ctx, worker, workerJob)
+			if err != nil {
+				// the run fails with the first failure, the cancelled jobs only fail because of it
+				failed.Do(func() {
+					failure = err
+					cancel()
+				})
+				return
+			}
+			results[i] = result
+		}()
+	}
+	wg.Wait()
+	if failure != nil {
+		return nil, failure
+	}
+	return results, nil
+}
+
+// checkWorkers returns an error unless every worker is reachable and idle, so no worker starts a job the others can't run
+func (c *Coordinator) checkWorkers(ctx context.Context) error {
+	errs := []error{}
+	for _, worker := range c.Workers {
+		status := Status{}
+		if err := c.get(ctx, worker+"/status", &status); err != nil {
+			errs = append(errs, fmt.Errorf("worker %s unavailable: %w", worker, err))
+			continue
+		}
+		if status.Busy {
+			errs = append(errs, fmt.Errorf("worker %s busy with another job", worker))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (c *Coordinator) get(ctx context.Context, url string, value any) error {
+	ctx, cancel := context.WithTimeout(ctx, statusTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	return c.do(req, value)
+}
+
+func (c *Coordinator) runJob(ctx context.Context, worker string, job Job) (*Result, error) {
+	body, err := json.Marshal(job)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode the job: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, worker+"/run", bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	result := &Result{}
+	if err := c.do(req, result); err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			return nil, fmt.Errorf("worker %s failed: no result %s after the last user of the job started: %w", worker, c.Margin, err)
+		}
+		return nil, fmt.Errorf("worker %s failed: %w", worker, err)
+	}
+	return result, nil
+}
+
+func (c *Coordinator) do(req *http.Request, value any) error {
+	resp, err := c.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("status code: %d, %s", resp.StatusCode, strings.TrimSpace(string(message)))
+	}
+	if err := json.NewDecoder(resp.Body).Decode(value); err != nil {
+		return fmt.Errorf("failed to decode the response: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,118 @@
+package distributed
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-squad-5/quiz-load-test/internal/metrics"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_distributed_NewCoordinator(t *testing.T) {
+	c := NewCoordinator([]string{"localhost:7071", " https://worker:7072/ "})
+	assert.Equal(t, []string{"http://localhost:7071", "https://worker:7072"}, c.Workers, "Expected the base urls of the workers")
+}
+
+func Test_distributed_coordinator_Run(t *testing.T) {
+	mu := sync.Mutex{}
+	jobs := []Job{}
+	servers := make([]string, 3)
+	for i := range servers {
+		worker := NewWorker(func(ctx context.Context, job Job) (*Result, error) {
+			mu.Lock()
+			jobs = append(jobs, job)
+			mu.Unlock()
+			sessions := metrics.NewSessionStats()
+			sessions.Add(metrics.SessionResult{Status: "completed", Topic: "go", Score: job.Worker})
+			return &Result{Worker: job.Worker, Sessions: sessions.Aggregate()}, nil
+		})
+		server := httptest.NewServer(worker.Handler())
+		defer server.Close()
+		servers[i] = server.URL
+	}
+
+	results, err := NewCoordinator(servers).Run(context.Background(), Job{RunID: "run", NumUsers: 10})
+	require.NoError(t, err, "Expected the run to succeed")
+	require.Len(t, results, 3, "Expected the result of every worker")
+	for i, result := range results {
+		assert.Equal(t, i, result.Worker, "Expected the results in the order of the workers")
+		assert.Equal(t, map[string]map[int]int{"go": {i: 1}}, result.Sessions.Scores, "Expected the sessions of the worker")
+	}
+	require.Len(t, jobs, 3, "Expected every worker to run the job")
+	for _, job := range jobs {
+		assert.Equal(t, 3, job.Workers, "Expected the number of workers in the job")
+		assert.Equal(t, 10, job.NumUsers, "Expected the users of the whole run in the job")
+	}
+}
+
+func Test_distributed_coordinator_Run_WhenWorkerUnavailable(t *testing.T) {
+	ran := false
+	server := httptest.NewServer(NewWorker(func(ctx context.Context, job Job) (*Result, error) {
+		ran = true
+		return &Result{}, nil
+	}).Handler())
+	defer server.Close()
+	unavailable := httptest.NewServer(http.NotFoundHandler())
+	unavailable.Close()
+
+	_, err := NewCoordinator([]string{server.URL, unavailable.URL}).Run(context.Background(), Job{})
+	require.Error(t, err, "Expected the run to fail")
+	assert.Contains(t, err.Error(), "unavailable", "Expected the unavailable worker in the error")
+	assert.False(t, ran, "Expected no worker to start when a worker is unavailable")
+}
+
+func Test_distributed_coordinator_Run_WhenWorkerFailed(t *testing.T) {
+	cancelled := make(chan struct{})
+	workers := []string{}
+	for _, fail := range []bool{false, true} {
+		server := httptest.NewServer(NewWorker(func(ctx context.Context, job Job) (*Result, error) {
+			if fail {
+				return nil, assert.AnError
+			}
+			// the job of the other worker runs until it is cancelled
+			<-ctx.Done()
+			close(cancelled)
+			return nil, ctx.Err()
+		}).Handler())
+		defer server.Close()
+		workers = append(workers, server.URL)
+	}
+
+	_, err := NewCoordinator(workers).Run(context.Background(), Job{StartAt: time.Now()})
+	require.Error(t, err, "Expected the run to fail when a worker fails")
+	assert.Contains(t, err.Error(), workers[1], "Expected the failed worker in the error")
+	assert.Contains(t, err.Error(), "status code: 500", "Expected the status code of the failed worker")
+	assert.NotContains(t, err.Error(), workers[0], "Expected the cancelled worker not to be reported as failed")
+	select {
+	case <-cancelled:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected the job of the other worker to be cancelled")
+	}
+}
+
+func Test_distributed_coordinator_Run_WhenWorkerHangs(t *testing.T) {
+	server := httptest.NewServer(NewWorker(func(ctx context.Context, job Job) (*Result, error) {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}).Handler())
+	defer server.Close()
+
+	coordinator := NewCoordinator([]string{server.URL})
+	coordinator.Margin = 100 * time.Millisecond
+	start := time.Now()
+	// the users of the job start over 100ms, the worker fails 200ms after the start
+	_, err := coordinator.Run(context.Background(), Job{NumUsers: 10, ArrivalRate: 100, StartAt: start})
+	require.Error(t, err, "Expected the run to fail when a worker doesn't answer in time")
+	assert.Contains(t, err.Error(), "no result", "Expected the hung worker in the error")
+	assert.Less(t, time.Since(start), 2*time.Second, "Expected the run to fail at the deadline of the job")
+}
+
+func Test_distributed_coordinator_Run_WhenNoWorkers(t *testing.T) {
+	_, err := NewCoordinator(nil).Run(context.Background(), Job{})
+	assert.Error(t, err, "Expected the run to fail without workers")
+}
@@ -0,0 +1,83 @@
+package distributed
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/go-squad-5/quiz-load-test/internal/loadprofile"
+	"github.com/go-squad-5/quiz-load-test/internal/metrics"
+)
+
+// Job is the load of a distributed run, sent to every worker which runs its share of it
+type Job struct {
+	RunID string `json:"run_id"`
+	// index of the worker running the job, from 0, and number of workers of the run
+	Worker  int `json:"worker"`
+	Workers int `json:"workers"`
+	// load of the whole run, split across the workers
+	NumUsers    int     `json:"num_users"`
+	ArrivalRate float64 `json:"arrival_rate"`
+	LoadProfile string  `json:"load_profile"`
+	Seed        int64   `json:"seed"`
+	// time every worker starts its users at, so the load adds up from the start
+	StartAt time.Time `json:"start_at"`
+	// interval of the time series of the run, every worker flushes its metrics at the same times to be merged
+	MetricsInterval time.Duration `json:"metrics_interval"`
+}
+
+// Duration returns the time the job takes to start its users from StartAt, the time the sessions take is not included
+func (j Job) Duration() (time.Duration, error) {
+	if j.LoadProfile != "" {
+		profile, err := loadprofile.Parse(j.LoadProfile)
+		if err != nil {
+			return 0, fmt.Errorf("invalid load profile: %w", err)
+		}
+		return profile.Duration(), nil
+	}
+	if j.ArrivalRate > 0 {
+		return time.Duration(float64(j.NumUsers) / j.ArrivalRate * float64(time.Second)), nil
+	}
+	return 0, nil
+}
+
+// Result is what a worker observed running its job, merged by the coordinator into the summary of the run
+type Result struct {
+	Worker            int                             `json:"worker"`
+	StartedAt         time.Time                       `json:"started_at"`
+	FinishedAt        time.Time                       `json:"finished_at"`
+	Endpoints         []metrics.EndpointTotals        `json:"endpoints"`
+	IntendedEndpoints []metrics.EndpointTotals        `json:"intended_endpoints"`
+	Sessions          metrics.SessionAggregate        `json:"sessions"`
+	NegativeScenarios []metrics.NegativeScenarioStats `json:"negative_scenarios"`
+	AbortReason       string                          `json:"abort_reason,omitempty"`
+	// requests of every interval and of every stage of the load profile, merged into the time series and the stages of the run
+	TimeSeries []metrics.IntervalTotals `json:"time_series"`
+	Stages     []metrics.StageTotals    `json:"stages"`
+}
+
+// Share returns the share of the users of a worker, the users are spread evenly and the remainder goes to the first workers
+func Share(users, worker, workers int) int {
+	share := users / workers
+	if worker < users%workers {
+		share++
+	}
+	return share
+}
+
+// SplitProfile returns the load profile of a worker, with its share of the users of every stage.
+// The profile is labelled with the worker, the stages keep their labels
+func SplitProfile(profile loadprofile.Profile, worker, workers int) loadprofile.Profile {
+	if profile.Empty() {
+		return profile
+	}
+	split := loadprofile.Profile{
+		Expression: fmt.Sprintf("%s (worker %d of %d)", profile.Expression, worker+1, workers),
+		Segments:   make([]loadprofile.Segment, len(profile.Segments)),
+	}
+	for i, segment := range profile.Segments {
+		segment.From = Share(segment.From, worker, workers)
+		segment.To = Share(segment.To, worker, workers)
+		split.Segments[i] = segment
+	}
+	return split
+}
@@ -0,0 +1,53 @@
+package distributed
+
+import (
+	"testing"
+	"time"
+
+	"github.com/go-squad-5/quiz-load-test/internal/loadprofile"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_distributed_Share(t *testing.T) {
+	assert.Equal(t, []int{4, 3, 3}, []int{Share(10, 0, 3), Share(10, 1, 3), Share(10, 2, 3)}, "Expected the remainder to go to the first workers")
+	assert.Equal(t, []int{1, 0}, []int{Share(1, 0, 2), Share(1, 1, 2)}, "Expected a worker without users when there are fewer users than workers")
+	assert.Equal(t, 10, Share(10, 0, 1), "Expected a single worker to run every user")
+}
+
+func Test_distributed_SplitProfile(t *testing.T) {
+	profile, err := loadprofile.Parse("up=ramp(5, 10s),hold(10s)")
+	require.NoError(t, err, "Expected the profile to parse")
+
+	first := SplitProfile(profile, 0, 2)
+	second := SplitProfile(profile, 1, 2)
+	assert.Equal(t, "up=ramp(5, 10s),hold(10s) (worker 1 of 2)", first.Expression, "Expected the profile labelled with the worker")
+	require.Len(t, first.Segments, len(profile.Segments), "Expected every segment of the profile")
+	for i, segment := range profile.Segments {
+		assert.Equal(t, segment.To, first.Segments[i].To+second.Segments[i].To, "Expected the users of the segment split across the workers")
+		assert.Equal(t, segment.Stage, first.Segments[i].Stage, "Expected the stage labels kept")
+		assert.Equal(t, segment.Duration, first.Segments[i].Duration, "Expected the timing of the segment kept")
+	}
+	assert.Equal(t, 3, first.Segments[0].To, "Expected the remainder of the users to go to the first worker")
+	assert.Equal(t, 5, profile.Segments[0].To, "Expected the profile to be unchanged")
+	assert.Equal(t, profile.Duration(), first.Duration(), "Expected the duration of the profile kept")
+
+	assert.True(t, SplitProfile(loadprofile.Profile{}, 0, 2).Empty(), "Expected an empty profile to stay empty")
+}
+
+func Test_distributed_Job_Duration(t *testing.T) {
+	duration, err := Job{LoadProfile: "ramp(5, 10s),hold(20s)", NumUsers: 100, ArrivalRate: 1}.Duration()
+	require.NoError(t, err, "Expected a valid load profile")
+	assert.Equal(t, 30*time.Second, duration, "Expected the duration of the load profile")
+
+	duration, err = Job{NumUsers: 100, ArrivalRate: 4}.Duration()
+	require.NoError(t, err, "Expected no error")
+	assert.Equal(t, 25*time.Second, duration, "Expected the time to start the users at the arrival rate")
+
+	duration, err = Job{NumUsers: 100}.Duration()
+	require.NoError(t, err, "Expected no error")
+	assert.Zero(t, duration, "Expected every user started at once")
+
+	_, err = Job{LoadProfile: "ramp("}.Duration()
+	assert.Error(t, err, "Expected an invalid load profile to fail")
+}
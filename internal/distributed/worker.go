@@ -0,0 +1,89 @@
+package distributed
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+)
+
+// RunFunc runs the share of the job of a worker and returns what the worker observed,
+// the job should stop once the context is done, e.g. when the coordinator cancels the run
+type RunFunc func(ctx context.Context, job Job) (*Result, error)
+
+// Status is the state of a worker, a busy worker is running a job and rejects the other jobs
+type Status struct {
+	Busy bool `json:"busy"`
+}
+
+// Worker runs the jobs sent by a coordinator over http, one at a time
+type Worker struct {
+	mu   sync.Mutex
+	busy bool
+	run  RunFunc
+}
+
+func NewWorker(run RunFunc) *Worker {
+	return &Worker{run: run}
+}
+
+// Handler serves the status of the worker on GET /status, and runs the job posted to POST /run,
+// the response is sent once the job is over
+func (w *Worker) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /status", w.handleStatus)
+	mux.HandleFunc("POST /run", w.handleRun)
+	return mux
+}
+
+func (w *Worker) handleStatus(rw http.ResponseWriter, r *http.Request) {
+	w.mu.Lock()
+	status := Status{Busy: w.busy}
+	w.mu.Unlock()
+	writeJSON(rw, http.StatusOK, status)
+}
+
+func (w *Worker) handleRun(rw http.ResponseWriter, r *http.Request) {
+	job := Job{}
+	if err := json.NewDecoder(r.Body).Decode(&job); err != nil {
+		http.Error(rw, fmt.Sprintf("invalid job: %v", err), http.StatusBadRequest)
+		return
+	}
+	if job.Workers <= 0 || job.Worker < 0 || job.Worker >= job.Workers {
+		http.Error(rw, fmt.Sprintf("invalid job: worker %d of %d", job.Worker, job.Workers), http.StatusBadRequest)
+		return
+	}
+
+	w.mu.Lock()
+	if w.busy {
+		w.mu.Unlock()
+		http.Error(rw, "worker busy with another job", http.StatusConflict)
+		return
+	}
+	w.busy = true
+	w.mu.Unlock()
+	defer func() {
+		w.mu.Lock()
+		w.busy = false
+		w.mu.Unlock()
+	}()
+
+	result, err := w.run(r.Context(), job)
+	if err != nil {
+		http.Error(rw, fmt.Sprintf("failed to run the job: %v", err), http.StatusInternalServerError)
+		return
+	}
+	writeJSON(rw, http.StatusOK, result)
+}
+
+func writeJSON(rw http.ResponseWriter, statusCode int, value any) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		http.Error(rw, fmt.Sprintf("failed to encode the response: %v", err), http.StatusInternalServerError)
+		return
+	}
+	rw.Header().Set("Content-Type", "application/json")
+	rw.WriteHeader(statusCode)
+	rw.Write(data)
+}
@@ -0,0 +1,92 @@
+package distributed
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func postJob(t *testing.T, handler http.Handler, job Job) *httptest.ResponseRecorder {
+	body, err := json.Marshal(job)
+	require.NoError(t, err, "Expected the job to encode")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/run", bytes.NewReader(body)))
+	return rec
+}
+
+func Test_distributed_worker_Run(t *testing.T) {
+	var received Job
+	worker := NewWorker(func(ctx context.Context, job Job) (*Result, error) {
+		received = job
+		return &Result{Worker: job.Worker, AbortReason: "error rate"}, nil
+	})
+
+	rec := postJob(t, worker.Handler(), Job{RunID: "run", Worker: 1, Workers: 2, NumUsers: 10})
+	require.Equal(t, http.StatusOK, rec.Code, "Expected the job to run")
+	assert.Equal(t, Job{RunID: "run", Worker: 1, Workers: 2, NumUsers: 10}, received, "Expected the posted job to run")
+	result := Result{}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &result), "Expected the result as json")
+	assert.Equal(t, 1, result.Worker, "Expected the result of the job")
+	assert.Equal(t, "error rate", result.AbortReason, "Expected the result of the job")
+}
+
+func Test_distributed_worker_Run_WhenInvalid(t *testing.T) {
+	worker := NewWorker(func(ctx context.Context, job Job) (*Result, error) {
+		t.Fatal("Expected an invalid job not to run")
+		return nil, nil
+	})
+
+	for _, job := range []Job{{Worker: 0, Workers: 0}, {Worker: 2, Workers: 2}, {Worker: -1, Workers: 2}} {
+		rec := postJob(t, worker.Handler(), job)
+		assert.Equal(t, http.StatusBadRequest, rec.Code, fmt.Sprintf("Expected worker %d of %d to be rejected", job.Worker, job.Workers))
+	}
+
+	rec := httptest.NewRecorder()
+	worker.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/run", bytes.NewReader([]byte("{"))))
+	assert.Equal(t, http.StatusBadRequest, rec.Code, "Expected an invalid body to be rejected")
+}
+
+func Test_distributed_worker_Run_WhenFailed(t *testing.T) {
+	worker := NewWorker(func(ctx context.Context, job Job) (*Result, error) {
+		return nil, fmt.Errorf("invalid load profile")
+	})
+
+	rec := postJob(t, worker.Handler(), Job{Workers: 1})
+	assert.Equal(t, http.StatusInternalServerError, rec.Code, "Expected the failed job to be reported")
+	assert.Contains(t, rec.Body.String(), "invalid load profile", "Expected the error of the job")
+}
+
+func Test_distributed_worker_Run_WhenBusy(t *testing.T) {
+	started := make(chan struct{})
+	release := make(chan struct{})
+	worker := NewWorker(func(ctx context.Context, job Job) (*Result, error) {
+		close(started)
+		<-release
+		return &Result{}, nil
+	})
+	handler := worker.Handler()
+
+	done := make(chan int)
+	go func() {
+		done <- postJob(t, handler, Job{Workers: 1}).Code
+	}()
+	<-started
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/status", nil))
+	assert.JSONEq(t, `{"busy": true}`, rec.Body.String(), "Expected the worker to be busy while running a job")
+	assert.Equal(t, http.StatusConflict, postJob(t, handler, Job{Workers: 1}).Code, "Expected a second job to be rejected")
+
+	close(release)
+	assert.Equal(t, http.StatusOK, <-done, "Expected the first job to finish")
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/status", nil))
+	assert.JSONEq(t, `{"busy": false}`, rec.Body.String(), "Expected the worker to be idle once the job is over")
+}
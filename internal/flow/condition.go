@@ -0,0 +1,110 @@
+package flow
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Vars are the results of the steps run so far, by variable name, conditions are evaluated against them
+type Vars map[string]string
+
+// numeric variables of the conditions, the other variables are compared as strings
+var numericVariables []string = []string{"score", "questions", "answers", "random"}
+
+// text variables of the conditions, only compared for equality
+var textVariables []string = []string{"behaviour", "topic", "email_status"}
+
+// operators ordered so that the two characters operators are found before their prefix
+var operators []string = []string{"==", "!=", "<=", ">=", "<", ">"}
+
+// Condition compares a variable with a value, e.g. "score >= 3", "behaviour == abandon" or "submit.ms > 500".
+// The variables are the score, the number of questions and answers, a random number in [0, 1), the behaviour profile,
+// the topic, the last email status and the latency in ms of the last request of every request step, e.g. "start_quiz.ms"
+type Condition struct {
+	Variable string
+	Operator string
+	Value    string
+	text     string
+}
+
+// ParseCondition parses a condition of a step, the variable is on the left of the operator
+func ParseCondition(text string) (*Condition, error) {
+	for _, operator := range operators {
+		variable, value, found := strings.Cut(text, operator)
+		if !found {
+			continue
+		}
+		c := &Condition{
+			Variable: strings.TrimSpace(variable),
+			Operator: operator,
+			Value:    strings.Trim(strings.TrimSpace(value), `"'`),
+			text:     strings.TrimSpace(text),
+		}
+		if err := c.validate(); err != nil {
+			return nil, err
+		}
+		return c, nil
+	}
+	return nil, fmt.Errorf("invalid condition %q, expected a variable, an operator (%s) and a value", text, strings.Join(operators, " "))
+}
+
+func (c *Condition) validate() error {
+	switch {
+	case c.numeric():
+		if _, err := strconv.ParseFloat(c.Value, 64); err != nil {
+			return fmt.Errorf("invalid condition %q, %s is compared with a number", c.text, c.Variable)
+		}
+	case slices.Contains(textVariables, c.Variable):
+		if c.Operator != "==" && c.Operator != "!=" {
+			return fmt.Errorf("invalid condition %q, %s is only compared with == or !=", c.text, c.Variable)
+		}
+	default:
+		return fmt.Errorf("invalid condition %q, unknown variable %q", c.text, c.Variable)
+	}
+	return nil
+}
+
+func (c *Condition) numeric() bool {
+	if slices.Contains(numericVariables, c.Variable) {
+		return true
+	}
+	action, found := strings.CutSuffix(c.Variable, ".ms")
+	return found && Action(action).Request()
+}
+
+func (c *Condition) String() string {
+	if c == nil {
+		return ""
+	}
+	return c.text
+}
+
+// Eval evaluates the condition against the variables, a nil condition is always true.
+// A variable without a value, e.g. the latency of a request not sent yet, is an empty string or 0
+func (c *Condition) Eval(vars Vars) bool {
+	if c == nil {
+		return true
+	}
+	value := vars[c.Variable]
+	if !c.numeric() {
+		return (value == c.Value) == (c.Operator == "==")
+	}
+	// the values are validated when parsed, an unset variable is 0
+	actual, _ := strconv.ParseFloat(value, 64)
+	expected, _ := strconv.ParseFloat(c.Value, 64)
+	switch c.Operator {
+	case "==":
+		return actual == expected
+	case "!=":
+		return actual != expected
+	case "<":
+		return actual < expected
+	case "<=":
+		return actual <= expected
+	case ">":
+		return actual > expected
+	}
+	return actual >= expected
+}
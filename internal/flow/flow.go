@@ -0,0 +1,296 @@
+package flow
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Action is what a step of the flow does
+type Action string
+
+const (
+	// create the session of the user, the first request of every flow
+	CreateSession Action = "create_session"
+	// start the quiz of the session
+	StartQuiz Action = "start_quiz"
+	// mark a random answer to every question
+	Answer Action = "answer"
+	// submit the answers, the score is verified against the answer key when configured
+	Submit Action = "submit"
+	// get the report of the session
+	GetReport Action = "get_report"
+	// request the email report of the session
+	EmailReport Action = "email_report"
+	// wait for the duration of the step, plus a random jitter
+	Sleep Action = "sleep"
+	// fail the session unless the condition of the step holds
+	Assert Action = "assert"
+	// continue the flow at the named step
+	Goto Action = "goto"
+	// end the session as abandoned
+	Abandon Action = "abandon"
+	// end the session as completed, before the last step
+	End Action = "end"
+)
+
+// Actions lists the actions in the order they are described in the README
+var Actions []Action = []Action{CreateSession, StartQuiz, Answer, Submit, GetReport, EmailReport, Sleep, Assert, Goto, Abandon, End}
+
+// Request reports whether the action sends a request to the quiz api
+func (a Action) Request() bool {
+	switch a {
+	case CreateSession, StartQuiz, Submit, GetReport, EmailReport:
+		return true
+	}
+	return false
+}
+
+// MaxSteps is the number of steps a session may run, so a flow looping with goto steps ends
+const MaxSteps = 1000
+
+// Step is a step of the flow, run when its condition holds
+type Step struct {
+	Action Action
+	// name of the step, the target of the goto steps
+	Name string
+	// the step is skipped unless the condition holds
+	If *Condition
+	// the consecutive parallel steps run concurrently, only the report requests may run in parallel
+	Parallel bool
+	// time slept by a sleep step, plus a random jitter up to Jitter
+	Duration time.Duration
+	Jitter   time.Duration
+	// condition of an assert step
+	That *Condition
+	// name of the step a goto step continues at
+	To string
+}
+
+// Flow is an ordered list of steps run by every user
+type Flow struct {
+	Name  string
+	Steps []Step
+}
+
+// Default is the flow of the users when no flow is configured: the quiz is completed and both reports are requested
+// at the same time. It follows the behaviour profile of the user, the skip email and retry submit profiles are
+// handled by the steps themselves
+func Default() *Flow {
+	return &Flow{
+		Name: "default",
+		Steps: []Step{
+			{Action: CreateSession},
+			{Action: StartQuiz},
+			{Action: Abandon, If: mustParseCondition("behaviour == abandon")},
+			{Action: Answer},
+			{Action: Submit},
+			{Action: GetReport, Parallel: true},
+			{Action: EmailReport, Parallel: true, If: mustParseCondition("behaviour != skip_email")},
+		},
+	}
+}
+
+func mustParseCondition(text string) *Condition {
+	c, err := ParseCondition(text)
+	if err != nil {
+		panic(err)
+	}
+	return c
+}
+
+// Index returns the index of the named step, -1 if there is none
+func (f *Flow) Index(name string) int {
+	return slices.IndexFunc(f.Steps, func(step Step) bool { return step.Name == name })
+}
+
+// ParallelGroup returns the index after the parallel steps starting at the index
+func (f *Flow) ParallelGroup(start int) int {
+	end := start
+	for end < len(f.Steps) && f.Steps[end].Parallel {
+		end++
+	}
+	return end
+}
+
+// Load reads a flow file, a json object with the name and the steps of the flow, e.g.
+// {"name": "impatient", "steps": ["create_session", "start_quiz", {"step": "abandon", "if": "random < 0.5"}, "answer", "submit"]}
+func Load(filePath string) (*Flow, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read flow: %w", err)
+	}
+	return Parse(data)
+}
+
+// jsonFlow is a flow as written in a flow file, a step is either the name of its action or an object
+type jsonFlow struct {
+	Name  string            `json:"name"`
+	Steps []json.RawMessage `json:"steps"`
+}
+
+type jsonStep struct {
+	Step     Action `json:"step"`
+	Name     string `json:"name"`
+	If       string `json:"if"`
+	Parallel bool   `json:"parallel"`
+	Duration string `json:"duration"`
+	Jitter   string `json:"jitter"`
+	That     string `json:"that"`
+	To       string `json:"to"`
+}
+
+// Parse parses and validates a flow
+func Parse(data []byte) (*Flow, error) {
+	raw := jsonFlow{}
+	if err := decodeStrict(data, &raw); err != nil {
+		return nil, fmt.Errorf("failed to parse flow: %w", err)
+	}
+	f := &Flow{Name: raw.Name, Steps: make([]Step, len(raw.Steps))}
+	for i, data := range raw.Steps {
+		step, err := parseStep(data)
+		if err != nil {
+			return nil, fmt.Errorf("invalid step %d: %w", i+1, err)
+		}
+		f.Steps[i] = step
+	}
+	if err := f.validate(); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+func parseStep(data []byte) (Step, error) {
+	raw := jsonStep{}
+	if err := json.Unmarshal(data, &raw.Step); err != nil {
+		if err := decodeStrict(data, &raw); err != nil {
+			return Step{}, fmt.Errorf("expected the name of an action or an object: %w", err)
+		}
+	}
+	step := Step{Action: raw.Step, Name: raw.Name, Parallel: raw.Parallel, To: raw.To}
+	var err error
+	if raw.If != "" {
+		if step.If, err = ParseCondition(raw.If); err != nil {
+			return Step{}, err
+		}
+	}
+	if raw.That != "" {
+		if step.That, err = ParseCondition(raw.That); err != nil {
+			return Step{}, err
+		}
+	}
+	if raw.Duration != "" {
+		if step.Duration, err = time.ParseDuration(raw.Duration); err != nil {
+			return Step{}, fmt.Errorf("invalid duration %q", raw.Duration)
+		}
+	}
+	if raw.Jitter != "" {
+		if step.Jitter, err = time.ParseDuration(raw.Jitter); err != nil {
+			return Step{}, fmt.Errorf("invalid jitter %q", raw.Jitter)
+		}
+	}
+	return step, nil
+}
+
+// decodeStrict decodes json rejecting the unknown fields, a misspelled field would silently change the flow
+func decodeStrict(data []byte, value any) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	return decoder.Decode(value)
+}
+
+// validate checks the steps of the flow before any user runs it
+func (f *Flow) validate() error {
+	if len(f.Steps) == 0 {
+		return fmt.Errorf("invalid flow, expected at least a step")
+	}
+	names := map[string]bool{}
+	for i, step := range f.Steps {
+		if err := step.validate(); err != nil {
+			return fmt.Errorf("invalid step %d: %w", i+1, err)
+		}
+		if step.Name != "" {
+			if names[step.Name] {
+				return fmt.Errorf("invalid step %d: duplicate name %q", i+1, step.Name)
+			}
+			names[step.Name] = true
+		}
+	}
+
+	// every request but the session creation is sent for the session, it must be created first and once
+	created := -1
+	for i, step := range f.Steps {
+		switch {
+		case step.Action == CreateSession && created >= 0:
+			return fmt.Errorf("invalid step %d: the session is already created at step %d", i+1, created+1)
+		case step.Action == CreateSession:
+			created = i
+		case created < 0 && (step.Action.Request() || step.Action == Answer || step.Action == Abandon):
+			return fmt.Errorf("invalid step %d: %s before create_session", i+1, step.Action)
+		}
+	}
+	if created < 0 {
+		return fmt.Errorf("invalid flow, expected a create_session step")
+	}
+	for i, step := range f.Steps {
+		if step.Action != Goto {
+			continue
+		}
+		target := f.Index(step.To)
+		if target < 0 {
+			return fmt.Errorf("invalid step %d: unknown step %q", i+1, step.To)
+		}
+		if target <= created {
+			return fmt.Errorf("invalid step %d: goto %q would create the session again", i+1, step.To)
+		}
+	}
+	return nil
+}
+
+func (s Step) validate() error {
+	if !slices.Contains(Actions, s.Action) {
+		actions := make([]string, len(Actions))
+		for i, action := range Actions {
+			actions[i] = string(action)
+		}
+		return fmt.Errorf("unknown action %q, expected one of %s", s.Action, strings.Join(actions, ", "))
+	}
+	switch {
+	case s.Action == Sleep && s.Duration <= 0:
+		return fmt.Errorf("sleep without a positive duration")
+	case s.Action != Sleep && (s.Duration != 0 || s.Jitter != 0):
+		return fmt.Errorf("duration of a %s step, only the sleep steps have a duration", s.Action)
+	case s.Jitter < 0:
+		return fmt.Errorf("negative jitter")
+	case s.Action == Assert && s.That == nil:
+		return fmt.Errorf("assert without a condition")
+	case s.Action != Assert && s.That != nil:
+		return fmt.Errorf("condition of a %s step, only the assert steps have a condition, use if to skip a step", s.Action)
+	case s.Action == Goto && s.To == "":
+		return fmt.Errorf("goto without a step to go to")
+	case s.Action != Goto && s.To != "":
+		return fmt.Errorf("target of a %s step, only the goto steps go to a step", s.Action)
+	case s.Parallel && s.Action != GetReport && s.Action != EmailReport:
+		return fmt.Errorf("parallel %s step, only get_report and email_report may run in parallel", s.Action)
+	}
+	return nil
+}
+
+// String describes the flow by its name, or its steps when unnamed
+func (f *Flow) String() string {
+	if f == nil {
+		return ""
+	}
+	if f.Name != "" {
+		return f.Name
+	}
+	actions := make([]string, len(f.Steps))
+	for i, step := range f.Steps {
+		actions[i] = string(step.Action)
+	}
+	return strings.Join(actions, ",")
+}
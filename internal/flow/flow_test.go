@@ -0,0 +1,134 @@
+package flow
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_flow_Parse(t *testing.T) {
+	f, err := Parse([]byte(`{
+		"name": "impatient",
+		"steps": [
+			"create_session",
+			"start_quiz",
+			{"step": "sleep", "duration": "2s", "jitter": "500ms"},
+			{"step": "abandon", "if": "questions > 20"},
+			{"step": "answer", "name": "answer"},
+			"submit",
+			{"step": "goto", "to": "answer", "if": "score < 1"},
+			{"step": "assert", "that": "score >= 1"},
+			{"step": "get_report", "parallel": true},
+			{"step": "email_report", "parallel": true, "if": "random < 0.5"}
+		]
+	}`))
+	require.NoError(t, err, "Expected a valid flow")
+
+	assert.Equal(t, "impatient", f.String(), "Expected the name of the flow")
+	require.Len(t, f.Steps, 10, "Expected every step")
+	assert.Equal(t, Step{Action: CreateSession}, f.Steps[0], "Expected a step named after its action")
+	assert.Equal(t, Step{Action: Sleep, Duration: 2 * time.Second, Jitter: 500 * time.Millisecond}, f.Steps[2], "Expected the duration of the sleep step")
+	assert.Equal(t, "questions > 20", f.Steps[3].If.String(), "Expected the condition of the step")
+	assert.Equal(t, "score >= 1", f.Steps[7].That.String(), "Expected the condition of the assert step")
+	assert.Equal(t, 4, f.Index(f.Steps[6].To), "Expected the goto step to target the named step")
+	assert.Equal(t, 10, f.ParallelGroup(8), "Expected the report steps to run in parallel")
+	assert.Equal(t, 2, f.ParallelGroup(2), "Expected a step not in parallel to end the group")
+}
+
+func Test_flow_Parse_WhenInvalid(t *testing.T) {
+	tests := []struct {
+		name  string
+		steps string
+		err   string
+	}{
+		{"no steps", `[]`, "invalid flow, expected at least a step"},
+		{"unknown action", `["create_session", "dance"]`, `invalid step 2: unknown action "dance"`},
+		{"unknown field", `["create_session", {"step": "sleep", "duration": "1s", "seconds": 1}]`, `invalid step 2: expected the name of an action or an object`},
+		{"invalid condition", `["create_session", {"step": "submit", "if": "mood == happy"}]`, `invalid step 2: invalid condition "mood == happy", unknown variable "mood"`},
+		{"sleep without duration", `["create_session", "sleep"]`, "invalid step 2: sleep without a positive duration"},
+		{"duration of a request", `["create_session", {"step": "submit", "duration": "1s"}]`, "invalid step 2: duration of a submit step"},
+		{"assert without condition", `["create_session", "assert"]`, "invalid step 2: assert without a condition"},
+		{"condition of a request", `["create_session", {"step": "submit", "that": "score > 1"}]`, "invalid step 2: condition of a submit step"},
+		{"goto unknown step", `["create_session", {"step": "goto", "to": "nowhere"}]`, `invalid step 2: unknown step "nowhere"`},
+		{"goto session creation", `[{"step": "create_session", "name": "start"}, {"step": "goto", "to": "start"}]`, `invalid step 2: goto "start" would create the session again`},
+		{"duplicate name", `["create_session", {"step": "submit", "name": "s"}, {"step": "submit", "name": "s"}]`, `invalid step 3: duplicate name "s"`},
+		{"parallel submit", `["create_session", {"step": "submit", "parallel": true}]`, "invalid step 2: parallel submit step"},
+		{"request before session", `["start_quiz", "create_session"]`, "invalid step 1: start_quiz before create_session"},
+		{"second session", `["create_session", "create_session"]`, "invalid step 2: the session is already created at step 1"},
+		{"no session", `[{"step": "sleep", "duration": "1s"}]`, "invalid flow, expected a create_session step"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse([]byte(`{"steps": ` + tt.steps + `}`))
+			require.Error(t, err, "Expected an invalid flow")
+			assert.Contains(t, err.Error(), tt.err, "Expected the reason the flow is invalid")
+		})
+	}
+}
+
+func Test_flow_Load(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "flow.json")
+	require.NoError(t, os.WriteFile(filePath, []byte(`{"steps": ["create_session", "start_quiz"]}`), 0o644), "Expected to write the flow file")
+
+	f, err := Load(filePath)
+	require.NoError(t, err, "Expected the flow file to be loaded")
+	assert.Equal(t, "create_session,start_quiz", f.String(), "Expected an unnamed flow to be described by its steps")
+
+	_, err = Load(filepath.Join(t.TempDir(), "missing.json"))
+	assert.Error(t, err, "Expected an error for a missing flow file")
+}
+
+func Test_flow_Default(t *testing.T) {
+	f := Default()
+	require.NoError(t, f.validate(), "Expected the default flow to be valid")
+	assert.Equal(t, 7, f.ParallelGroup(5), "Expected both reports to be requested in parallel")
+	assert.True(t, f.Steps[2].If.Eval(Vars{"behaviour": "abandon"}), "Expected the abandon profile to abandon the quiz")
+	assert.False(t, f.Steps[6].If.Eval(Vars{"behaviour": "skip_email"}), "Expected the skip email profile to skip the email report")
+}
+
+func Test_flow_Condition_Eval(t *testing.T) {
+	vars := Vars{"score": "3", "behaviour": "complete", "submit.ms": "250"}
+	tests := []struct {
+		condition string
+		expected  bool
+	}{
+		{"score >= 3", true},
+		{"score > 3", false},
+		{"score<4", true},
+		{"score <= 2", false},
+		{"score == 3.0", true},
+		{"score != 3", false},
+		{"submit.ms > 200", true},
+		{"questions == 0", true},
+		{"behaviour == complete", true},
+		{`behaviour != "complete"`, false},
+		{"email_status == ''", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.condition, func(t *testing.T) {
+			c, err := ParseCondition(tt.condition)
+			require.NoError(t, err, "Expected a valid condition")
+			assert.Equal(t, tt.expected, c.Eval(vars), "Expected the condition to be evaluated against the variables")
+		})
+	}
+	assert.True(t, (*Condition)(nil).Eval(vars), "Expected a step without condition to run")
+}
+
+func Test_flow_ParseCondition_WhenInvalid(t *testing.T) {
+	for _, text := range []string{
+		"score",
+		"score >= many",
+		"behaviour > abandon",
+		"mood == happy",
+		"sleep.ms > 1",
+	} {
+		_, err := ParseCondition(text)
+		assert.Error(t, err, "Expected an error for %q", text)
+	}
+}
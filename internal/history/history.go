@@ -0,0 +1,213 @@
+package history
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/go-squad-5/quiz-load-test/internal/metrics"
+)
+
+const runFileName = "run.json"
+
+// runIDPattern are the run IDs which are safe as the name of the run directory
+var runIDPattern = regexp.MustCompile(`^[A-Za-z0-9_-][A-Za-z0-9._-]*$`)
+
+// ErrRunExists is returned when a run is saved with the ID of a run already in the store
+var ErrRunExists = errors.New("run already saved")
+
+// Run is a load test run saved in the store
+type Run struct {
+	ID        string            `json:"id"`
+	StartedAt time.Time         `json:"started_at"`
+	Seed      int64             `json:"seed"`
+	Labels    map[string]string `json:"labels"`
+	Summary   *metrics.Summary  `json:"summary"`
+}
+
+// Store saves every run in its own directory, named after the run ID
+type Store struct {
+	dir string
+}
+
+func NewStore(dir string) *Store {
+	return &Store{dir: dir}
+}
+
+// NewRunID returns a unique run ID, sortable by the start time of the run
+func NewRunID(startedAt time.Time) string {
+	suffix := make([]byte, 3)
+	if _, err := rand.Read(suffix); err != nil {
+		return startedAt.Format("20060102-150405.000")
+	}
+	return startedAt.Format("20060102-150405") + "-" + hex.EncodeToString(suffix)
+}
+
+// ValidateRunID returns an error when the run ID can't name a run directory, e.g. it contains a path separator
+func ValidateRunID(id string) error {
+	if !runIDPattern.MatchString(id) {
+		return fmt.Errorf("invalid run id %q, expected letters, digits, '.', '_' or '-' not starting with '.'", id)
+	}
+	return nil
+}
+
+// GitCommit returns the commit checked out in the working directory, or an empty string outside of a git repository
+func GitCommit() string {
+	out, err := exec.Command("git", "rev-parse", "HEAD").Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(out))
+}
+
+// ParseLabels parses comma separated key=value labels, e.g. "commit=3f2a1c,env=staging"
+func ParseLabels(value string) (map[string]string, error) {
+	labels := map[string]string{}
+	for _, pair := range strings.Split(value, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		key, val, ok := strings.Cut(pair, "=")
+		if !ok || strings.TrimSpace(key) == "" {
+			return nil, fmt.Errorf("invalid label %q, expected key=value", pair)
+		}
+		labels[strings.TrimSpace(key)] = strings.TrimSpace(val)
+	}
+	return labels, nil
+}
+
+// Save writes the run and copies the artifacts files into the run directory, it returns the run directory.
+// A run is never overwritten, saving a run with the ID of a saved run returns ErrRunExists
+func (s *Store) Save(run *Run, artifacts ...string) (string, error) {
+	if err := ValidateRunID(run.ID); err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(s.dir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create history directory: %w", err)
+	}
+	runDir := filepath.Join(s.dir, run.ID)
+	if err := os.Mkdir(runDir, 0755); errors.Is(err, os.ErrExist) {
+		return "", fmt.Errorf("%w: %s", ErrRunExists, runDir)
+	} else if err != nil {
+		return "", fmt.Errorf("failed to create run directory: %w", err)
+	}
+
+	file, err := os.Create(filepath.Join(runDir, runFileName))
+	if err != nil {
+		return "", fmt.Errorf("failed to create run file: %w", err)
+	}
+	defer file.Close()
+
+	encoder := json.NewEncoder(file)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(run); err != nil {
+		return "", fmt.Errorf("failed to encode run: %w", err)
+	}
+
+	for _, artifact := range artifacts {
+		if err := copyFile(artifact, filepath.Join(runDir, filepath.Base(artifact))); err != nil {
+			return runDir, err
+		}
+	}
+	return runDir, nil
+}
+
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return fmt.Errorf("failed to open artifact: %w", err)
+	}
+	defer in.Close()
+
+	out, err := os.Create(dst)
+	if err != nil {
+		return fmt.Errorf("failed to create artifact copy: %w", err)
+	}
+	defer out.Close()
+
+	if _, err := io.Copy(out, in); err != nil {
+		return fmt.Errorf("failed to copy artifact %s: %w", src, err)
+	}
+	return nil
+}
+
+// List returns the saved runs matching all the labels, oldest first
+func (s *Store) List(labels map[string]string) ([]*Run, error) {
+	entries, err := os.ReadDir(s.dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return []*Run{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read history directory: %w", err)
+	}
+
+	runs := []*Run{}
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		run, err := readRun(filepath.Join(s.dir, entry.Name(), runFileName))
+		if errors.Is(err, os.ErrNotExist) {
+			// not a run directory
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if matchLabels(run, labels) {
+			runs = append(runs, run)
+		}
+	}
+	sort.Slice(runs, func(i, j int) bool {
+		return runs[i].StartedAt.Before(runs[j].StartedAt)
+	})
+	return runs, nil
+}
+
+// Exists reports whether a run with the ID is saved in the store
+func (s *Store) Exists(id string) bool {
+	_, err := os.Stat(filepath.Join(s.dir, id))
+	return err == nil
+}
+
+// Get returns the saved run with the given ID
+func (s *Store) Get(id string) (*Run, error) {
+	if err := ValidateRunID(id); err != nil {
+		return nil, err
+	}
+	return readRun(filepath.Join(s.dir, id, runFileName))
+}
+
+func readRun(filePath string) (*Run, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	run := &Run{}
+	if err := json.NewDecoder(file).Decode(run); err != nil {
+		return nil, fmt.Errorf("failed to decode run %s: %w", filePath, err)
+	}
+	return run, nil
+}
+
+func matchLabels(run *Run, labels map[string]string) bool {
+	for key, value := range labels {
+		if run.Labels[key] != value {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,130 @@
+package history
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-squad-5/quiz-load-test/internal/metrics"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testRun(id string, startedAt time.Time, labels map[string]string) *Run {
+	return &Run{
+		ID:        id,
+		StartedAt: startedAt,
+		Seed:      42,
+		Labels:    labels,
+		Summary:   &metrics.Summary{Sessions: metrics.SessionCounts{Total: 10, Completed: 10}},
+	}
+}
+
+func Test_history_NewRunID(t *testing.T) {
+	startedAt := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	id := NewRunID(startedAt)
+	assert.Regexp(t, `^20250102-030405-[0-9a-f]{6}$`, id, "Expected the start time and a random suffix")
+	assert.NotEqual(t, id, NewRunID(startedAt), "Expected unique ids for runs started at the same time")
+}
+
+func Test_history_ValidateRunID(t *testing.T) {
+	for _, id := range []string{"20250102-030405-a1b2c3", "release-1.2_rc", "nightly-worker-2"} {
+		assert.NoError(t, ValidateRunID(id), "Expected %q to be a valid run id", id)
+	}
+	for _, id := range []string{"", ".", "..", "../x", "a/b", `a\b`, ".hidden", "run id"} {
+		assert.Error(t, ValidateRunID(id), "Expected %q to be an invalid run id", id)
+	}
+}
+
+func Test_history_GitCommit(t *testing.T) {
+	// best effort, the tests may run outside of a git repository
+	if commit := GitCommit(); commit != "" {
+		assert.Regexp(t, `^[0-9a-f]{40,64}$`, commit, "Expected the hash of the commit")
+	}
+}
+
+func Test_history_ParseLabels(t *testing.T) {
+	labels, err := ParseLabels("commit=3f2a1c, env = staging,")
+	require.NoError(t, err, "Expected valid labels")
+	assert.Equal(t, map[string]string{"commit": "3f2a1c", "env": "staging"}, labels, "Expected every label")
+
+	labels, err = ParseLabels("")
+	require.NoError(t, err, "Expected no error without labels")
+	assert.Empty(t, labels, "Expected no labels")
+
+	_, err = ParseLabels("staging")
+	assert.Error(t, err, "Expected an error for a label without value")
+}
+
+func Test_history_Store_SaveAndList(t *testing.T) {
+	dir := t.TempDir()
+	store := NewStore(dir)
+
+	artifact := filepath.Join(t.TempDir(), "logs.txt")
+	require.NoError(t, os.WriteFile(artifact, []byte("logs"), 0644), "Expected the artifact to be written")
+
+	now := time.Now()
+	runDir, err := store.Save(testRun("second", now, map[string]string{"env": "staging"}), artifact)
+	require.NoError(t, err, "Expected the run to be saved")
+	assert.Equal(t, filepath.Join(dir, "second"), runDir, "Expected the run directory named after the run id")
+	content, err := os.ReadFile(filepath.Join(runDir, "logs.txt"))
+	require.NoError(t, err, "Expected the artifact in the run directory")
+	assert.Equal(t, "logs", string(content), "Expected a copy of the artifact")
+
+	_, err = store.Save(testRun("first", now.Add(-time.Hour), map[string]string{"env": "prod"}))
+	require.NoError(t, err, "Expected the run to be saved")
+	require.NoError(t, os.Mkdir(filepath.Join(dir, "not-a-run"), 0755), "Expected the directory to be created")
+
+	runs, err := store.List(nil)
+	require.NoError(t, err, "Expected the runs to be listed")
+	require.Len(t, runs, 2, "Expected every saved run")
+	assert.Equal(t, "first", runs[0].ID, "Expected the oldest run first")
+	assert.Equal(t, int64(42), runs[1].Seed, "Expected the seed of the run")
+	assert.Equal(t, 10, runs[1].Summary.Sessions.Total, "Expected the summary of the run")
+
+	runs, err = store.List(map[string]string{"env": "staging"})
+	require.NoError(t, err, "Expected the runs to be listed")
+	require.Len(t, runs, 1, "Expected only the runs with the labels")
+	assert.Equal(t, "second", runs[0].ID, "Expected the run with the labels")
+
+	run, err := store.Get("first")
+	require.NoError(t, err, "Expected the run to be found")
+	assert.Equal(t, "prod", run.Labels["env"], "Expected the labels of the run")
+}
+
+func Test_history_Store_List_WhenNoHistory(t *testing.T) {
+	runs, err := NewStore(filepath.Join(t.TempDir(), "missing")).List(nil)
+	require.NoError(t, err, "Expected no error without history")
+	assert.Empty(t, runs, "Expected no runs")
+}
+
+func Test_history_Store_Save_WhenRunExists(t *testing.T) {
+	store := NewStore(t.TempDir())
+	runDir, err := store.Save(testRun("nightly", time.Now(), nil))
+	require.NoError(t, err, "Expected the run to be saved")
+	assert.True(t, store.Exists("nightly"), "Expected the saved run to exist")
+	assert.False(t, store.Exists("weekly"), "Expected an unknown run not to exist")
+
+	rerun := testRun("nightly", time.Now(), nil)
+	rerun.Seed = 7
+	_, err = store.Save(rerun)
+	require.ErrorIs(t, err, ErrRunExists, "Expected the run id to be rejected")
+
+	run, err := store.Get("nightly")
+	require.NoError(t, err, "Expected the first run to be found")
+	assert.Equal(t, int64(42), run.Seed, "Expected the first run not to be overwritten")
+	assert.DirExists(t, runDir, "Expected the run directory to be kept")
+}
+
+func Test_history_Store_Save_WhenInvalidRunID(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "runs")
+	store := NewStore(dir)
+
+	_, err := store.Save(testRun("../escaped", time.Now(), nil))
+	require.Error(t, err, "Expected the run id to be rejected")
+	assert.NoDirExists(t, filepath.Join(dir, "..", "escaped"), "Expected nothing written outside of the store")
+
+	_, err = store.Get("../escaped")
+	assert.Error(t, err, "Expected the run id to be rejected")
+}
@@ -0,0 +1,164 @@
+package history
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/go-squad-5/quiz-load-test/internal/metrics"
+)
+
+// AllEndpoints selects the requests of every endpoint
+const AllEndpoints = "all"
+
+// TrendMetrics are the metrics shown in the history trends
+var TrendMetrics []string = []string{"rps", "error_rate", "p50", "p95", "p99"}
+
+var sparks []rune = []rune("▁▂▃▄▅▆▇█")
+
+// Value returns a metric of the run for the endpoint, false when the endpoint has no requests
+func Value(run *Run, endpoint, metric string) (float64, bool) {
+	summary := run.Summary
+	if summary == nil {
+		return 0, false
+	}
+
+	latency := metrics.NewHistogram()
+	var errors uint64
+	if endpoint == AllEndpoints {
+		for _, hist := range summary.Histograms {
+			latency.Merge(hist)
+		}
+		errors = summary.Errors
+	} else {
+		hist, ok := summary.Histograms[endpoint]
+		if !ok {
+			return 0, false
+		}
+		latency.Merge(hist)
+		for _, stats := range summary.Endpoints {
+			if stats.Endpoint == endpoint {
+				errors = stats.Errors
+			}
+		}
+	}
+	if latency.Count == 0 {
+		return 0, false
+	}
+
+	switch metric {
+	case "rps":
+		if summary.Seconds <= 0 {
+			return 0, false
+		}
+		return float64(latency.Count) / summary.Seconds, true
+	case "error_rate":
+		return float64(errors) / float64(latency.Count), true
+	case "p50":
+		return latency.Quantile(0.50), true
+	case "p95":
+		return latency.Quantile(0.95), true
+	case "p99":
+		return latency.Quantile(0.99), true
+	}
+	return 0, false
+}
+
+// Write prints the runs with their key metrics for the endpoint, followed by the trend of every metric
+func Write(w io.Writer, runs []*Run, endpoint string) error {
+	if len(runs) == 0 {
+		_, err := fmt.Fprintln(w, "No runs found")
+		return err
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "RUN ID\tSTARTED\tLABELS\tSESSIONS\tFAILED\tRPS\tERROR RATE\tP50\tP95\tP99")
+	for _, run := range runs {
+		sessions, failed := 0, 0
+		if run.Summary != nil {
+			sessions, failed = run.Summary.Sessions.Total, run.Summary.Sessions.Failed
+		}
+		values := []string{}
+		for _, metric := range TrendMetrics {
+			value, ok := Value(run, endpoint, metric)
+			if !ok {
+				values = append(values, "-")
+				continue
+			}
+			values = append(values, formatValue(metric, value))
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%d\t%d\t%s\n",
+			run.ID, run.StartedAt.Format("2006-01-02 15:04:05"), formatLabels(run.Labels), sessions, failed, strings.Join(values, "\t"))
+	}
+	if err := tw.Flush(); err != nil {
+		return fmt.Errorf("failed to write history: %w", err)
+	}
+
+	fmt.Fprintf(w, "\nTrends of %s over %d runs:\n", endpoint, len(runs))
+	tw = tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	for _, metric := range TrendMetrics {
+		values := []float64{}
+		for _, run := range runs {
+			if value, ok := Value(run, endpoint, metric); ok {
+				values = append(values, value)
+			}
+		}
+		if len(values) == 0 {
+			continue
+		}
+		first, last := values[0], values[len(values)-1]
+		change := ""
+		if first != 0 {
+			change = fmt.Sprintf("(%+.1f%%)", (last-first)/first*100)
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s -> %s %s\n", metric, sparkline(values), formatValue(metric, first), formatValue(metric, last), change)
+	}
+	if err := tw.Flush(); err != nil {
+		return fmt.Errorf("failed to write history: %w", err)
+	}
+	return nil
+}
+
+// sparkline draws the values between their min and max
+func sparkline(values []float64) string {
+	low, high := math.Inf(1), math.Inf(-1)
+	for _, v := range values {
+		low = min(low, v)
+		high = max(high, v)
+	}
+	var b strings.Builder
+	for _, v := range values {
+		index := 0
+		if high > low {
+			index = int((v - low) / (high - low) * float64(len(sparks)-1))
+		}
+		b.WriteRune(sparks[index])
+	}
+	return b.String()
+}
+
+func formatLabels(labels map[string]string) string {
+	if len(labels) == 0 {
+		return "-"
+	}
+	pairs := make([]string, 0, len(labels))
+	for key, value := range labels {
+		pairs = append(pairs, key+"="+value)
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, ",")
+}
+
+func formatValue(metric string, value float64) string {
+	switch metric {
+	case "error_rate":
+		return strconv.FormatFloat(value*100, 'f', 2, 64) + "%"
+	case "rps":
+		return strconv.FormatFloat(value, 'f', 2, 64) + "/s"
+	}
+	return strconv.FormatFloat(value, 'f', 1, 64) + "ms"
+}
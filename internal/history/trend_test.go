@@ -0,0 +1,70 @@
+package history
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/go-squad-5/quiz-load-test/internal/metrics"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func trendRun(id string, latency float64, errors uint64) *Run {
+	hist := metrics.NewHistogram()
+	for i := 0; i < 100; i++ {
+		hist.Record(latency)
+	}
+	return &Run{
+		ID:        id,
+		StartedAt: time.Now(),
+		Summary: &metrics.Summary{
+			Seconds:    10,
+			Sessions:   metrics.SessionCounts{Total: 20, Completed: 19, Failed: 1},
+			Errors:     errors,
+			Endpoints:  []metrics.EndpointStats{{Endpoint: "start_quiz", Count: 100, Errors: errors}},
+			Histograms: map[string]*metrics.Histogram{"start_quiz": hist},
+		},
+	}
+}
+
+func Test_history_trend_Value(t *testing.T) {
+	run := trendRun("run", 100, 5)
+
+	rps, ok := Value(run, AllEndpoints, "rps")
+	require.True(t, ok, "Expected the throughput of the run")
+	assert.InDelta(t, 10, rps, 0.001, "Expected the requests per second")
+
+	errorRate, ok := Value(run, "start_quiz", "error_rate")
+	require.True(t, ok, "Expected the error rate of the endpoint")
+	assert.InDelta(t, 0.05, errorRate, 0.001, "Expected the error rate of the endpoint")
+
+	p95, ok := Value(run, "start_quiz", "p95")
+	require.True(t, ok, "Expected the p95 of the endpoint")
+	assert.InDelta(t, 100, p95, 1, "Expected the p95 latency")
+
+	_, ok = Value(run, "get_report", "p95")
+	assert.False(t, ok, "Expected no value for an endpoint without requests")
+}
+
+func Test_history_trend_Sparkline(t *testing.T) {
+	assert.Equal(t, "▁▄█", sparkline([]float64{1, 2, 3}), "Expected the values scaled between min and max")
+	assert.Equal(t, "▁▁", sparkline([]float64{5, 5}), "Expected a flat line for constant values")
+}
+
+func Test_history_trend_Write(t *testing.T) {
+	var buf bytes.Buffer
+	runs := []*Run{trendRun("first", 100, 0), trendRun("second", 150, 10)}
+	runs[1].Labels = map[string]string{"env": "staging", "commit": "3f2a1c"}
+
+	require.NoError(t, Write(&buf, runs, AllEndpoints), "Expected the history to be written")
+	output := buf.String()
+	assert.Contains(t, output, "RUN ID", "Expected the table header")
+	assert.Contains(t, output, "commit=3f2a1c,env=staging", "Expected the sorted labels of the run")
+	assert.Contains(t, output, "Trends of all over 2 runs", "Expected the trends")
+	assert.Contains(t, output, "(+50.", "Expected the latency change between the first and last runs")
+
+	buf.Reset()
+	require.NoError(t, Write(&buf, []*Run{}, AllEndpoints), "Expected no error without runs")
+	assert.Contains(t, buf.String(), "No runs found", "Expected a message without runs")
+}
@@ -0,0 +1,227 @@
+package htmlreport
+
+import (
+	_ "embed"
+	"fmt"
+	"html/template"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-squad-5/quiz-load-test/internal/metrics"
+)
+
+//go:embed report.html.tmpl
+var reportTemplate string
+
+var tmpl = template.Must(template.New("report").Funcs(template.FuncMap{
+	"ms":          func(v float64) string { return strconv.FormatFloat(v, 'f', 1, 64) },
+	"percent":     func(v float64) string { return strconv.FormatFloat(v*100, 'f', 2, 64) + "%" },
+	"time":        func(t time.Time) string { return t.Format(time.RFC3339) },
+	"join":        strings.Join,
+	"statusCodes": formatStatusCodes,
+}).Parse(reportTemplate))
+
+var colors []string = []string{"#2980b9", "#e67e22", "#27ae60", "#8e44ad", "#c0392b", "#16a085", "#7f8c8d"}
+
+type scoreBar struct {
+	Score   int
+	Count   int
+	Percent float64
+}
+
+type topicScores struct {
+	metrics.TopicScores
+	Bars []scoreBar
+}
+
+// intendedLatency compares the latency of an endpoint measured from the send time and from the intended start
+type intendedLatency struct {
+	Sent     metrics.EndpointStats
+	Intended metrics.EndpointStats
+}
+
+type reportData struct {
+	Summary         *metrics.Summary
+	ConfigKeys      []string
+	LatencyChart    template.HTML
+	ThroughputChart template.HTML
+	Scores          []topicScores
+	IntendedLatency []intendedLatency
+}
+
+// Write renders the run summary as a self-contained html page
+func Write(w io.Writer, summary *metrics.Summary) error {
+	data := reportData{
+		Summary:         summary,
+		ConfigKeys:      sortedKeys(summary.Config),
+		LatencyChart:    latencyChart(summary.TimeSeries),
+		ThroughputChart: throughputChart(summary.TimeSeries),
+		Scores:          scoreBars(summary.Scores),
+		IntendedLatency: intendedLatencies(summary),
+	}
+	if err := tmpl.Execute(w, data); err != nil {
+		return fmt.Errorf("failed to render html report: %w", err)
+	}
+	return nil
+}
+
+// formatStatusCodes lists the status codes in ascending order with their count, e.g. "404: 3, 500: 1"
+func formatStatusCodes(codes map[int]int) string {
+	keys := make([]int, 0, len(codes))
+	for code := range codes {
+		keys = append(keys, code)
+	}
+	sort.Ints(keys)
+	counts := make([]string, len(keys))
+	for i, code := range keys {
+		counts[i] = fmt.Sprintf("%d: %d", code, codes[code])
+	}
+	return strings.Join(counts, ", ")
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func intendedLatencies(summary *metrics.Summary) []intendedLatency {
+	result := []intendedLatency{}
+	for _, intended := range summary.IntendedEndpoints {
+		for _, sent := range summary.Endpoints {
+			if sent.Endpoint == intended.Endpoint {
+				result = append(result, intendedLatency{Sent: sent, Intended: intended})
+			}
+		}
+	}
+	return result
+}
+
+func scoreBars(scores []metrics.TopicScores) []topicScores {
+	result := []topicScores{}
+	for _, ts := range scores {
+		bars := []scoreBar{}
+		for score, count := range ts.Distribution {
+			bars = append(bars, scoreBar{
+				Score:   score,
+				Count:   count,
+				Percent: float64(count) / float64(ts.Sessions) * 100,
+			})
+		}
+		sort.Slice(bars, func(i, j int) bool { return bars[i].Score < bars[j].Score })
+		result = append(result, topicScores{TopicScores: ts, Bars: bars})
+	}
+	return result
+}
+
+type series struct {
+	name   string
+	values []float64
+}
+
+// latencyChart plots the p95 latency of every endpoint over time
+func latencyChart(snapshots []metrics.Snapshot) template.HTML {
+	if len(snapshots) == 0 {
+		return ""
+	}
+	return lineChart("ms", snapshots, latencySeries(snapshots))
+}
+
+// latencySeries returns the p95 latency of every endpoint in the order the endpoints first appear, the endpoints are
+// matched by name as a snapshot only has the endpoints requested so far, the latency is zero in the snapshots without it
+func latencySeries(snapshots []metrics.Snapshot) []series {
+	all := []series{}
+	byEndpoint := map[string]int{}
+	for i, snapshot := range snapshots {
+		for _, stats := range snapshot.Endpoints {
+			index, ok := byEndpoint[stats.Endpoint]
+			if !ok {
+				index = len(all)
+				byEndpoint[stats.Endpoint] = index
+				all = append(all, series{name: stats.Endpoint + " p95", values: make([]float64, len(snapshots))})
+			}
+			all[index].values[i] = stats.P95
+		}
+	}
+	return all
+}
+
+// throughputChart plots the requests and errors per second over time
+func throughputChart(snapshots []metrics.Snapshot) template.HTML {
+	if len(snapshots) == 0 {
+		return ""
+	}
+	rps := series{name: "requests/s"}
+	errors := series{name: "errors/s"}
+	users := series{name: "active users"}
+	for _, snapshot := range snapshots {
+		rps.values = append(rps.values, snapshot.RPS)
+		errorRate := 0.0
+		if snapshot.Seconds > 0 {
+			errorRate = float64(snapshot.Errors) / snapshot.Seconds
+		}
+		errors.values = append(errors.values, errorRate)
+		users.values = append(users.values, float64(snapshot.ActiveUsers))
+	}
+	return lineChart("", snapshots, []series{rps, errors, users})
+}
+
+// lineChart renders the series as an inline svg, the x axis is the time elapsed since the first snapshot
+func lineChart(unit string, snapshots []metrics.Snapshot, all []series) template.HTML {
+	const width, height, left, right, top, bottom = 900.0, 280.0, 60.0, 20.0, 20.0, 40.0
+	plotWidth := width - left - right
+	plotHeight := height - top - bottom
+
+	maxValue := 0.0
+	for _, s := range all {
+		for _, v := range s.values {
+			if v > maxValue {
+				maxValue = v
+			}
+		}
+	}
+	if maxValue == 0 {
+		maxValue = 1
+	}
+
+	x := func(i int) float64 {
+		if len(snapshots) < 2 {
+			return left
+		}
+		return left + float64(i)/float64(len(snapshots)-1)*plotWidth
+	}
+	y := func(v float64) float64 {
+		return top + plotHeight - v/maxValue*plotHeight
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, `<svg viewBox="0 0 %.0f %.0f" width="%.0f" height="%.0f" xmlns="http://www.w3.org/2000/svg">`, width, height, width, height)
+	fmt.Fprintf(&b, `<line x1="%.0f" y1="%.0f" x2="%.0f" y2="%.0f" stroke="#999"/>`, left, top+plotHeight, left+plotWidth, top+plotHeight)
+	fmt.Fprintf(&b, `<line x1="%.0f" y1="%.0f" x2="%.0f" y2="%.0f" stroke="#999"/>`, left, top, left, top+plotHeight)
+	for _, fraction := range []float64{0, 0.5, 1} {
+		fmt.Fprintf(&b, `<text x="%.0f" y="%.1f" font-size="11" text-anchor="end">%s%s</text>`,
+			left-6, y(maxValue*fraction)+4, strconv.FormatFloat(maxValue*fraction, 'f', 1, 64), unit)
+	}
+	elapsed := snapshots[len(snapshots)-1].Time.Sub(snapshots[0].Time).Seconds() + snapshots[0].Seconds
+	fmt.Fprintf(&b, `<text x="%.0f" y="%.0f" font-size="11">0s</text>`, left, height-bottom+16)
+	fmt.Fprintf(&b, `<text x="%.0f" y="%.0f" font-size="11" text-anchor="end">%.0fs</text>`, left+plotWidth, height-bottom+16, elapsed)
+
+	for i, s := range all {
+		color := colors[i%len(colors)]
+		points := make([]string, 0, len(s.values))
+		for j, v := range s.values {
+			points = append(points, fmt.Sprintf("%.1f,%.1f", x(j), y(v)))
+		}
+		fmt.Fprintf(&b, `<polyline fill="none" stroke="%s" stroke-width="1.5" points="%s"/>`, color, strings.Join(points, " "))
+		fmt.Fprintf(&b, `<rect x="%.0f" y="%.0f" width="10" height="10" fill="%s"/>`, left+float64(i)*150, height-14, color)
+		fmt.Fprintf(&b, `<text x="%.0f" y="%.0f" font-size="11">%s</text>`, left+float64(i)*150+14, height-5, template.HTMLEscapeString(s.name))
+	}
+	b.WriteString(`</svg>`)
+	return template.HTML(b.String())
+}
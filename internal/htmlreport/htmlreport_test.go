@@ -0,0 +1,146 @@
+package htmlreport
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-squad-5/quiz-load-test/internal/metrics"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestSummary() *metrics.Summary {
+	collector := metrics.NewCollector("create_session", "start_quiz")
+	start := time.Now()
+	for i := range 3 {
+		collector.Observe(metrics.Observation{Endpoint: "create_session", Latency: time.Duration(100*(i+1)) * time.Millisecond})
+		collector.Observe(metrics.Observation{Endpoint: "start_quiz", Latency: 40 * time.Millisecond, Failed: i == 2})
+		collector.Flush(start.Add(time.Duration(i+1) * time.Second))
+	}
+
+	sessions := metrics.NewSessionStats()
+	sessions.Add(metrics.SessionResult{Status: "completed", Topic: "go", Score: 4})
+	sessions.Add(metrics.SessionResult{Status: "completed", Topic: "go", Score: 6})
+	sessions.Add(metrics.SessionResult{Status: "failed", Topic: "rust", FailedStep: "start_quiz", Error: "<script>status code: 500</script>"})
+
+	return metrics.NewSummary(start, start.Add(3*time.Second), map[string]string{"NUM_USERS": "3", "BASE_URL": "http://localhost:8080"}, collector, sessions)
+}
+
+func Test_htmlreport_Write(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, Write(&buf, newTestSummary()), "Expected the report to be rendered")
+	out := buf.String()
+
+	assert.True(t, strings.HasPrefix(out, "<!DOCTYPE html>"), "Expected an html document")
+	assert.Equal(t, 2, strings.Count(out, "<svg"), "Expected the latency and throughput charts")
+	assert.Contains(t, out, "create_session p95", "Expected the latency series of every endpoint")
+	assert.Contains(t, out, "<td>create_session</td><td>3</td><td>0</td>", "Expected the endpoint table")
+	assert.Contains(t, out, "<td>start_quiz</td>", "Expected the endpoint table")
+	assert.Contains(t, out, "&lt;script&gt;status code: 500&lt;/script&gt;", "Expected the error messages to be escaped")
+	assert.Contains(t, out, "<td>go</td><td>2</td><td>5.0</td><td>4</td><td>6</td>", "Expected the topic scores")
+	assert.Contains(t, out, "http://localhost:8080", "Expected the run configuration")
+	assert.NotContains(t, out, "<script>", "Expected the report to be a static page")
+	assert.NotContains(t, out, "Run aborted early", "Expected no abort reason for a completed run")
+	assert.NotContains(t, out, "Email delivery", "Expected no email delivery without the smtp sink")
+	assert.NotContains(t, out, "Negative scenarios", "Expected no negative scenarios without scenarios in the traffic")
+}
+
+func Test_htmlreport_LatencySeries(t *testing.T) {
+	snapshots := []metrics.Snapshot{
+		{Endpoints: []metrics.EndpointStats{{Endpoint: "create_session", P95: 100}}},
+		{Endpoints: []metrics.EndpointStats{{Endpoint: "start_quiz", P95: 40}, {Endpoint: "create_session", P95: 200}}},
+		{Endpoints: []metrics.EndpointStats{{Endpoint: "create_session", P95: 300}, {Endpoint: "negative_unknown_session", P95: 10}}},
+	}
+
+	all := latencySeries(snapshots)
+	require.Len(t, all, 3, "Expected a series for every endpoint of any snapshot")
+	assert.Equal(t, series{name: "create_session p95", values: []float64{100, 200, 300}}, all[0], "Expected the latency matched by endpoint name")
+	assert.Equal(t, series{name: "start_quiz p95", values: []float64{0, 40, 0}}, all[1], "Expected no latency in the snapshots without the endpoint")
+	assert.Equal(t, series{name: "negative_unknown_session p95", values: []float64{0, 0, 10}}, all[2], "Expected an endpoint first seen later to be plotted")
+}
+
+func Test_htmlreport_Write_WhenAborted(t *testing.T) {
+	summary := newTestSummary()
+	summary.AbortReason = "error_rate>20% for 30s, actual 35.00%"
+
+	var buf bytes.Buffer
+	require.NoError(t, Write(&buf, summary), "Expected the report to be rendered")
+	assert.Contains(t, buf.String(), "Run aborted early: error_rate&gt;20% for 30s, actual 35.00%", "Expected the abort reason")
+}
+
+func Test_htmlreport_Write_WithStages(t *testing.T) {
+	summary := newTestSummary()
+	summary.Stages = []metrics.StageStats{
+		{Name: "warmup", Seconds: 60, Requests: 10, Endpoints: []metrics.EndpointStats{{Endpoint: "create_session", P95: 12.5}}},
+		{Name: "spike", Seconds: 30, Requests: 20, Errors: 2, ErrorRate: 0.1, Endpoints: []metrics.EndpointStats{{Endpoint: "create_session", P95: 80}}},
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, Write(&buf, summary), "Expected the report to be rendered")
+	assert.Contains(t, buf.String(), "Load profile stages", "Expected the stages table")
+	assert.Contains(t, buf.String(), "<th>create_session p95 (ms)</th>", "Expected a p95 column per endpoint")
+	assert.Contains(t, buf.String(), "<td>spike</td>", "Expected a row per stage")
+	assert.Contains(t, buf.String(), "<td>10.00%</td><td>80.0</td>", "Expected the error rate and latency of the stage")
+}
+
+func Test_htmlreport_Write_WithIntendedLatency(t *testing.T) {
+	summary := newTestSummary()
+	endpoint := summary.Endpoints[0].Endpoint
+	summary.IntendedEndpoints = []metrics.EndpointStats{{Endpoint: endpoint, P50: 111.5, P95: 2222.5, P99: 3333.5, Max: 4444.5}}
+
+	var buf bytes.Buffer
+	require.NoError(t, Write(&buf, summary), "Expected the report to be rendered")
+	assert.Contains(t, buf.String(), "Latency from the intended start", "Expected the intended latency table")
+	assert.Contains(t, buf.String(), "<td>"+endpoint+"</td>", "Expected a row per endpoint")
+	assert.Contains(t, buf.String(), "<td>2222.5</td>", "Expected the p95 latency from the intended start")
+	assert.Contains(t, buf.String(), "<td>4444.5</td>", "Expected the max latency from the intended start")
+}
+
+func Test_htmlreport_Write_WhenNoTimeSeries(t *testing.T) {
+	summary := metrics.NewSummary(time.Now(), time.Now(), map[string]string{}, metrics.NewCollector(), metrics.NewSessionStats())
+
+	var buf bytes.Buffer
+	require.NoError(t, Write(&buf, summary), "Expected an empty summary to be rendered")
+	assert.Contains(t, buf.String(), "No time series recorded.", "Expected a message instead of the charts")
+	assert.Contains(t, buf.String(), "No session failed.", "Expected a message instead of the errors table")
+	assert.Contains(t, buf.String(), "No session completed.", "Expected a message instead of the scores table")
+	assert.NotContains(t, buf.String(), "Load profile stages", "Expected no stages table without a load profile")
+	assert.NotContains(t, buf.String(), "Latency from the intended start", "Expected no intended latency without an arrival rate")
+}
+
+func Test_htmlreport_Write_WithEmailDelivery(t *testing.T) {
+	summary := newTestSummary()
+	summary.EmailDelivery = metrics.NewEmailDeliveryStats([]time.Duration{1500 * time.Millisecond}, []string{"s2", "s3"}, 1)
+
+	var buf bytes.Buffer
+	require.NoError(t, Write(&buf, summary), "Expected the report to be rendered")
+	assert.Contains(t, buf.String(), "Email delivery", "Expected the email delivery table")
+	assert.Contains(t, buf.String(), "<td>3</td><td>1</td><td>2</td><td>1</td>", "Expected the expected, delivered, missing and unmatched emails")
+	assert.Contains(t, buf.String(), "Missing emails for the sessions: s2, s3", "Expected the sessions of the missing emails")
+}
+
+func Test_htmlreport_Write_WithNegativeScenarios(t *testing.T) {
+	summary := newTestSummary()
+	summary.NegativeScenarios = []metrics.NegativeScenarioStats{
+		{Scenario: "double_submit", Runs: 5, Passed: 3, Failed: 2, ServerErrors: 1, StatusCodes: map[int]int{409: 3, 200: 1, 500: 1}},
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, Write(&buf, summary), "Expected the report to be rendered")
+	assert.Contains(t, buf.String(), "Negative scenarios", "Expected the negative scenarios table")
+	assert.Contains(t, buf.String(), "<td>double_submit</td><td>5</td><td>3</td><td>2</td><td>1</td><td>200: 1, 409: 3, 500: 1</td>", "Expected the runs and the status codes in ascending order")
+}
+
+func Test_htmlreport_Write_WithAbandonedSessions(t *testing.T) {
+	summary := newTestSummary()
+	var buf bytes.Buffer
+	require.NoError(t, Write(&buf, summary), "Expected the report to be rendered")
+	assert.NotContains(t, buf.String(), "abandoned", "Expected no abandoned card without abandoned sessions")
+
+	summary.Sessions.Abandoned = 4
+	buf.Reset()
+	require.NoError(t, Write(&buf, summary), "Expected the report to be rendered")
+	assert.Contains(t, buf.String(), `<div class="value">4</div><div class="label">abandoned</div>`, "Expected the abandoned sessions card")
+}
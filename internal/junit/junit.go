@@ -0,0 +1,141 @@
+package junit
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+
+	"github.com/go-squad-5/quiz-load-test/internal/metrics"
+	"github.com/go-squad-5/quiz-load-test/internal/thresholds"
+)
+
+const (
+	suitesName     = "quiz-load-test"
+	thresholdSuite = "thresholds"
+	sessionSuite   = "sessions"
+)
+
+type TestSuites struct {
+	XMLName  xml.Name    `xml:"testsuites"`
+	Name     string      `xml:"name,attr"`
+	Tests    int         `xml:"tests,attr"`
+	Failures int         `xml:"failures,attr"`
+	Time     string      `xml:"time,attr"`
+	Suites   []TestSuite `xml:"testsuite"`
+}
+
+type TestSuite struct {
+	Name      string     `xml:"name,attr"`
+	Tests     int        `xml:"tests,attr"`
+	Failures  int        `xml:"failures,attr"`
+	Time      string     `xml:"time,attr"`
+	Timestamp string     `xml:"timestamp,attr"`
+	Cases     []TestCase `xml:"testcase"`
+}
+
+type TestCase struct {
+	Name      string   `xml:"name,attr"`
+	ClassName string   `xml:"classname,attr"`
+	Time      string   `xml:"time,attr"`
+	Failure   *Failure `xml:"failure,omitempty"`
+}
+
+type Failure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Text    string `xml:",chardata"`
+}
+
+// NewTestSuites builds a test case for every threshold check, and when includeSessions is set,
+// a test case for every failed session in a suite per failed step
+func NewTestSuites(summary *metrics.Summary, checks []thresholds.Check, includeSessions bool) TestSuites {
+	timestamp := summary.StartedAt.Format("2006-01-02T15:04:05")
+	suites := TestSuites{
+		Name:   suitesName,
+		Time:   seconds(summary.Seconds),
+		Suites: []TestSuite{},
+	}
+
+	suite := TestSuite{Name: thresholdSuite, Time: seconds(summary.Seconds), Timestamp: timestamp, Cases: []TestCase{}}
+	for _, check := range checks {
+		testCase := TestCase{Name: check.Threshold.String(), ClassName: thresholdSuite, Time: "0"}
+		if !check.Passed {
+			testCase.Failure = &Failure{Message: check.Message(), Type: "ThresholdFailed", Text: check.Message()}
+		}
+		suite.add(testCase)
+	}
+	suites.add(suite)
+
+	if includeSessions {
+		steps := map[string]*TestSuite{}
+		for _, failure := range summary.Failures {
+			name := sessionSuite + "." + failure.FailedStep
+			if _, ok := steps[name]; !ok {
+				steps[name] = &TestSuite{Name: name, Time: seconds(summary.Seconds), Timestamp: timestamp, Cases: []TestCase{}}
+			}
+			steps[name].add(TestCase{
+				Name:      sessionName(failure),
+				ClassName: name,
+				Time:      seconds(failure.Duration.Seconds()),
+				Failure: &Failure{
+					Message: failure.Error,
+					Type:    "SessionFailed",
+					Text:    fmt.Sprintf("session %s (%s, topic %s) failed at %s: %s", failure.SessionID, failure.Email, failure.Topic, failure.FailedStep, failure.Error),
+				},
+			})
+		}
+		names := make([]string, 0, len(steps))
+		for name := range steps {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			suites.add(*steps[name])
+		}
+	}
+
+	return suites
+}
+
+// Write writes the junit xml report of the run
+func Write(w io.Writer, summary *metrics.Summary, checks []thresholds.Check, includeSessions bool) error {
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return fmt.Errorf("failed to write junit report: %w", err)
+	}
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(NewTestSuites(summary, checks, includeSessions)); err != nil {
+		return fmt.Errorf("failed to write junit report: %w", err)
+	}
+	if _, err := io.WriteString(w, "\n"); err != nil {
+		return fmt.Errorf("failed to write junit report: %w", err)
+	}
+	return nil
+}
+
+func (s *TestSuite) add(testCase TestCase) {
+	s.Cases = append(s.Cases, testCase)
+	s.Tests++
+	if testCase.Failure != nil {
+		s.Failures++
+	}
+}
+
+func (s *TestSuites) add(suite TestSuite) {
+	s.Suites = append(s.Suites, suite)
+	s.Tests += suite.Tests
+	s.Failures += suite.Failures
+}
+
+func sessionName(failure metrics.SessionResult) string {
+	if failure.SessionID == "" {
+		return fmt.Sprintf("%s (%s)", failure.Email, failure.Topic)
+	}
+	return fmt.Sprintf("%s (%s, %s)", failure.SessionID, failure.Email, failure.Topic)
+}
+
+func seconds(s float64) string {
+	return strconv.FormatFloat(s, 'f', 3, 64)
+}
@@ -0,0 +1,76 @@
+package junit
+
+import (
+	"bytes"
+	"encoding/xml"
+	"testing"
+	"time"
+
+	"github.com/go-squad-5/quiz-load-test/internal/metrics"
+	"github.com/go-squad-5/quiz-load-test/internal/thresholds"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testChecks(t *testing.T) []thresholds.Check {
+	passed, err := thresholds.ParseThreshold("p95<500ms")
+	require.NoError(t, err, "Expected a valid threshold")
+	failed, err := thresholds.ParseThreshold("error_rate<1%")
+	require.NoError(t, err, "Expected a valid threshold")
+	return []thresholds.Check{
+		{Threshold: passed, Actual: 120, Passed: true},
+		{Threshold: failed, Actual: 0.05},
+	}
+}
+
+func testSummary() *metrics.Summary {
+	return &metrics.Summary{
+		StartedAt: time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC),
+		Seconds:   12.5,
+		Failures: []metrics.SessionResult{
+			{SessionID: "s1", Email: "a@example.com", Topic: "go", FailedStep: "submit_quiz", Error: "status code: 500", Duration: 1500 * time.Millisecond},
+			{Email: "b@example.com", Topic: "go", FailedStep: "create_session", Error: "connection refused"},
+			{SessionID: "s3", Email: "c@example.com", Topic: "c", FailedStep: "submit_quiz", Error: "timeout"},
+		},
+	}
+}
+
+func Test_junit_NewTestSuites(t *testing.T) {
+	suites := NewTestSuites(testSummary(), testChecks(t), false)
+
+	assert.Equal(t, 2, suites.Tests, "Expected a test case for every check")
+	assert.Equal(t, 1, suites.Failures, "Expected the failed check")
+	require.Len(t, suites.Suites, 1, "Expected only the thresholds suite without sessions")
+	assert.Equal(t, "thresholds", suites.Suites[0].Name, "Expected the thresholds suite")
+	assert.Nil(t, suites.Suites[0].Cases[0].Failure, "Expected no failure for the passed check")
+	require.NotNil(t, suites.Suites[0].Cases[1].Failure, "Expected a failure for the failed check")
+	assert.Contains(t, suites.Suites[0].Cases[1].Failure.Message, "actual 5.00%", "Expected the actual value in the failure")
+}
+
+func Test_junit_NewTestSuites_WithSessions(t *testing.T) {
+	suites := NewTestSuites(testSummary(), testChecks(t), true)
+
+	assert.Equal(t, 5, suites.Tests, "Expected test cases for the checks and the failed sessions")
+	assert.Equal(t, 4, suites.Failures, "Expected the failed check and sessions")
+	require.Len(t, suites.Suites, 3, "Expected a suite per failed step")
+	assert.Equal(t, "sessions.create_session", suites.Suites[1].Name, "Expected the session suites sorted by step")
+	assert.Equal(t, "sessions.submit_quiz", suites.Suites[2].Name, "Expected the session suites sorted by step")
+	assert.Equal(t, 2, suites.Suites[2].Failures, "Expected the failed sessions of the step")
+	assert.Equal(t, "s1 (a@example.com, go)", suites.Suites[2].Cases[0].Name, "Expected the session in the test case name")
+	assert.Equal(t, "1.500", suites.Suites[2].Cases[0].Time, "Expected the session duration")
+	assert.Equal(t, "b@example.com (go)", suites.Suites[1].Cases[0].Name, "Expected the email for sessions without id")
+}
+
+func Test_junit_Write(t *testing.T) {
+	var buf bytes.Buffer
+	err := Write(&buf, testSummary(), testChecks(t), true)
+	require.NoError(t, err, "Expected the junit report to be written")
+
+	assert.Contains(t, buf.String(), `<?xml version="1.0"`, "Expected the xml header")
+	assert.Contains(t, buf.String(), `<testcase name="error_rate&lt;1%" classname="thresholds" time="0">`, "Expected the escaped threshold name")
+
+	var parsed TestSuites
+	require.NoError(t, xml.Unmarshal(buf.Bytes(), &parsed), "Expected valid xml")
+	assert.Equal(t, 5, parsed.Tests, "Expected the test count attribute")
+	assert.Equal(t, "2025-01-02T03:04:05", parsed.Suites[0].Timestamp, "Expected the run start timestamp")
+}
@@ -0,0 +1,261 @@
+package loadprofile
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var stageRegexp = regexp.MustCompile(`^\s*(?:([A-Za-z0-9_.-]+)\s*=\s*)?([a-z]+)\(([^()]*)\)\s*(?:,|$)`)
+
+// Segment is a part of the profile during which the number of users changes linearly
+type Segment struct {
+	// label of the stage the segment belongs to
+	Stage    string
+	Start    time.Duration
+	Duration time.Duration
+	From     int
+	To       int
+}
+
+// Users returns the target number of users at the elapsed time since the start of the segment
+func (s Segment) Users(elapsed time.Duration) int {
+	if s.Duration <= 0 || s.From == s.To {
+		return s.To
+	}
+	fraction := min(max(float64(elapsed)/float64(s.Duration), 0), 1)
+	return s.From + int(math.Round(float64(s.To-s.From)*fraction))
+}
+
+// Profile is the number of concurrent users over time, built from labelled stages, e.g.
+// "warmup=ramp(20, 1m),step(50, 2m, 3),spike(10x, 30s),sawtooth(20, 100, 1m, 2)"
+type Profile struct {
+	Expression string
+	Segments   []Segment
+}
+
+func (p Profile) Empty() bool {
+	return len(p.Segments) == 0
+}
+
+func (p Profile) String() string {
+	return p.Expression
+}
+
+// Duration returns the total duration of the stages
+func (p Profile) Duration() time.Duration {
+	if p.Empty() {
+		return 0
+	}
+	last := p.Segments[len(p.Segments)-1]
+	return last.Start + last.Duration
+}
+
+// Peak returns the highest number of users of the profile
+func (p Profile) Peak() int {
+	peak := 0
+	for _, segment := range p.Segments {
+		peak = max(peak, segment.From, segment.To)
+	}
+	return peak
+}
+
+// At returns the target number of users and the stage at the elapsed time since the start of the run,
+// done is true once all the stages are over
+func (p Profile) At(elapsed time.Duration) (users int, stage string, done bool) {
+	for _, segment := range p.Segments {
+		if elapsed < segment.Start+segment.Duration {
+			return segment.Users(elapsed - segment.Start), segment.Stage, false
+		}
+	}
+	return 0, "", true
+}
+
+// Parse parses comma separated stages, each optionally labelled with "label=". The stages are:
+//   - ramp(users, duration): linear change from the current users to the given users
+//   - hold(duration) or hold(users, duration): keep the current or the given users
+//   - step(users, every, count): add the users every interval, count times
+//   - spike(users or factor, duration): jump to the users, e.g. 200, or multiply the current users, e.g. 10x, then back
+//   - sawtooth(low, high, period, count): ramp from low to high users over the period, then drop back, count times
+//
+// Unlabelled stages are labelled with their position and kind, e.g. "2-step"
+func Parse(expression string) (Profile, error) {
+	profile := Profile{Expression: strings.TrimSpace(expression), Segments: []Segment{}}
+	if profile.Expression == "" {
+		return profile, nil
+	}
+
+	b := &builder{profile: &profile}
+	rest := profile.Expression
+	for index := 1; strings.TrimSpace(rest) != ""; index++ {
+		matches := stageRegexp.FindStringSubmatch(rest)
+		if matches == nil {
+			return Profile{}, fmt.Errorf("invalid stage %q, expected kind(arguments)", strings.TrimSpace(rest))
+		}
+		rest = rest[len(matches[0]):]
+
+		label, kind := matches[1], matches[2]
+		if label == "" {
+			label = fmt.Sprintf("%d-%s", index, kind)
+		}
+		args := []string{}
+		for _, arg := range strings.Split(matches[3], ",") {
+			args = append(args, strings.TrimSpace(arg))
+		}
+		if err := b.add(label, kind, args); err != nil {
+			return Profile{}, fmt.Errorf("invalid stage %s(%s): %w", kind, matches[3], err)
+		}
+	}
+	return profile, nil
+}
+
+type builder struct {
+	profile *Profile
+	// users at the end of the last segment
+	users int
+	end   time.Duration
+}
+
+func (b *builder) segment(stage string, duration time.Duration, from, to int) {
+	b.profile.Segments = append(b.profile.Segments, Segment{
+		Stage:    stage,
+		Start:    b.end,
+		Duration: duration,
+		From:     from,
+		To:       to,
+	})
+	b.end += duration
+	b.users = to
+}
+
+func (b *builder) add(label, kind string, args []string) error {
+	switch kind {
+	case "ramp":
+		if len(args) != 2 {
+			return fmt.Errorf("expected ramp(users, duration)")
+		}
+		users, err := parseUsers(args[0])
+		if err != nil {
+			return err
+		}
+		duration, err := parseDuration(args[1])
+		if err != nil {
+			return err
+		}
+		b.segment(label, duration, b.users, users)
+	case "hold":
+		if len(args) != 1 && len(args) != 2 {
+			return fmt.Errorf("expected hold(duration) or hold(users, duration)")
+		}
+		users := b.users
+		if len(args) == 2 {
+			var err error
+			if users, err = parseUsers(args[0]); err != nil {
+				return err
+			}
+		}
+		duration, err := parseDuration(args[len(args)-1])
+		if err != nil {
+			return err
+		}
+		b.segment(label, duration, users, users)
+	case "step":
+		if len(args) != 3 {
+			return fmt.Errorf("expected step(users, every, count)")
+		}
+		users, err := parseUsers(args[0])
+		if err != nil {
+			return err
+		}
+		every, err := parseDuration(args[1])
+		if err != nil {
+			return err
+		}
+		count, err := parseCount(args[2])
+		if err != nil {
+			return err
+		}
+		for i := 1; i <= count; i++ {
+			next := b.users + users
+			b.segment(fmt.Sprintf("%s#%d", label, i), every, next, next)
+		}
+	case "spike":
+		if len(args) != 2 {
+			return fmt.Errorf("expected spike(users, duration)")
+		}
+		previous := b.users
+		users := 0
+		if factor, ok := strings.CutSuffix(args[0], "x"); ok {
+			value, err := strconv.ParseFloat(factor, 64)
+			if err != nil || value <= 0 {
+				return fmt.Errorf("invalid factor %q, must be a positive number", args[0])
+			}
+			users = int(math.Round(float64(previous) * value))
+		} else {
+			var err error
+			if users, err = parseUsers(args[0]); err != nil {
+				return err
+			}
+		}
+		duration, err := parseDuration(args[1])
+		if err != nil {
+			return err
+		}
+		b.segment(label, duration, users, users)
+		b.users = previous
+	case "sawtooth":
+		if len(args) != 4 {
+			return fmt.Errorf("expected sawtooth(low, high, period, count)")
+		}
+		low, err := parseUsers(args[0])
+		if err != nil {
+			return err
+		}
+		high, err := parseUsers(args[1])
+		if err != nil {
+			return err
+		}
+		period, err := parseDuration(args[2])
+		if err != nil {
+			return err
+		}
+		count, err := parseCount(args[3])
+		if err != nil {
+			return err
+		}
+		for i := 1; i <= count; i++ {
+			b.segment(fmt.Sprintf("%s#%d", label, i), period, low, high)
+		}
+		b.users = low
+	default:
+		return fmt.Errorf("unknown stage %q, expected ramp, hold, step, spike or sawtooth", kind)
+	}
+	return nil
+}
+
+func parseUsers(value string) (int, error) {
+	users, err := strconv.Atoi(value)
+	if err != nil || users < 0 {
+		return 0, fmt.Errorf("invalid users %q, must be a positive integer", value)
+	}
+	return users, nil
+}
+
+func parseCount(value string) (int, error) {
+	count, err := strconv.Atoi(value)
+	if err != nil || count <= 0 {
+		return 0, fmt.Errorf("invalid count %q, must be a positive integer", value)
+	}
+	return count, nil
+}
+
+func parseDuration(value string) (time.Duration, error) {
+	duration, err := time.ParseDuration(value)
+	if err != nil || duration <= 0 {
+		return 0, fmt.Errorf("invalid duration %q, must be positive, e.g. 30s or 2m", value)
+	}
+	return duration, nil
+}
@@ -0,0 +1,71 @@
+package loadprofile
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_loadprofile_Parse(t *testing.T) {
+	profile, err := Parse("warmup=ramp(20, 1m), step(50, 2m, 2),spike(10x, 30s),hold(1m),sawtooth(10, 100, 1m, 2)")
+	require.NoError(t, err, "Expected a valid profile")
+
+	expected := []Segment{
+		{Stage: "warmup", Start: 0, Duration: time.Minute, From: 0, To: 20},
+		{Stage: "2-step#1", Start: time.Minute, Duration: 2 * time.Minute, From: 70, To: 70},
+		{Stage: "2-step#2", Start: 3 * time.Minute, Duration: 2 * time.Minute, From: 120, To: 120},
+		{Stage: "3-spike", Start: 5 * time.Minute, Duration: 30 * time.Second, From: 1200, To: 1200},
+		{Stage: "4-hold", Start: 5*time.Minute + 30*time.Second, Duration: time.Minute, From: 120, To: 120},
+		{Stage: "5-sawtooth#1", Start: 6*time.Minute + 30*time.Second, Duration: time.Minute, From: 10, To: 100},
+		{Stage: "5-sawtooth#2", Start: 7*time.Minute + 30*time.Second, Duration: time.Minute, From: 10, To: 100},
+	}
+	assert.Equal(t, expected, profile.Segments, "Expected the segments of the stages")
+	assert.Equal(t, 8*time.Minute+30*time.Second, profile.Duration(), "Expected the total duration")
+	assert.Equal(t, 1200, profile.Peak(), "Expected the peak users")
+	assert.False(t, profile.Empty(), "Expected stages")
+
+	profile, err = Parse("")
+	require.NoError(t, err, "Expected no error without a profile")
+	assert.True(t, profile.Empty(), "Expected no stages")
+}
+
+func Test_loadprofile_Parse_WhenInvalid(t *testing.T) {
+	for _, expression := range []string{
+		"ramp(20)",
+		"ramp(-1, 1m)",
+		"ramp(20, soon)",
+		"step(10, 1m, 0)",
+		"spike(0x, 30s)",
+		"wave(10, 1m)",
+		"ramp(10, 1m) hold(1m)",
+		"ramp 10",
+	} {
+		_, err := Parse(expression)
+		assert.Errorf(t, err, "Expected %q to be invalid", expression)
+	}
+}
+
+func Test_loadprofile_Profile_At(t *testing.T) {
+	profile, err := Parse("ramp(10, 10s),spike(5x, 5s)")
+	require.NoError(t, err, "Expected a valid profile")
+
+	for _, tc := range []struct {
+		elapsed time.Duration
+		users   int
+		stage   string
+		done    bool
+	}{
+		{0, 0, "1-ramp", false},
+		{5 * time.Second, 5, "1-ramp", false},
+		{9 * time.Second, 9, "1-ramp", false},
+		{10 * time.Second, 50, "2-spike", false},
+		{15 * time.Second, 0, "", true},
+	} {
+		users, stage, done := profile.At(tc.elapsed)
+		assert.Equal(t, tc.users, users, "Expected the users at %s", tc.elapsed)
+		assert.Equal(t, tc.stage, stage, "Expected the stage at %s", tc.elapsed)
+		assert.Equal(t, tc.done, done, "Expected the profile to be done at %s", tc.elapsed)
+	}
+}
@@ -0,0 +1,588 @@
+package metrics
+
+import (
+	"maps"
+	"math"
+	"slices"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Observation is a single request observed during the simulation
+type Observation struct {
+	Time       time.Time
+	Endpoint   string
+	Topic      string
+	StatusCode int
+	Latency    time.Duration
+	Bytes      int64
+	Failed     bool
+}
+
+type EndpointStats struct {
+	Endpoint string  `json:"endpoint"`
+	Count    uint64  `json:"count"`
+	Errors   uint64  `json:"errors"`
+	RPS      float64 `json:"rps"`
+	Mean     float64 `json:"mean_ms"`
+	P50      float64 `json:"p50_ms"`
+	P90      float64 `json:"p90_ms"`
+	P95      float64 `json:"p95_ms"`
+	P99      float64 `json:"p99_ms"`
+	Max      float64 `json:"max_ms"`
+}
+
+func NewEndpointStats(endpoint string, latency *Histogram, errors uint64, seconds float64) EndpointStats {
+	stats := EndpointStats{
+		Endpoint: endpoint,
+		Count:    latency.Count,
+		Errors:   errors,
+		Mean:     latency.Mean(),
+		P50:      latency.Quantile(0.50),
+		P90:      latency.Quantile(0.90),
+		P95:      latency.Quantile(0.95),
+		P99:      latency.Quantile(0.99),
+		Max:      latency.Max,
+	}
+	if seconds > 0 {
+		stats.RPS = float64(latency.Count) / seconds
+	}
+	return stats
+}
+
+// Snapshot holds the metrics of a single collector interval
+type Snapshot struct {
+	Time        time.Time `json:"time"`
+	Seconds     float64   `json:"seconds"`
+	Requests    uint64    `json:"requests"`
+	Errors      uint64    `json:"errors"`
+	RPS         float64   `json:"rps"`
+	ActiveUsers int64     `json:"active_users"`
+	// load profile stage in progress at the end of the interval
+	Stage     string          `json:"stage,omitempty"`
+	Endpoints []EndpointStats `json:"endpoints"`
+}
+
+type endpointWindow struct {
+	latency *Histogram
+	errors  uint64
+}
+
+// EndpointTotals holds all the requests of an endpoint observed during the run
+type EndpointTotals struct {
+	Endpoint string     `json:"endpoint"`
+	Latency  *Histogram `json:"latency"`
+	Errors   uint64     `json:"errors"`
+}
+
+// StageStats holds the requests observed during a stage of the load profile
+type StageStats struct {
+	Name      string          `json:"name"`
+	StartedAt time.Time       `json:"started_at"`
+	Seconds   float64         `json:"seconds"`
+	Requests  uint64          `json:"requests"`
+	Errors    uint64          `json:"errors"`
+	RPS       float64         `json:"rps"`
+	ErrorRate float64         `json:"error_rate"`
+	Endpoints []EndpointStats `json:"endpoints"`
+}
+
+// IntervalTotals holds the requests of a collector interval, to merge the time series of the workers of a distributed run
+type IntervalTotals struct {
+	Time        time.Time        `json:"time"`
+	Seconds     float64          `json:"seconds"`
+	ActiveUsers int64            `json:"active_users"`
+	Stage       string           `json:"stage,omitempty"`
+	Endpoints   []EndpointTotals `json:"endpoints"`
+}
+
+// StageTotals holds the requests of a stage of the load profile, to merge the stages of the workers of a distributed run
+type StageTotals struct {
+	Name      string           `json:"name"`
+	StartedAt time.Time        `json:"started_at"`
+	EndedAt   time.Time        `json:"ended_at"`
+	Endpoints []EndpointTotals `json:"endpoints"`
+}
+
+type stageWindow struct {
+	name      string
+	startedAt time.Time
+	endedAt   time.Time
+	endpoints map[string]*endpointWindow
+}
+
+// Collector aggregates the observed requests into fixed intervals and
+// publishes a snapshot of every interval to its subscribers
+type Collector struct {
+	mu          sync.Mutex
+	endpoints   []string
+	windowStart time.Time
+	window      map[string]*endpointWindow
+	totals      map[string]*endpointWindow
+	// requests measured from their intended start, only in the open model
+	intended    map[string]*endpointWindow
+	stages      []*stageWindow
+	history     []Snapshot
+	latest      *Snapshot
+	subscribers map[chan Snapshot]struct{}
+	activeUsers atomic.Int64
+
+	// requests of every flushed interval, and of the intervals merged from other collectors by their index
+	intervals []IntervalTotals
+	merged    map[int]*IntervalTotals
+
+	startOnce sync.Once
+	stopOnce  sync.Once
+	stop      chan struct{}
+	done      chan struct{}
+}
+
+// NewCollector creates a collector, the endpoints are always reported in the given order
+func NewCollector(endpoints ...string) *Collector {
+	return &Collector{
+		endpoints:   endpoints,
+		windowStart: time.Now(),
+		window:      map[string]*endpointWindow{},
+		totals:      map[string]*endpointWindow{},
+		intended:    map[string]*endpointWindow{},
+		subscribers: map[chan Snapshot]struct{}{},
+		stop:        make(chan struct{}),
+		done:        make(chan struct{}),
+	}
+}
+
+func (c *Collector) Observe(o Observation) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	ms := float64(o.Latency.Microseconds()) / 1000
+	all := []map[string]*endpointWindow{c.window, c.totals}
+	if len(c.stages) > 0 {
+		all = append(all, c.stages[len(c.stages)-1].endpoints)
+	}
+	for _, windows := range all {
+		w, ok := windows[o.Endpoint]
+		if !ok {
+			w = &endpointWindow{latency: NewHistogram()}
+			windows[o.Endpoint] = w
+		}
+		w.latency.Record(ms)
+		if o.Failed {
+			w.errors++
+		}
+	}
+}
+
+// ObserveIntended records the latency of a request measured from its intended start,
+// the request itself is observed with Observe
+func (c *Collector) ObserveIntended(o Observation) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	w, ok := c.intended[o.Endpoint]
+	if !ok {
+		w = &endpointWindow{latency: NewHistogram()}
+		c.intended[o.Endpoint] = w
+	}
+	w.latency.Record(float64(o.Latency.Microseconds()) / 1000)
+	if o.Failed {
+		w.errors++
+	}
+}
+
+// SetStage attributes the requests observed from now on to the stage of the load profile
+func (c *Collector) SetStage(name string, now time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if len(c.stages) > 0 {
+		current := c.stages[len(c.stages)-1]
+		if current.name == name {
+			return
+		}
+		current.endedAt = now
+	}
+	c.stages = append(c.stages, &stageWindow{
+		name:      name,
+		startedAt: now,
+		endpoints: map[string]*endpointWindow{},
+	})
+}
+
+// Stages returns the requests observed during every stage, in the order the stages started.
+// The stage in progress ends at the given time.
+func (c *Collector) Stages(end time.Time) []StageStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	stages := []StageStats{}
+	for _, stage := range c.stages {
+		endedAt := stage.endedAt
+		if endedAt.IsZero() {
+			endedAt = end
+		}
+		stats := StageStats{
+			Name:      stage.name,
+			StartedAt: stage.startedAt,
+			Seconds:   endedAt.Sub(stage.startedAt).Seconds(),
+			Endpoints: []EndpointStats{},
+		}
+		for _, endpoint := range c.orderedEndpoints(stage.endpoints) {
+			w, ok := stage.endpoints[endpoint]
+			if !ok {
+				w = &endpointWindow{latency: NewHistogram()}
+			}
+			stats.Endpoints = append(stats.Endpoints, NewEndpointStats(endpoint, w.latency, w.errors, stats.Seconds))
+			stats.Requests += w.latency.Count
+			stats.Errors += w.errors
+		}
+		if stats.Seconds > 0 {
+			stats.RPS = float64(stats.Requests) / stats.Seconds
+		}
+		if stats.Requests > 0 {
+			stats.ErrorRate = float64(stats.Errors) / float64(stats.Requests)
+		}
+		stages = append(stages, stats)
+	}
+	return stages
+}
+
+func (c *Collector) UserStarted() {
+	c.activeUsers.Add(1)
+}
+
+func (c *Collector) UserFinished() {
+	c.activeUsers.Add(-1)
+}
+
+func (c *Collector) ActiveUsers() int64 {
+	return c.activeUsers.Load()
+}
+
+// Flush closes the current interval at the given time and returns its snapshot
+func (c *Collector) Flush(now time.Time) Snapshot {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	stage := ""
+	if len(c.stages) > 0 {
+		stage = c.stages[len(c.stages)-1].name
+	}
+	snapshot := c.snapshot(now, now.Sub(c.windowStart).Seconds(), c.activeUsers.Load(), stage, c.window)
+	c.intervals = append(c.intervals, IntervalTotals{
+		Time:        now,
+		Seconds:     snapshot.Seconds,
+		ActiveUsers: snapshot.ActiveUsers,
+		Stage:       stage,
+		Endpoints:   c.copyTotals(c.window),
+	})
+
+	c.window = map[string]*endpointWindow{}
+	c.windowStart = now
+	c.latest = &snapshot
+	c.history = append(c.history, snapshot)
+	return snapshot
+}
+
+// snapshot returns the metrics of the requests of an interval
+func (c *Collector) snapshot(now time.Time, seconds float64, activeUsers int64, stage string, windows map[string]*endpointWindow) Snapshot {
+	snapshot := Snapshot{
+		Time:        now,
+		Seconds:     seconds,
+		ActiveUsers: activeUsers,
+		Stage:       stage,
+		Endpoints:   []EndpointStats{},
+	}
+	for _, endpoint := range c.orderedEndpoints(windows) {
+		w, ok := windows[endpoint]
+		if !ok {
+			w = &endpointWindow{latency: NewHistogram()}
+		}
+		snapshot.Endpoints = append(snapshot.Endpoints, NewEndpointStats(endpoint, w.latency, w.errors, seconds))
+		snapshot.Requests += w.latency.Count
+		snapshot.Errors += w.errors
+	}
+	if seconds > 0 {
+		snapshot.RPS = float64(snapshot.Requests) / seconds
+	}
+	return snapshot
+}
+
+// orderedEndpoints returns the known endpoints followed by any other observed endpoint
+func (c *Collector) orderedEndpoints(windows map[string]*endpointWindow) []string {
+	others := []string{}
+	for endpoint := range windows {
+		if !slices.Contains(c.endpoints, endpoint) {
+			others = append(others, endpoint)
+		}
+	}
+	sort.Strings(others)
+	return append(append([]string{}, c.endpoints...), others...)
+}
+
+// History returns all the flushed snapshots, oldest first
+func (c *Collector) History() []Snapshot {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]Snapshot{}, c.history...)
+}
+
+// Totals returns a copy of the requests observed since the collector was created
+func (c *Collector) Totals() []EndpointTotals {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.copyTotals(c.totals)
+}
+
+// IntendedTotals returns a copy of the requests latency measured from their intended start,
+// empty when no request was scheduled
+func (c *Collector) IntendedTotals() []EndpointTotals {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.intended) == 0 {
+		return []EndpointTotals{}
+	}
+	return c.copyTotals(c.intended)
+}
+
+// Merge adds the requests observed by another collector, e.g. by the workers of a distributed run,
+// to the totals of the run. The time series and the stages are merged with MergeTimeSeries
+func (c *Collector) Merge(totals, intended []EndpointTotals) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	mergeTotals(c.totals, totals)
+	mergeTotals(c.intended, intended)
+}
+
+// Intervals returns a copy of the requests of every flushed interval, oldest first
+func (c *Collector) Intervals() []IntervalTotals {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]IntervalTotals{}, c.intervals...)
+}
+
+// StageTotals returns a copy of the requests of every stage, in the order the stages started.
+// The stage in progress ends at the given time.
+func (c *Collector) StageTotals(end time.Time) []StageTotals {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	stages := []StageTotals{}
+	for _, stage := range c.stages {
+		endedAt := stage.endedAt
+		if endedAt.IsZero() {
+			endedAt = end
+		}
+		stages = append(stages, StageTotals{
+			Name:      stage.name,
+			StartedAt: stage.startedAt,
+			EndedAt:   endedAt,
+			Endpoints: c.copyTotals(stage.endpoints),
+		})
+	}
+	return stages
+}
+
+// MergeTimeSeries adds the intervals and the stages observed by another collector, e.g. by a worker of a distributed run,
+// to the history and the stages of the collector. The collectors flush every interval from start: an interval is merged
+// with the intervals of the other collectors starting at the same time, and a stage with the stage of the other
+// collectors at the same position and of the same name
+func (c *Collector) MergeTimeSeries(start time.Time, interval time.Duration, intervals []IntervalTotals, stages []StageTotals) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.merged == nil {
+		c.merged = map[int]*IntervalTotals{}
+	}
+	for _, other := range intervals {
+		// the first interval of a worker starts before the run, while the worker waits for the start
+		otherStart := other.Time.Add(-time.Duration(other.Seconds * float64(time.Second)))
+		index := 0
+		if interval > 0 {
+			index = max(int(math.Round(float64(otherStart.Sub(start))/float64(interval))), 0)
+		}
+		merged, ok := c.merged[index]
+		if !ok {
+			merged = &IntervalTotals{Stage: other.Stage}
+			c.merged[index] = merged
+		}
+		if other.Time.After(merged.Time) {
+			merged.Time = other.Time
+		}
+		merged.Seconds = max(merged.Time.Sub(start.Add(time.Duration(index)*interval)).Seconds(), 0)
+		merged.ActiveUsers += other.ActiveUsers
+		merged.Endpoints = mergedTotals(merged.Endpoints, other.Endpoints)
+	}
+	indexes := slices.Sorted(maps.Keys(c.merged))
+	c.history = []Snapshot{}
+	for _, index := range indexes {
+		merged := c.merged[index]
+		windows := map[string]*endpointWindow{}
+		mergeTotals(windows, merged.Endpoints)
+		c.history = append(c.history, c.snapshot(merged.Time, merged.Seconds, merged.ActiveUsers, merged.Stage, windows))
+	}
+
+	for i, other := range stages {
+		var stage *stageWindow
+		if i < len(c.stages) && c.stages[i].name == other.Name {
+			stage = c.stages[i]
+		} else {
+			stage = &stageWindow{
+				name:      other.Name,
+				startedAt: other.StartedAt,
+				endedAt:   other.EndedAt,
+				endpoints: map[string]*endpointWindow{},
+			}
+			c.stages = append(c.stages, stage)
+		}
+		if other.StartedAt.Before(stage.startedAt) {
+			stage.startedAt = other.StartedAt
+		}
+		if other.EndedAt.After(stage.endedAt) {
+			stage.endedAt = other.EndedAt
+		}
+		mergeTotals(stage.endpoints, other.Endpoints)
+	}
+}
+
+// mergedTotals returns the totals with the requests of the other totals added, by endpoint
+func mergedTotals(totals, other []EndpointTotals) []EndpointTotals {
+	for _, o := range other {
+		i := slices.IndexFunc(totals, func(t EndpointTotals) bool { return t.Endpoint == o.Endpoint })
+		if i < 0 {
+			totals = append(totals, EndpointTotals{Endpoint: o.Endpoint, Latency: NewHistogram()})
+			i = len(totals) - 1
+		}
+		totals[i].Latency.Merge(o.Latency)
+		totals[i].Errors += o.Errors
+	}
+	return totals
+}
+
+func mergeTotals(windows map[string]*endpointWindow, totals []EndpointTotals) {
+	for _, other := range totals {
+		w, ok := windows[other.Endpoint]
+		if !ok {
+			w = &endpointWindow{latency: NewHistogram()}
+			windows[other.Endpoint] = w
+		}
+		w.latency.Merge(other.Latency)
+		w.errors += other.Errors
+	}
+}
+
+func (c *Collector) copyTotals(windows map[string]*endpointWindow) []EndpointTotals {
+	totals := []EndpointTotals{}
+	for _, endpoint := range c.orderedEndpoints(windows) {
+		latency := NewHistogram()
+		var errors uint64
+		if w, ok := windows[endpoint]; ok {
+			latency.Merge(w.latency)
+			errors = w.errors
+		}
+		totals = append(totals, EndpointTotals{
+			Endpoint: endpoint,
+			Latency:  latency,
+			Errors:   errors,
+		})
+	}
+	return totals
+}
+
+// Latest returns the last flushed snapshot
+func (c *Collector) Latest() (Snapshot, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.latest == nil {
+		return Snapshot{}, false
+	}
+	return *c.latest, true
+}
+
+// Subscribe returns a channel receiving every flushed snapshot, and a function to unsubscribe.
+// The channel is closed when the collector stops.
+func (c *Collector) Subscribe() (<-chan Snapshot, func()) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	ch := make(chan Snapshot, 16)
+	select {
+	case <-c.done:
+		close(ch)
+		return ch, func() {}
+	default:
+	}
+	c.subscribers[ch] = struct{}{}
+
+	return ch, func() {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		if _, ok := c.subscribers[ch]; ok {
+			delete(c.subscribers, ch)
+			close(ch)
+		}
+	}
+}
+
+func (c *Collector) publish(snapshot Snapshot) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for ch := range c.subscribers {
+		select {
+		case ch <- snapshot:
+		default:
+			// drop the snapshot for slow subscribers
+		}
+	}
+}
+
+// Start flushes and publishes a snapshot every interval until Stop is called
+func (c *Collector) Start(interval time.Duration) {
+	c.startOnce.Do(func() {
+		c.mu.Lock()
+		c.windowStart = time.Now()
+		c.mu.Unlock()
+		go c.run(interval)
+	})
+}
+
+func (c *Collector) run(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case now := <-ticker.C:
+			c.publish(c.Flush(now))
+		case <-c.stop:
+			c.publish(c.Flush(time.Now()))
+			c.closeSubscribers()
+			return
+		}
+	}
+}
+
+// Stop publishes the last interval and closes all the subscriptions
+func (c *Collector) Stop() {
+	c.stopOnce.Do(func() {
+		started := true
+		c.startOnce.Do(func() { started = false })
+		if started {
+			close(c.stop)
+			<-c.done
+		} else {
+			c.closeSubscribers()
+		}
+	})
+}
+
+func (c *Collector) closeSubscribers() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for ch := range c.subscribers {
+		delete(c.subscribers, ch)
+		close(ch)
+	}
+	close(c.done)
+}
@@ -0,0 +1,184 @@
+package metrics
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_metrics_collector_Flush(t *testing.T) {
+	c := NewCollector("create_session", "start_quiz")
+	start := c.windowStart
+
+	c.Observe(Observation{Endpoint: "create_session", Latency: 100 * time.Millisecond})
+	c.Observe(Observation{Endpoint: "create_session", Latency: 200 * time.Millisecond, Failed: true})
+	c.Observe(Observation{Endpoint: "get_report", Latency: 50 * time.Millisecond})
+	c.UserStarted()
+	c.UserStarted()
+	c.UserFinished()
+
+	snapshot := c.Flush(start.Add(2 * time.Second))
+
+	assert.Equal(t, uint64(3), snapshot.Requests, "Expected 3 requests in the interval")
+	assert.Equal(t, uint64(1), snapshot.Errors, "Expected 1 error in the interval")
+	assert.InDelta(t, 1.5, snapshot.RPS, 0.001, "Expected 1.5 requests per second")
+	assert.Equal(t, int64(1), snapshot.ActiveUsers, "Expected 1 active user")
+	assert.InDelta(t, 2, snapshot.Seconds, 0.001, "Expected a 2 second interval")
+
+	require.Len(t, snapshot.Endpoints, 3, "Expected known endpoints and observed endpoints")
+	assert.Equal(t, "create_session", snapshot.Endpoints[0].Endpoint, "Expected known endpoints first")
+	assert.Equal(t, "start_quiz", snapshot.Endpoints[1].Endpoint, "Expected known endpoints even without requests")
+	assert.Equal(t, "get_report", snapshot.Endpoints[2].Endpoint, "Expected observed endpoints after the known ones")
+	assert.Equal(t, uint64(2), snapshot.Endpoints[0].Count, "Expected 2 create_session requests")
+	assert.Equal(t, uint64(1), snapshot.Endpoints[0].Errors, "Expected 1 create_session error")
+	assert.InEpsilon(t, 200, snapshot.Endpoints[0].P99, 0.02, "Expected p99 close to 200ms")
+	assert.Equal(t, uint64(0), snapshot.Endpoints[1].Count, "Expected no start_quiz requests")
+
+	latest, ok := c.Latest()
+	require.True(t, ok, "Expected a latest snapshot after flushing")
+	assert.Equal(t, snapshot, latest, "Expected latest to be the flushed snapshot")
+
+	next := c.Flush(start.Add(3 * time.Second))
+	assert.Equal(t, uint64(0), next.Requests, "Expected the interval to be reset after flushing")
+}
+
+func Test_metrics_collector_Latest_WhenNotFlushed(t *testing.T) {
+	c := NewCollector()
+	_, ok := c.Latest()
+	assert.False(t, ok, "Expected no latest snapshot before flushing")
+}
+
+func Test_metrics_collector_Subscribe(t *testing.T) {
+	c := NewCollector("create_session")
+	snapshots, unsubscribe := c.Subscribe()
+	defer unsubscribe()
+
+	c.Start(10 * time.Millisecond)
+	c.Observe(Observation{Endpoint: "create_session", Latency: time.Millisecond})
+
+	select {
+	case snapshot := <-snapshots:
+		assert.Len(t, snapshot.Endpoints, 1, "Expected a published snapshot")
+	case <-time.After(time.Second):
+		t.Fatal("Expected a snapshot to be published within a second")
+	}
+
+	c.Stop()
+	for range snapshots {
+		// drain until the channel is closed by Stop
+	}
+}
+
+func Test_metrics_collector_Unsubscribe(t *testing.T) {
+	c := NewCollector()
+	snapshots, unsubscribe := c.Subscribe()
+	unsubscribe()
+	unsubscribe()
+
+	_, ok := <-snapshots
+	assert.False(t, ok, "Expected the channel to be closed after unsubscribing")
+	require.NotPanics(t, c.Stop, "Expected stop to not panic after unsubscribing")
+}
+
+func Test_metrics_collector_Stop_WhenNotStarted(t *testing.T) {
+	c := NewCollector()
+	snapshots, _ := c.Subscribe()
+
+	require.NotPanics(t, c.Stop, "Expected stop to not panic when the collector never started")
+	require.NotPanics(t, c.Stop, "Expected stop to be idempotent")
+
+	_, ok := <-snapshots
+	assert.False(t, ok, "Expected subscriptions to be closed on stop")
+
+	late, _ := c.Subscribe()
+	_, ok = <-late
+	assert.False(t, ok, "Expected subscriptions after stop to be closed")
+}
+
+func Test_metrics_collector_Stages(t *testing.T) {
+	c := NewCollector("create_session")
+	start := c.windowStart
+
+	c.Observe(Observation{Endpoint: "create_session", Latency: time.Millisecond})
+	c.SetStage("warmup", start)
+	c.Observe(Observation{Endpoint: "create_session", Latency: time.Millisecond})
+	c.Observe(Observation{Endpoint: "create_session", Latency: time.Millisecond, Failed: true})
+	c.SetStage("warmup", start.Add(time.Second))
+	assert.Equal(t, "warmup", c.Flush(start.Add(time.Second)).Stage, "Expected the stage of the interval")
+
+	c.SetStage("spike", start.Add(2*time.Second))
+	c.Observe(Observation{Endpoint: "create_session", Latency: time.Millisecond})
+
+	stages := c.Stages(start.Add(6 * time.Second))
+	require.Len(t, stages, 2, "Expected a stage per label change")
+	assert.Equal(t, "warmup", stages[0].Name, "Expected the stages in order")
+	assert.InDelta(t, 2, stages[0].Seconds, 0.001, "Expected the stage to end when the next one starts")
+	assert.Equal(t, uint64(2), stages[0].Requests, "Expected only the requests observed during the stage")
+	assert.Equal(t, uint64(1), stages[0].Errors, "Expected the errors of the stage")
+	assert.InDelta(t, 0.5, stages[0].ErrorRate, 0.001, "Expected the error rate of the stage")
+	assert.Equal(t, uint64(2), stages[0].Endpoints[0].Count, "Expected the endpoints of the stage")
+	assert.Equal(t, "spike", stages[1].Name, "Expected the stages in order")
+	assert.InDelta(t, 4, stages[1].Seconds, 0.001, "Expected the last stage to end at the given time")
+	assert.InDelta(t, 0.25, stages[1].RPS, 0.001, "Expected the requests per second of the stage")
+}
+
+func Test_metrics_collector_IntendedTotals(t *testing.T) {
+	c := NewCollector("create_session", "start_quiz")
+	assert.Empty(t, c.IntendedTotals(), "Expected no intended totals when no request was scheduled")
+
+	c.Observe(Observation{Endpoint: "create_session", Latency: 10 * time.Millisecond})
+	c.ObserveIntended(Observation{Endpoint: "create_session", Latency: 510 * time.Millisecond, Failed: true})
+
+	totals := c.IntendedTotals()
+	require.Len(t, totals, 2, "Expected the known endpoints")
+	assert.Equal(t, uint64(1), totals[0].Latency.Count, "Expected the intended request")
+	assert.Equal(t, uint64(1), totals[0].Errors, "Expected the intended error")
+	assert.InEpsilon(t, 510, totals[0].Latency.Max, 0.02, "Expected the latency from the intended start")
+	assert.InEpsilon(t, 10, c.Totals()[0].Latency.Max, 0.02, "Expected the observed latency to be kept apart")
+}
+
+func Test_metrics_collector_MergeTimeSeries(t *testing.T) {
+	start := time.Now()
+	workers := []*Collector{}
+	for i := range 2 {
+		c := NewCollector("create_session")
+		// the first interval of a worker starts while it waits for the start of the run
+		c.windowStart = start.Add(-time.Duration(i+1) * time.Second)
+		c.SetStage("warmup", start)
+		c.Observe(Observation{Endpoint: "create_session", Latency: 10 * time.Millisecond})
+		c.UserStarted()
+		c.Flush(start.Add(time.Second + time.Duration(i)*time.Millisecond))
+		c.SetStage("spike", start.Add(time.Second))
+		c.Observe(Observation{Endpoint: "create_session", Latency: time.Duration(100*(i+1)) * time.Millisecond, Failed: i == 1})
+		c.Flush(start.Add(2*time.Second + time.Duration(i)*time.Millisecond))
+		// the last interval is flushed when the worker stops
+		c.Observe(Observation{Endpoint: "negative_submit", Latency: time.Millisecond})
+		c.Flush(start.Add(2400 * time.Millisecond))
+		workers = append(workers, c)
+	}
+
+	c := NewCollector("create_session")
+	for _, worker := range workers {
+		c.MergeTimeSeries(start, time.Second, worker.Intervals(), worker.StageTotals(start.Add(2400*time.Millisecond)))
+	}
+
+	history := c.History()
+	require.Len(t, history, 3, "Expected the intervals of the workers merged by their start")
+	assert.Equal(t, []uint64{2, 2, 2}, []uint64{history[0].Requests, history[1].Requests, history[2].Requests}, "Expected the requests of every worker")
+	assert.InDelta(t, 1, history[0].Seconds, 0.01, "Expected the first interval to start with the run")
+	assert.InDelta(t, 0.4, history[2].Seconds, 0.01, "Expected the last interval to end with the last worker")
+	assert.InDelta(t, 2, history[0].RPS, 0.05, "Expected the throughput of every worker")
+	assert.Equal(t, int64(2), history[0].ActiveUsers, "Expected the active users of every worker")
+	assert.Equal(t, uint64(1), history[1].Errors, "Expected the errors of every worker")
+	assert.InEpsilon(t, 200, history[1].Endpoints[0].Max, 0.02, "Expected the latency of every worker")
+	assert.Equal(t, "negative_submit", history[2].Endpoints[1].Endpoint, "Expected the endpoints observed by the workers")
+
+	stages := c.Stages(start.Add(2400 * time.Millisecond))
+	require.Len(t, stages, 2, "Expected the stages of the workers merged by name")
+	assert.Equal(t, "warmup", stages[0].Name, "Expected the stages in order")
+	assert.Equal(t, uint64(2), stages[0].Requests, "Expected the requests of the stage on every worker")
+	assert.Equal(t, uint64(4), stages[1].Requests, "Expected the requests of the stage on every worker")
+	assert.InDelta(t, 1.4, stages[1].Seconds, 0.01, "Expected the stage to last until the last worker stopped")
+}
@@ -0,0 +1,138 @@
+package metrics
+
+import (
+	"math"
+	"sort"
+)
+
+// growth between two consecutive histogram buckets, bounds the relative error of quantiles to 1%
+const bucketGrowth = 1.02
+
+var logBucketGrowth = math.Log(bucketGrowth)
+
+// Histogram records latencies in milliseconds into logarithmic buckets.
+// It is not safe for concurrent use.
+type Histogram struct {
+	Buckets map[int]uint64 `json:"buckets"`
+	Count   uint64         `json:"count"`
+	Sum     float64        `json:"sum"`
+	SumSq   float64        `json:"sum_sq"`
+	Min     float64        `json:"min"`
+	Max     float64        `json:"max"`
+}
+
+func NewHistogram() *Histogram {
+	return &Histogram{
+		Buckets: map[int]uint64{},
+	}
+}
+
+func bucketIndex(ms float64) int {
+	if ms <= 1 {
+		return 0
+	}
+	return int(math.Ceil(math.Log(ms) / logBucketGrowth))
+}
+
+// bucketUpperBound returns the largest value (ms) that falls in the bucket
+func bucketUpperBound(index int) float64 {
+	if index <= 0 {
+		return 1
+	}
+	return math.Pow(bucketGrowth, float64(index))
+}
+
+func (h *Histogram) Record(ms float64) {
+	if ms < 0 {
+		ms = 0
+	}
+	if h.Count == 0 || ms < h.Min {
+		h.Min = ms
+	}
+	if ms > h.Max {
+		h.Max = ms
+	}
+	h.Buckets[bucketIndex(ms)]++
+	h.Count++
+	h.Sum += ms
+	h.SumSq += ms * ms
+}
+
+func (h *Histogram) Merge(other *Histogram) {
+	if other == nil || other.Count == 0 {
+		return
+	}
+	if h.Count == 0 || other.Min < h.Min {
+		h.Min = other.Min
+	}
+	if other.Max > h.Max {
+		h.Max = other.Max
+	}
+	for index, count := range other.Buckets {
+		h.Buckets[index] += count
+	}
+	h.Count += other.Count
+	h.Sum += other.Sum
+	h.SumSq += other.SumSq
+}
+
+func (h *Histogram) Mean() float64 {
+	if h.Count == 0 {
+		return 0
+	}
+	return h.Sum / float64(h.Count)
+}
+
+// StdDev returns the sample standard deviation of the recorded values
+func (h *Histogram) StdDev() float64 {
+	if h.Count < 2 {
+		return 0
+	}
+	n := float64(h.Count)
+	variance := (h.SumSq - h.Sum*h.Sum/n) / (n - 1)
+	if variance < 0 {
+		return 0
+	}
+	return math.Sqrt(variance)
+}
+
+// CountAbove returns the number of recorded values in the buckets above the bucket of the value (ms)
+func (h *Histogram) CountAbove(ms float64) uint64 {
+	limit := bucketIndex(ms)
+	var count uint64
+	for index, n := range h.Buckets {
+		if index > limit {
+			count += n
+		}
+	}
+	return count
+}
+
+// Quantile returns the value (ms) below which q (0-1) of the recorded values fall
+func (h *Histogram) Quantile(q float64) float64 {
+	if h.Count == 0 {
+		return 0
+	}
+	if q <= 0 {
+		return h.Min
+	}
+	if q >= 1 {
+		return h.Max
+	}
+
+	indexes := make([]int, 0, len(h.Buckets))
+	for index := range h.Buckets {
+		indexes = append(indexes, index)
+	}
+	sort.Ints(indexes)
+
+	rank := uint64(math.Ceil(q * float64(h.Count)))
+	var seen uint64
+	for _, index := range indexes {
+		seen += h.Buckets[index]
+		if seen >= rank {
+			return math.Max(h.Min, math.Min(h.Max, bucketUpperBound(index)))
+		}
+	}
+	return h.Max
+}
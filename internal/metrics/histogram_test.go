@@ -0,0 +1,103 @@
+package metrics
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_metrics_histogram_Record(t *testing.T) {
+	h := NewHistogram()
+	for i := 1; i <= 100; i++ {
+		h.Record(float64(i))
+	}
+
+	assert.Equal(t, uint64(100), h.Count, "Expected 100 recorded values")
+	assert.Equal(t, float64(1), h.Min, "Expected min to be 1")
+	assert.Equal(t, float64(100), h.Max, "Expected max to be 100")
+	assert.InDelta(t, 50.5, h.Mean(), 0.001, "Expected mean to be 50.5")
+	assert.InDelta(t, 29.01, h.StdDev(), 0.01, "Expected sample standard deviation of 1..100")
+}
+
+func Test_metrics_histogram_Quantile(t *testing.T) {
+	h := NewHistogram()
+	for i := 1; i <= 1000; i++ {
+		h.Record(float64(i))
+	}
+
+	tests := []struct {
+		q        float64
+		expected float64
+	}{
+		{0.50, 500},
+		{0.90, 900},
+		{0.95, 950},
+		{0.99, 990},
+	}
+	for _, tt := range tests {
+		actual := h.Quantile(tt.q)
+		assert.InEpsilonf(t, tt.expected, actual, 0.02, "Expected quantile %v to be within 2%% of %v, got %v", tt.q, tt.expected, actual)
+	}
+	assert.Equal(t, float64(1), h.Quantile(0), "Expected quantile 0 to be the min")
+	assert.Equal(t, float64(1000), h.Quantile(1), "Expected quantile 1 to be the max")
+}
+
+func Test_metrics_histogram_Quantile_WhenEmpty(t *testing.T) {
+	h := NewHistogram()
+	assert.Equal(t, float64(0), h.Quantile(0.5), "Expected 0 for an empty histogram")
+	assert.Equal(t, float64(0), h.Mean(), "Expected mean 0 for an empty histogram")
+	assert.Equal(t, float64(0), h.StdDev(), "Expected stddev 0 for an empty histogram")
+}
+
+func Test_metrics_histogram_Quantile_WhenSubMillisecond(t *testing.T) {
+	h := NewHistogram()
+	h.Record(0.2)
+	h.Record(0.4)
+	assert.Equal(t, 0.4, h.Quantile(0.99), "Expected quantile to be clamped to the max value")
+}
+
+func Test_metrics_histogram_CountAbove(t *testing.T) {
+	h := NewHistogram()
+	for i := 1; i <= 100; i++ {
+		h.Record(float64(i))
+	}
+	// the values in the bucket of 90 are not counted, at the 2% resolution of the buckets
+	assert.InDelta(t, 10, h.CountAbove(90), 1, "Expected the values above 90")
+	assert.Equal(t, uint64(0), h.CountAbove(100), "Expected no value above the max")
+	assert.Equal(t, uint64(99), h.CountAbove(0.5), "Expected every value above the first bucket")
+}
+
+func Test_metrics_histogram_Merge(t *testing.T) {
+	a := NewHistogram()
+	b := NewHistogram()
+	for i := 1; i <= 50; i++ {
+		a.Record(float64(i))
+	}
+	for i := 51; i <= 100; i++ {
+		b.Record(float64(i))
+	}
+
+	a.Merge(b)
+	a.Merge(nil)
+
+	assert.Equal(t, uint64(100), a.Count, "Expected merged count to be 100")
+	assert.Equal(t, float64(1), a.Min, "Expected merged min to be 1")
+	assert.Equal(t, float64(100), a.Max, "Expected merged max to be 100")
+	assert.InDelta(t, 50.5, a.Mean(), 0.001, "Expected merged mean to be 50.5")
+	assert.InEpsilon(t, 95, a.Quantile(0.95), 0.02, "Expected merged p95 to be close to 95")
+}
+
+func Test_metrics_histogram_JSON(t *testing.T) {
+	h := NewHistogram()
+	h.Record(10)
+	h.Record(250)
+
+	data, err := json.Marshal(h)
+	require.NoError(t, err, "Expected histogram to be marshalled")
+
+	decoded := NewHistogram()
+	require.NoError(t, json.Unmarshal(data, decoded), "Expected histogram to be unmarshalled")
+	assert.Equal(t, h, decoded, "Expected histogram to survive a json round trip")
+}
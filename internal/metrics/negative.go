@@ -0,0 +1,93 @@
+package metrics
+
+import (
+	"sort"
+	"sync"
+)
+
+// NegativeScenarioStats describes the responses of the server to a deliberate misuse of the quiz api
+type NegativeScenarioStats struct {
+	Scenario string `json:"scenario"`
+	Runs     int    `json:"runs"`
+	// rejected with an expected 4xx status code
+	Passed int `json:"passed"`
+	// accepted or rejected with another status code
+	Failed int `json:"failed"`
+	// failed runs without a response or with a 5xx status code
+	ServerErrors int `json:"server_errors"`
+	// runs by status code of the misuse request, 0 when no response was received
+	StatusCodes map[int]int `json:"status_codes"`
+}
+
+// NegativeStats aggregates the negative scenarios run during the simulation
+type NegativeStats struct {
+	mu        sync.Mutex
+	scenarios map[string]*NegativeScenarioStats
+}
+
+func NewNegativeStats() *NegativeStats {
+	return &NegativeStats{scenarios: map[string]*NegativeScenarioStats{}}
+}
+
+// Add records a run of the scenario, serverError tells whether the server failed to handle the misuse
+func (s *NegativeStats) Add(scenario string, statusCode int, passed, serverError bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stats, ok := s.scenarios[scenario]
+	if !ok {
+		stats = &NegativeScenarioStats{Scenario: scenario, StatusCodes: map[int]int{}}
+		s.scenarios[scenario] = stats
+	}
+	stats.Runs++
+	stats.StatusCodes[statusCode]++
+	if passed {
+		stats.Passed++
+		return
+	}
+	stats.Failed++
+	if serverError {
+		stats.ServerErrors++
+	}
+}
+
+// Merge adds the runs of the scenarios observed elsewhere, e.g. by the workers of a distributed run
+func (s *NegativeStats) Merge(scenarios []NegativeScenarioStats) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, other := range scenarios {
+		stats, ok := s.scenarios[other.Scenario]
+		if !ok {
+			stats = &NegativeScenarioStats{Scenario: other.Scenario, StatusCodes: map[int]int{}}
+			s.scenarios[other.Scenario] = stats
+		}
+		stats.Runs += other.Runs
+		stats.Passed += other.Passed
+		stats.Failed += other.Failed
+		stats.ServerErrors += other.ServerErrors
+		for code, count := range other.StatusCodes {
+			stats.StatusCodes[code] += count
+		}
+	}
+}
+
+// Scenarios returns the stats of the scenarios which were run, by scenario name
+func (s *NegativeStats) Scenarios() []NegativeScenarioStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	scenarios := []NegativeScenarioStats{}
+	for _, stats := range s.scenarios {
+		scenario := *stats
+		scenario.StatusCodes = map[int]int{}
+		for code, count := range stats.StatusCodes {
+			scenario.StatusCodes[code] = count
+		}
+		scenarios = append(scenarios, scenario)
+	}
+	sort.Slice(scenarios, func(i, j int) bool {
+		return scenarios[i].Scenario < scenarios[j].Scenario
+	})
+	return scenarios
+}
@@ -0,0 +1,37 @@
+package metrics
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_metrics_negative_NegativeStats(t *testing.T) {
+	stats := NewNegativeStats()
+	stats.Add("unknown_session", 404, true, false)
+	stats.Add("double_submit", 409, true, false)
+	stats.Add("double_submit", 200, false, false)
+	stats.Add("double_submit", 500, false, true)
+	stats.Add("double_submit", 409, true, false)
+
+	assert.Equal(t, []NegativeScenarioStats{
+		{Scenario: "double_submit", Runs: 4, Passed: 2, Failed: 2, ServerErrors: 1, StatusCodes: map[int]int{200: 1, 409: 2, 500: 1}},
+		{Scenario: "unknown_session", Runs: 1, Passed: 1, StatusCodes: map[int]int{404: 1}},
+	}, stats.Scenarios(), "Expected the runs by scenario name")
+	assert.Empty(t, NewNegativeStats().Scenarios(), "Expected no scenarios before any run")
+}
+
+func Test_metrics_negative_NegativeStats_Merge(t *testing.T) {
+	stats := NewNegativeStats()
+	stats.Add("double_submit", 409, true, false)
+
+	other := NewNegativeStats()
+	other.Add("double_submit", 500, false, true)
+	other.Add("unknown_session", 404, true, false)
+	stats.Merge(other.Scenarios())
+
+	assert.Equal(t, []NegativeScenarioStats{
+		{Scenario: "double_submit", Runs: 2, Passed: 1, Failed: 1, ServerErrors: 1, StatusCodes: map[int]int{409: 1, 500: 1}},
+		{Scenario: "unknown_session", Runs: 1, Passed: 1, StatusCodes: map[int]int{404: 1}},
+	}, stats.Scenarios(), "Expected the runs of both stats by scenario name")
+}
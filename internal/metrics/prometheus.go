@@ -0,0 +1,168 @@
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// upper bounds (seconds) of the request duration histogram buckets
+var durationBuckets []float64 = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60}
+
+type requestLabels struct {
+	endpoint string
+	status   string
+	topic    string
+}
+
+type requestSeries struct {
+	count   uint64
+	bytes   int64
+	sum     float64
+	buckets []uint64
+}
+
+// PrometheusExporter exposes the observed requests in the prometheus text format
+type PrometheusExporter struct {
+	mu          sync.Mutex
+	requests    map[requestLabels]*requestSeries
+	sessions    map[string]uint64
+	activeUsers func() int64
+}
+
+func NewPrometheusExporter(activeUsers func() int64) *PrometheusExporter {
+	return &PrometheusExporter{
+		requests:    map[requestLabels]*requestSeries{},
+		sessions:    map[string]uint64{},
+		activeUsers: activeUsers,
+	}
+}
+
+func (p *PrometheusExporter) Observe(o Observation) {
+	labels := requestLabels{
+		endpoint: o.Endpoint,
+		status:   statusLabel(o.StatusCode),
+		topic:    o.Topic,
+	}
+	seconds := o.Latency.Seconds()
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	series, ok := p.requests[labels]
+	if !ok {
+		series = &requestSeries{buckets: make([]uint64, len(durationBuckets))}
+		p.requests[labels] = series
+	}
+	series.count++
+	series.bytes += o.Bytes
+	series.sum += seconds
+	for i, bound := range durationBuckets {
+		if seconds <= bound {
+			series.buckets[i]++
+		}
+	}
+}
+
+// ObserveSession counts a finished session by its final status
+func (p *PrometheusExporter) ObserveSession(status string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.sessions[status]++
+}
+
+// statusLabel returns the status code label, requests without a response are labelled "error"
+func statusLabel(statusCode int) string {
+	if statusCode == 0 {
+		return "error"
+	}
+	return strconv.Itoa(statusCode)
+}
+
+func (p *PrometheusExporter) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	p.WriteTo(w)
+}
+
+// WriteTo writes all the metrics in the prometheus text exposition format
+func (p *PrometheusExporter) WriteTo(w io.Writer) (int64, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	var b strings.Builder
+
+	labels := make([]requestLabels, 0, len(p.requests))
+	for l := range p.requests {
+		labels = append(labels, l)
+	}
+	sort.Slice(labels, func(i, j int) bool {
+		if labels[i].endpoint != labels[j].endpoint {
+			return labels[i].endpoint < labels[j].endpoint
+		}
+		if labels[i].status != labels[j].status {
+			return labels[i].status < labels[j].status
+		}
+		return labels[i].topic < labels[j].topic
+	})
+
+	b.WriteString("# HELP quiz_loadtest_requests_total Total number of requests sent to the quiz api.\n")
+	b.WriteString("# TYPE quiz_loadtest_requests_total counter\n")
+	for _, l := range labels {
+		fmt.Fprintf(&b, "quiz_loadtest_requests_total{%s} %d\n", l.format(), p.requests[l].count)
+	}
+
+	b.WriteString("# HELP quiz_loadtest_response_bytes_total Total number of response bytes received from the quiz api.\n")
+	b.WriteString("# TYPE quiz_loadtest_response_bytes_total counter\n")
+	for _, l := range labels {
+		fmt.Fprintf(&b, "quiz_loadtest_response_bytes_total{%s} %d\n", l.format(), p.requests[l].bytes)
+	}
+
+	b.WriteString("# HELP quiz_loadtest_request_duration_seconds Client observed latency of the quiz api requests.\n")
+	b.WriteString("# TYPE quiz_loadtest_request_duration_seconds histogram\n")
+	for _, l := range labels {
+		series := p.requests[l]
+		for i, bound := range durationBuckets {
+			fmt.Fprintf(&b, "quiz_loadtest_request_duration_seconds_bucket{%s,le=\"%s\"} %d\n",
+				l.format(), strconv.FormatFloat(bound, 'g', -1, 64), series.buckets[i])
+		}
+		fmt.Fprintf(&b, "quiz_loadtest_request_duration_seconds_bucket{%s,le=\"+Inf\"} %d\n", l.format(), series.count)
+		fmt.Fprintf(&b, "quiz_loadtest_request_duration_seconds_sum{%s} %s\n", l.format(), strconv.FormatFloat(series.sum, 'g', -1, 64))
+		fmt.Fprintf(&b, "quiz_loadtest_request_duration_seconds_count{%s} %d\n", l.format(), series.count)
+	}
+
+	statuses := make([]string, 0, len(p.sessions))
+	for status := range p.sessions {
+		statuses = append(statuses, status)
+	}
+	sort.Strings(statuses)
+
+	b.WriteString("# HELP quiz_loadtest_sessions_total Total number of finished sessions by status.\n")
+	b.WriteString("# TYPE quiz_loadtest_sessions_total counter\n")
+	for _, status := range statuses {
+		fmt.Fprintf(&b, "quiz_loadtest_sessions_total{status=\"%s\"} %d\n", escapeLabelValue(status), p.sessions[status])
+	}
+
+	if p.activeUsers != nil {
+		b.WriteString("# HELP quiz_loadtest_active_users Number of users currently simulated.\n")
+		b.WriteString("# TYPE quiz_loadtest_active_users gauge\n")
+		fmt.Fprintf(&b, "quiz_loadtest_active_users %d\n", p.activeUsers())
+	}
+
+	n, err := io.WriteString(w, b.String())
+	return int64(n), err
+}
+
+func (l requestLabels) format() string {
+	return fmt.Sprintf("endpoint=\"%s\",status=\"%s\",topic=\"%s\"",
+		escapeLabelValue(l.endpoint), escapeLabelValue(l.status), escapeLabelValue(l.topic))
+}
+
+var labelValueReplacer = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func escapeLabelValue(value string) string {
+	return labelValueReplacer.Replace(value)
+}
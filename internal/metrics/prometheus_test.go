@@ -0,0 +1,81 @@
+package metrics
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_metrics_prometheus_WriteTo(t *testing.T) {
+	p := NewPrometheusExporter(func() int64 { return 7 })
+
+	p.Observe(Observation{Endpoint: "start_quiz", StatusCode: 200, Topic: "go", Latency: 30 * time.Millisecond, Bytes: 120})
+	p.Observe(Observation{Endpoint: "start_quiz", StatusCode: 200, Topic: "go", Latency: 700 * time.Millisecond, Bytes: 80})
+	p.Observe(Observation{Endpoint: "submit_quiz", StatusCode: 0, Topic: "rust", Latency: time.Second, Failed: true})
+	p.ObserveSession("completed")
+	p.ObserveSession("completed")
+	p.ObserveSession("failed")
+
+	var buf bytes.Buffer
+	_, err := p.WriteTo(&buf)
+	require.NoError(t, err, "Expected the metrics to be written")
+	out := buf.String()
+
+	expected := []string{
+		"# TYPE quiz_loadtest_requests_total counter",
+		`quiz_loadtest_requests_total{endpoint="start_quiz",status="200",topic="go"} 2`,
+		`quiz_loadtest_requests_total{endpoint="submit_quiz",status="error",topic="rust"} 1`,
+		`quiz_loadtest_response_bytes_total{endpoint="start_quiz",status="200",topic="go"} 200`,
+		"# TYPE quiz_loadtest_request_duration_seconds histogram",
+		`quiz_loadtest_request_duration_seconds_bucket{endpoint="start_quiz",status="200",topic="go",le="0.05"} 1`,
+		`quiz_loadtest_request_duration_seconds_bucket{endpoint="start_quiz",status="200",topic="go",le="1"} 2`,
+		`quiz_loadtest_request_duration_seconds_bucket{endpoint="start_quiz",status="200",topic="go",le="+Inf"} 2`,
+		`quiz_loadtest_request_duration_seconds_sum{endpoint="start_quiz",status="200",topic="go"} 0.73`,
+		`quiz_loadtest_request_duration_seconds_count{endpoint="start_quiz",status="200",topic="go"} 2`,
+		`quiz_loadtest_sessions_total{status="completed"} 2`,
+		`quiz_loadtest_sessions_total{status="failed"} 1`,
+		"# TYPE quiz_loadtest_active_users gauge",
+		"quiz_loadtest_active_users 7",
+	}
+	for _, line := range expected {
+		assert.Containsf(t, out, line+"\n", "Expected the exposition to contain the line: %s", line)
+	}
+
+	// every sample line is "name{labels} value" or "name value"
+	for _, line := range strings.Split(strings.TrimSpace(out), "\n") {
+		if strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Fields(line)
+		assert.Lenf(t, fields, 2, "Expected a metric name and a value in line: %s", line)
+	}
+}
+
+func Test_metrics_prometheus_EscapeLabelValue(t *testing.T) {
+	assert.Equal(t, `c\"\\\n`, escapeLabelValue("c\"\\\n"), "Expected quotes, backslashes and newlines to be escaped")
+}
+
+func Test_metrics_prometheus_ServeHTTP(t *testing.T) {
+	p := NewPrometheusExporter(nil)
+	p.Observe(Observation{Endpoint: "get_report", StatusCode: 404, Latency: time.Millisecond})
+
+	server := httptest.NewServer(p)
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "/metrics")
+	require.NoError(t, err, "Expected no error while scraping the metrics")
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err, "Expected to read the metrics")
+	assert.Contains(t, resp.Header.Get("Content-Type"), "text/plain; version=0.0.4", "Expected the prometheus text content type")
+	assert.Contains(t, string(body), `quiz_loadtest_requests_total{endpoint="get_report",status="404",topic=""} 1`, "Expected the observed request")
+	assert.NotContains(t, string(body), "quiz_loadtest_active_users", "Expected no active users gauge without a source")
+}
@@ -0,0 +1,305 @@
+package metrics
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// SessionResult is the outcome of a single simulated session
+type SessionResult struct {
+	SessionID  string        `json:"session_id"`
+	Email      string        `json:"email"`
+	Status     string        `json:"status"`
+	Topic      string        `json:"topic"`
+	Score      int           `json:"score"`
+	Duration   time.Duration `json:"duration"`
+	FailedStep string        `json:"failed_step,omitempty"`
+	Error      string        `json:"error,omitempty"`
+	Behaviour  string        `json:"behaviour,omitempty"`
+}
+
+// StatusAbandoned is the status of the sessions abandoned after starting the quiz, they are not scored
+const StatusAbandoned = "abandoned"
+
+type ErrorCount struct {
+	Step    string `json:"step"`
+	Message string `json:"message"`
+	Count   int    `json:"count"`
+}
+
+type TopicScores struct {
+	Topic        string      `json:"topic"`
+	Sessions     int         `json:"sessions"`
+	Mean         float64     `json:"mean"`
+	Min          int         `json:"min"`
+	Max          int         `json:"max"`
+	Distribution map[int]int `json:"distribution"`
+}
+
+type SessionCounts struct {
+	Total     int `json:"total"`
+	Completed int `json:"completed"`
+	Failed    int `json:"failed"`
+	// sessions left after starting the quiz by their behaviour profile, neither completed nor failed
+	Abandoned int `json:"abandoned,omitempty"`
+}
+
+type errorKey struct {
+	step    string
+	message string
+}
+
+// SessionStats aggregates the finished sessions of a run
+type SessionStats struct {
+	mu       sync.Mutex
+	counts   SessionCounts
+	errors   map[errorKey]int
+	scores   map[string]map[int]int
+	failures []SessionResult
+}
+
+func NewSessionStats() *SessionStats {
+	return &SessionStats{
+		errors: map[errorKey]int{},
+		scores: map[string]map[int]int{},
+	}
+}
+
+func (s *SessionStats) Add(result SessionResult) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.counts.Total++
+	if result.Status == StatusAbandoned {
+		s.counts.Abandoned++
+		return
+	}
+	if result.Error != "" {
+		s.counts.Failed++
+		s.errors[errorKey{step: result.FailedStep, message: result.Error}]++
+		s.failures = append(s.failures, result)
+		return
+	}
+	s.counts.Completed++
+	if _, ok := s.scores[result.Topic]; !ok {
+		s.scores[result.Topic] = map[int]int{}
+	}
+	s.scores[result.Topic][result.Score]++
+}
+
+// SessionAggregate is the state of session stats, sent by the workers of a distributed run to be merged
+type SessionAggregate struct {
+	Counts   SessionCounts          `json:"counts"`
+	Errors   []ErrorCount           `json:"errors"`
+	Scores   map[string]map[int]int `json:"scores"`
+	Failures []SessionResult        `json:"failures"`
+}
+
+// Aggregate returns the state of the stats, to be merged into the stats of another process
+func (s *SessionStats) Aggregate() SessionAggregate {
+	aggregate := SessionAggregate{
+		Counts:   s.Counts(),
+		Errors:   s.ErrorBreakdown(),
+		Scores:   map[string]map[int]int{},
+		Failures: s.Failures(),
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for topic, distribution := range s.scores {
+		aggregate.Scores[topic] = map[int]int{}
+		for score, count := range distribution {
+			aggregate.Scores[topic][score] = count
+		}
+	}
+	return aggregate
+}
+
+// Merge adds the sessions of the aggregate, the failures are appended in the order they are merged
+func (s *SessionStats) Merge(aggregate SessionAggregate) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.counts.Total += aggregate.Counts.Total
+	s.counts.Completed += aggregate.Counts.Completed
+	s.counts.Failed += aggregate.Counts.Failed
+	s.counts.Abandoned += aggregate.Counts.Abandoned
+	for _, e := range aggregate.Errors {
+		s.errors[errorKey{step: e.Step, message: e.Message}] += e.Count
+	}
+	for topic, distribution := range aggregate.Scores {
+		if _, ok := s.scores[topic]; !ok {
+			s.scores[topic] = map[int]int{}
+		}
+		for score, count := range distribution {
+			s.scores[topic][score] += count
+		}
+	}
+	s.failures = append(s.failures, aggregate.Failures...)
+}
+
+func (s *SessionStats) Counts() SessionCounts {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.counts
+}
+
+// Failures returns the failed sessions in the order they finished
+func (s *SessionStats) Failures() []SessionResult {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]SessionResult{}, s.failures...)
+}
+
+// ErrorBreakdown returns the session errors grouped by step and message, most frequent first
+func (s *SessionStats) ErrorBreakdown() []ErrorCount {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	breakdown := []ErrorCount{}
+	for key, count := range s.errors {
+		breakdown = append(breakdown, ErrorCount{Step: key.step, Message: key.message, Count: count})
+	}
+	sort.Slice(breakdown, func(i, j int) bool {
+		if breakdown[i].Count != breakdown[j].Count {
+			return breakdown[i].Count > breakdown[j].Count
+		}
+		if breakdown[i].Step != breakdown[j].Step {
+			return breakdown[i].Step < breakdown[j].Step
+		}
+		return breakdown[i].Message < breakdown[j].Message
+	})
+	return breakdown
+}
+
+// Scores returns the score distribution of the completed sessions by topic
+func (s *SessionStats) Scores() []TopicScores {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	scores := []TopicScores{}
+	for topic, distribution := range s.scores {
+		ts := TopicScores{Topic: topic, Distribution: map[int]int{}}
+		total := 0
+		first := true
+		for score, count := range distribution {
+			ts.Distribution[score] = count
+			ts.Sessions += count
+			total += score * count
+			if first || score < ts.Min {
+				ts.Min = score
+			}
+			if first || score > ts.Max {
+				ts.Max = score
+			}
+			first = false
+		}
+		if ts.Sessions > 0 {
+			ts.Mean = float64(total) / float64(ts.Sessions)
+		}
+		scores = append(scores, ts)
+	}
+	sort.Slice(scores, func(i, j int) bool {
+		return scores[i].Topic < scores[j].Topic
+	})
+	return scores
+}
+
+// EmailDeliveryStats describes the emails received by the smtp sink for the accepted email report requests
+type EmailDeliveryStats struct {
+	Expected  int `json:"expected"`
+	Delivered int `json:"delivered"`
+	Missing   int `json:"missing"`
+	// emails received which match no session
+	Unmatched int `json:"unmatched"`
+	// latency in ms from the accepted email report request to the email received
+	Mean            float64  `json:"mean_ms"`
+	P50             float64  `json:"p50_ms"`
+	P95             float64  `json:"p95_ms"`
+	P99             float64  `json:"p99_ms"`
+	Max             float64  `json:"max_ms"`
+	MissingSessions []string `json:"missing_sessions,omitempty"`
+}
+
+func NewEmailDeliveryStats(latencies []time.Duration, missingSessions []string, unmatched int) *EmailDeliveryStats {
+	latency := NewHistogram()
+	for _, l := range latencies {
+		latency.Record(float64(l.Microseconds()) / 1000)
+	}
+	return &EmailDeliveryStats{
+		Expected:        len(latencies) + len(missingSessions),
+		Delivered:       len(latencies),
+		Missing:         len(missingSessions),
+		Unmatched:       unmatched,
+		Mean:            latency.Mean(),
+		P50:             latency.Quantile(0.50),
+		P95:             latency.Quantile(0.95),
+		P99:             latency.Quantile(0.99),
+		Max:             latency.Max,
+		MissingSessions: missingSessions,
+	}
+}
+
+// Summary describes a whole run, it is the source of all the reports written after the run
+type Summary struct {
+	StartedAt      time.Time             `json:"started_at"`
+	EndedAt        time.Time             `json:"ended_at"`
+	Seconds        float64               `json:"seconds"`
+	Config         map[string]string     `json:"config"`
+	Sessions       SessionCounts         `json:"sessions"`
+	Requests       uint64                `json:"requests"`
+	Errors         uint64                `json:"errors"`
+	RPS            float64               `json:"rps"`
+	ErrorRate      float64               `json:"error_rate"`
+	Endpoints      []EndpointStats       `json:"endpoints"`
+	Histograms     map[string]*Histogram `json:"histograms"`
+	ErrorBreakdown []ErrorCount          `json:"error_breakdown"`
+	Failures       []SessionResult       `json:"failures"`
+	Scores         []TopicScores         `json:"scores"`
+	TimeSeries     []Snapshot            `json:"time_series"`
+	// latency of the requests measured from their intended start instead of the time they were sent,
+	// corrected for coordinated omission, empty unless the users are started at an arrival rate
+	IntendedEndpoints []EndpointStats `json:"intended_endpoints,omitempty"`
+	// requests by stage of the load profile, empty without a load profile
+	Stages []StageStats `json:"stages,omitempty"`
+	// rule which ended the run early, empty if the run completed
+	AbortReason string `json:"abort_reason,omitempty"`
+	// emails received by the smtp sink, nil unless the sink is enabled
+	EmailDelivery *EmailDeliveryStats `json:"email_delivery,omitempty"`
+	// responses to the negative scenarios, empty unless scenarios are mixed into the traffic
+	NegativeScenarios []NegativeScenarioStats `json:"negative_scenarios,omitempty"`
+}
+
+func NewSummary(startedAt, endedAt time.Time, config map[string]string, collector *Collector, sessions *SessionStats) *Summary {
+	summary := &Summary{
+		StartedAt:      startedAt,
+		EndedAt:        endedAt,
+		Seconds:        endedAt.Sub(startedAt).Seconds(),
+		Config:         config,
+		Sessions:       sessions.Counts(),
+		Endpoints:      []EndpointStats{},
+		Histograms:     map[string]*Histogram{},
+		ErrorBreakdown: sessions.ErrorBreakdown(),
+		Failures:       sessions.Failures(),
+		Scores:         sessions.Scores(),
+		TimeSeries:     collector.History(),
+		Stages:         collector.Stages(endedAt),
+	}
+
+	for _, totals := range collector.Totals() {
+		summary.Endpoints = append(summary.Endpoints, NewEndpointStats(totals.Endpoint, totals.Latency, totals.Errors, summary.Seconds))
+		summary.Histograms[totals.Endpoint] = totals.Latency
+		summary.Requests += totals.Latency.Count
+		summary.Errors += totals.Errors
+	}
+	for _, totals := range collector.IntendedTotals() {
+		summary.IntendedEndpoints = append(summary.IntendedEndpoints, NewEndpointStats(totals.Endpoint, totals.Latency, totals.Errors, summary.Seconds))
+	}
+	if summary.Seconds > 0 {
+		summary.RPS = float64(summary.Requests) / summary.Seconds
+	}
+	if summary.Requests > 0 {
+		summary.ErrorRate = float64(summary.Errors) / float64(summary.Requests)
+	}
+	return summary
+}
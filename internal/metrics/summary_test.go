@@ -0,0 +1,155 @@
+package metrics
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_metrics_summary_SessionStats(t *testing.T) {
+	s := NewSessionStats()
+	s.Add(SessionResult{Status: "completed", Topic: "go", Score: 5})
+	s.Add(SessionResult{Status: "completed", Topic: "go", Score: 5})
+	s.Add(SessionResult{Status: "completed", Topic: "go", Score: 8})
+	s.Add(SessionResult{Status: "completed", Topic: "c", Score: 2})
+	s.Add(SessionResult{Status: "failed", Topic: "go", FailedStep: "start_quiz", Error: "status code: 500"})
+	s.Add(SessionResult{Status: "failed", Topic: "c", FailedStep: "start_quiz", Error: "status code: 500"})
+	s.Add(SessionResult{Status: "failed", Topic: "c", FailedStep: "get_report", Error: "timeout"})
+
+	counts := s.Counts()
+	assert.Equal(t, SessionCounts{Total: 7, Completed: 4, Failed: 3}, counts, "Expected the session counts")
+
+	breakdown := s.ErrorBreakdown()
+	require.Len(t, breakdown, 2, "Expected errors grouped by step and message")
+	assert.Equal(t, ErrorCount{Step: "start_quiz", Message: "status code: 500", Count: 2}, breakdown[0], "Expected the most frequent error first")
+	assert.Equal(t, ErrorCount{Step: "get_report", Message: "timeout", Count: 1}, breakdown[1], "Expected the least frequent error last")
+
+	failures := s.Failures()
+	require.Len(t, failures, 3, "Expected every failed session")
+	assert.Equal(t, "get_report", failures[2].FailedStep, "Expected failures in the order they were added")
+
+	scores := s.Scores()
+	require.Len(t, scores, 2, "Expected scores for every topic with completed sessions")
+	assert.Equal(t, "c", scores[0].Topic, "Expected topics sorted by name")
+	assert.Equal(t, "go", scores[1].Topic, "Expected topics sorted by name")
+	assert.Equal(t, 3, scores[1].Sessions, "Expected the completed sessions of the topic")
+	assert.Equal(t, 5, scores[1].Min, "Expected the min score of the topic")
+	assert.Equal(t, 8, scores[1].Max, "Expected the max score of the topic")
+	assert.InDelta(t, 6, scores[1].Mean, 0.001, "Expected the mean score of the topic")
+	assert.Equal(t, map[int]int{5: 2, 8: 1}, scores[1].Distribution, "Expected the score distribution of the topic")
+}
+
+func Test_metrics_summary_SessionStats_WhenAbandoned(t *testing.T) {
+	s := NewSessionStats()
+	s.Add(SessionResult{Status: "completed", Topic: "go", Score: 5})
+	s.Add(SessionResult{Status: StatusAbandoned, Topic: "go", Behaviour: "abandon"})
+
+	assert.Equal(t, SessionCounts{Total: 2, Completed: 1, Abandoned: 1}, s.Counts(), "Expected the abandoned session to be counted apart")
+	assert.Empty(t, s.Failures(), "Expected an abandoned session not to fail")
+	scores := s.Scores()
+	require.Len(t, scores, 1, "Expected the scores of the completed sessions")
+	assert.Equal(t, map[int]int{5: 1}, scores[0].Distribution, "Expected the abandoned session not to be scored")
+}
+
+func Test_metrics_summary_SessionStats_Merge(t *testing.T) {
+	s := NewSessionStats()
+	s.Add(SessionResult{Status: "completed", Topic: "go", Score: 5})
+	s.Add(SessionResult{Status: "failed", Topic: "go", FailedStep: "start_quiz", Error: "status code: 500"})
+
+	other := NewSessionStats()
+	other.Add(SessionResult{Status: "completed", Topic: "go", Score: 5})
+	other.Add(SessionResult{Status: "completed", Topic: "c", Score: 2})
+	other.Add(SessionResult{Status: StatusAbandoned, Topic: "c"})
+	other.Add(SessionResult{Status: "failed", Topic: "c", FailedStep: "start_quiz", Error: "status code: 500"})
+	other.Add(SessionResult{Status: "failed", Topic: "c", FailedStep: "get_report", Error: "timeout"})
+	s.Merge(other.Aggregate())
+
+	assert.Equal(t, SessionCounts{Total: 7, Completed: 3, Failed: 3, Abandoned: 1}, s.Counts(), "Expected the sessions of both stats")
+	assert.Equal(t, []ErrorCount{
+		{Step: "start_quiz", Message: "status code: 500", Count: 2},
+		{Step: "get_report", Message: "timeout", Count: 1},
+	}, s.ErrorBreakdown(), "Expected the errors of both stats grouped by step and message")
+	require.Len(t, s.Failures(), 3, "Expected the failures of both stats")
+	assert.Equal(t, "get_report", s.Failures()[2].FailedStep, "Expected the merged failures after the others")
+	scores := s.Scores()
+	require.Len(t, scores, 2, "Expected the scores of both stats")
+	assert.Equal(t, map[int]int{2: 1}, scores[0].Distribution, "Expected the scores of the merged topic")
+	assert.Equal(t, map[int]int{5: 2}, scores[1].Distribution, "Expected the scores of the topic of both stats added")
+}
+
+func Test_metrics_summary_NewSummary(t *testing.T) {
+	collector := NewCollector("create_session", "start_quiz")
+	collector.Observe(Observation{Endpoint: "create_session", Latency: 100 * time.Millisecond})
+	collector.Observe(Observation{Endpoint: "create_session", Latency: 300 * time.Millisecond, Failed: true})
+	collector.Observe(Observation{Endpoint: "start_quiz", Latency: 50 * time.Millisecond})
+	collector.Flush(time.Now())
+	collector.Observe(Observation{Endpoint: "start_quiz", Latency: 70 * time.Millisecond})
+
+	sessions := NewSessionStats()
+	sessions.Add(SessionResult{Status: "completed", Topic: "go", Score: 5})
+
+	start := time.Now()
+	summary := NewSummary(start, start.Add(2*time.Second), map[string]string{"NUM_USERS": "1"}, collector, sessions)
+
+	assert.InDelta(t, 2, summary.Seconds, 0.001, "Expected the run duration")
+	assert.Equal(t, uint64(4), summary.Requests, "Expected requests of flushed and current intervals")
+	assert.Equal(t, uint64(1), summary.Errors, "Expected the failed request")
+	assert.InDelta(t, 2, summary.RPS, 0.001, "Expected requests per second over the run")
+	assert.InDelta(t, 0.25, summary.ErrorRate, 0.001, "Expected the request error rate")
+	assert.Len(t, summary.TimeSeries, 1, "Expected the flushed interval in the time series")
+	assert.Equal(t, "1", summary.Config["NUM_USERS"], "Expected the run configuration")
+	assert.Equal(t, 1, summary.Sessions.Completed, "Expected the session counts")
+
+	require.Len(t, summary.Endpoints, 2, "Expected stats for every endpoint")
+	assert.Equal(t, "create_session", summary.Endpoints[0].Endpoint, "Expected endpoints in the collector order")
+	assert.Equal(t, uint64(2), summary.Endpoints[0].Count, "Expected all the create_session requests")
+	assert.Equal(t, uint64(2), summary.Histograms["start_quiz"].Count, "Expected the start_quiz histogram")
+}
+
+func Test_metrics_collector_Totals(t *testing.T) {
+	collector := NewCollector("create_session")
+	collector.Observe(Observation{Endpoint: "get_report", Latency: time.Millisecond, Failed: true})
+
+	totals := collector.Totals()
+	require.Len(t, totals, 2, "Expected known and observed endpoints")
+	assert.Equal(t, uint64(0), totals[0].Latency.Count, "Expected no create_session requests")
+	assert.Equal(t, "get_report", totals[1].Endpoint, "Expected the observed endpoint")
+	assert.Equal(t, uint64(1), totals[1].Errors, "Expected the failed request")
+
+	// totals are copies, recording into them doesn't change the collector
+	totals[1].Latency.Record(10)
+	assert.Equal(t, uint64(1), collector.Totals()[1].Latency.Count, "Expected the collector totals to be unchanged")
+}
+
+func Test_metrics_collector_Merge(t *testing.T) {
+	collector := NewCollector("create_session", "start_quiz")
+	collector.Observe(Observation{Endpoint: "create_session", Latency: 100 * time.Millisecond})
+
+	other := NewCollector("create_session", "start_quiz")
+	other.Observe(Observation{Endpoint: "create_session", Latency: 300 * time.Millisecond, Failed: true})
+	other.Observe(Observation{Endpoint: "get_report", Latency: 50 * time.Millisecond})
+	collector.Merge(other.Totals(), other.IntendedTotals())
+
+	totals := collector.Totals()
+	require.Len(t, totals, 3, "Expected the known endpoints and the merged endpoint")
+	assert.Equal(t, uint64(2), totals[0].Latency.Count, "Expected the create_session requests of both collectors")
+	assert.Equal(t, uint64(1), totals[0].Errors, "Expected the merged failed request")
+	assert.Equal(t, uint64(0), totals[1].Latency.Count, "Expected no start_quiz requests")
+	assert.Equal(t, "get_report", totals[2].Endpoint, "Expected the endpoint observed by the other collector")
+	assert.Empty(t, collector.IntendedTotals(), "Expected no intended latency when no request was scheduled")
+	assert.Empty(t, collector.History(), "Expected the time series not to be merged")
+}
+
+func Test_metrics_summary_NewEmailDeliveryStats(t *testing.T) {
+	stats := NewEmailDeliveryStats([]time.Duration{100 * time.Millisecond, 300 * time.Millisecond}, []string{"s3"}, 1)
+
+	assert.Equal(t, 3, stats.Expected, "Expected the delivered and missing emails")
+	assert.Equal(t, 2, stats.Delivered, "Expected the delivered emails")
+	assert.Equal(t, 1, stats.Missing, "Expected the missing emails")
+	assert.Equal(t, 1, stats.Unmatched, "Expected the unmatched emails")
+	assert.Equal(t, []string{"s3"}, stats.MissingSessions, "Expected the sessions of the missing emails")
+	assert.InDelta(t, 200, stats.Mean, 0.001, "Expected the mean delivery latency")
+	assert.Equal(t, 300.0, stats.Max, "Expected the max delivery latency")
+}
@@ -0,0 +1,144 @@
+package negative
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/go-squad-5/quiz-load-test/internal/compare"
+)
+
+// Scenario is a deliberate misuse of the quiz api, the server should reject it with a 4xx status code
+type Scenario string
+
+const (
+	// submit the quiz a second time for a session already submitted
+	DoubleSubmit Scenario = "double_submit"
+	// submit the quiz for a session which was never created
+	UnknownSession Scenario = "unknown_session"
+	// start the quiz of a session with a topic which doesn't exist
+	UnknownTopic Scenario = "unknown_topic"
+	// submit answers to questions which are not the questions of the session
+	ForeignQuestions Scenario = "foreign_questions"
+	// submit an answer larger than any valid answer
+	OversizedAnswers Scenario = "oversized_answers"
+	// get the report of a session which was started but never submitted
+	IncompleteReport Scenario = "incomplete_report"
+)
+
+// Scenarios lists the scenarios in the order they are described in the README
+var Scenarios []Scenario = []Scenario{DoubleSubmit, UnknownSession, UnknownTopic, ForeignQuestions, OversizedAnswers, IncompleteReport}
+
+// OversizedAnswerSize is the size in bytes of the answer submitted by the oversized answers scenario
+const OversizedAnswerSize = 1 << 20
+
+// UnknownTopicName is the topic started by the unknown topic scenario
+const UnknownTopicName = "no-such-topic"
+
+var expectedStatusCodes map[Scenario][]int = map[Scenario][]int{
+	DoubleSubmit:     {http.StatusBadRequest, http.StatusConflict},
+	UnknownSession:   {http.StatusBadRequest, http.StatusNotFound},
+	UnknownTopic:     {http.StatusBadRequest, http.StatusNotFound},
+	ForeignQuestions: {http.StatusBadRequest, http.StatusUnprocessableEntity},
+	OversizedAnswers: {http.StatusBadRequest, http.StatusRequestEntityTooLarge, http.StatusUnprocessableEntity},
+	IncompleteReport: {http.StatusBadRequest, http.StatusNotFound, http.StatusConflict},
+}
+
+// ExpectedStatusCodes returns the status codes the server may reject the scenario with
+func (s Scenario) ExpectedStatusCodes() []int {
+	return expectedStatusCodes[s]
+}
+
+// Check returns an error unless the server rejected the scenario with one of its expected status codes,
+// statusCode is 0 when no response was received
+func (s Scenario) Check(statusCode int) error {
+	expected := s.ExpectedStatusCodes()
+	codes := make([]string, len(expected))
+	for i, code := range expected {
+		codes[i] = strconv.Itoa(code)
+	}
+	switch {
+	case slices.Contains(expected, statusCode):
+		return nil
+	case statusCode == 0:
+		return fmt.Errorf("%s: no response, expected status %s", s, strings.Join(codes, " or "))
+	case statusCode < 400:
+		return fmt.Errorf("%s: accepted with status %d, expected status %s", s, statusCode, strings.Join(codes, " or "))
+	}
+	return fmt.Errorf("%s: status %d, expected status %s", s, statusCode, strings.Join(codes, " or "))
+}
+
+// IsServerError reports whether the server failed to handle the request, rejecting the misuse with an
+// unexpected 4xx status code is a wrong answer but not a failure of the server
+func IsServerError(statusCode int) bool {
+	return statusCode == 0 || statusCode >= 500
+}
+
+// Share is the fraction of the sessions running a scenario
+type Share struct {
+	Scenario Scenario
+	Ratio    float64
+}
+
+// Mix is the share of the sessions running each scenario, the other sessions run the normal quiz flow
+type Mix []Share
+
+func (m Mix) Empty() bool {
+	return len(m) == 0
+}
+
+func (m Mix) String() string {
+	shares := make([]string, len(m))
+	for i, share := range m {
+		shares[i] = string(share.Scenario) + "=" + strconv.FormatFloat(share.Ratio, 'f', -1, 64)
+	}
+	return strings.Join(shares, ",")
+}
+
+// Pick returns the scenario of a session from a random value in [0, 1), or an empty scenario for the normal quiz flow
+func (m Mix) Pick(value float64) Scenario {
+	cumulative := 0.0
+	for _, share := range m {
+		cumulative += share.Ratio
+		if value < cumulative {
+			return share.Scenario
+		}
+	}
+	return ""
+}
+
+// ParseMix parses comma separated shares of the sessions by scenario, as fractions or percentages,
+// e.g. "double_submit=5%,unknown_session=0.02"
+func ParseMix(value string) (Mix, error) {
+	mix := Mix{}
+	total := 0.0
+	for _, part := range strings.Split(value, ",") {
+		if strings.TrimSpace(part) == "" {
+			continue
+		}
+		name, ratioValue, found := strings.Cut(part, "=")
+		if !found {
+			return nil, fmt.Errorf("invalid scenario share %q, expected scenario=ratio", strings.TrimSpace(part))
+		}
+		scenario := Scenario(strings.TrimSpace(name))
+		if !slices.Contains(Scenarios, scenario) {
+			return nil, fmt.Errorf("unknown scenario %q", scenario)
+		}
+		if slices.ContainsFunc(mix, func(share Share) bool { return share.Scenario == scenario }) {
+			return nil, fmt.Errorf("duplicate scenario %q", scenario)
+		}
+		ratio, err := compare.ParseRatio(strings.TrimSpace(ratioValue))
+		if err != nil {
+			return nil, fmt.Errorf("invalid share of scenario %q: %w", scenario, err)
+		}
+		total += ratio
+		mix = append(mix, Share{Scenario: scenario, Ratio: ratio})
+	}
+	// tolerate the rounding of the sum of fractions, e.g. 0.1+0.2+0.7
+	if total > 1+1e-9 {
+		return nil, fmt.Errorf("the scenarios share %.0f%% of the sessions, expected at most 100%%", total*100)
+	}
+	return mix, nil
+}
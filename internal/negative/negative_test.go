@@ -0,0 +1,62 @@
+package negative
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_negative_ParseMix(t *testing.T) {
+	mix, err := ParseMix("double_submit=5%, unknown_session=0.02,incomplete_report=0")
+	require.NoError(t, err, "Expected a valid mix")
+	assert.Equal(t, Mix{
+		{Scenario: DoubleSubmit, Ratio: 0.05},
+		{Scenario: UnknownSession, Ratio: 0.02},
+		{Scenario: IncompleteReport, Ratio: 0},
+	}, mix, "Expected the shares in the given order")
+	assert.Equal(t, "double_submit=0.05,unknown_session=0.02,incomplete_report=0", mix.String(), "Expected the mix as fractions")
+
+	mix, err = ParseMix("")
+	require.NoError(t, err, "Expected no error without scenarios")
+	assert.True(t, mix.Empty(), "Expected no scenarios")
+
+	_, err = ParseMix("double_submit=0.1,unknown_session=0.2,oversized_answers=0.7")
+	assert.NoError(t, err, "Expected the sum of the fractions to be rounded")
+}
+
+func Test_negative_ParseMix_WhenInvalid(t *testing.T) {
+	for _, value := range []string{
+		"double_submit",
+		"double_submit=often",
+		"double_submit=-1%",
+		"drop_tables=5%",
+		"double_submit=5%,double_submit=1%",
+		"double_submit=60%,unknown_topic=50%",
+	} {
+		_, err := ParseMix(value)
+		assert.Error(t, err, "Expected an error for %q", value)
+	}
+}
+
+func Test_negative_Mix_Pick(t *testing.T) {
+	mix := Mix{{Scenario: DoubleSubmit, Ratio: 0.1}, {Scenario: UnknownTopic, Ratio: 0.2}}
+
+	assert.Equal(t, DoubleSubmit, mix.Pick(0), "Expected the first scenario for the lowest values")
+	assert.Equal(t, UnknownTopic, mix.Pick(0.15), "Expected the second scenario after the share of the first")
+	assert.Equal(t, Scenario(""), mix.Pick(0.35), "Expected the normal quiz flow after the shares of the scenarios")
+	assert.Equal(t, Scenario(""), Mix{}.Pick(0), "Expected the normal quiz flow without scenarios")
+}
+
+func Test_negative_Scenario_Check(t *testing.T) {
+	assert.NoError(t, DoubleSubmit.Check(http.StatusConflict), "Expected a conflict to reject a second submit")
+	assert.NoError(t, OversizedAnswers.Check(http.StatusRequestEntityTooLarge), "Expected a too large payload to be rejected")
+	assert.EqualError(t, UnknownSession.Check(http.StatusOK), "unknown_session: accepted with status 200, expected status 400 or 404", "Expected an accepted misuse to fail")
+	assert.EqualError(t, IncompleteReport.Check(http.StatusInternalServerError), "incomplete_report: status 500, expected status 400 or 404 or 409", "Expected a server error to fail")
+	assert.EqualError(t, UnknownTopic.Check(0), "unknown_topic: no response, expected status 400 or 404", "Expected a missing response to fail")
+
+	assert.True(t, IsServerError(0), "Expected no response to be a server error")
+	assert.True(t, IsServerError(http.StatusBadGateway), "Expected a 5xx to be a server error")
+	assert.False(t, IsServerError(http.StatusForbidden), "Expected a 4xx not to be a server error")
+}
@@ -0,0 +1,71 @@
+package pdfcheck
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"mime"
+	"regexp"
+	"strings"
+)
+
+const (
+	header  = "%PDF-"
+	trailer = "%%EOF"
+	// readers look for the trailer in the last kilobyte of the file, trailing bytes are tolerated
+	trailerWindow = 1024
+)
+
+var streamRegexp = regexp.MustCompile(`(?s)stream\r?\n(.*?)\r?\n?endstream`)
+
+// Error lists the problems of an invalid report, it is a correctness error of the report, the request succeeded
+type Error struct {
+	Problems []string
+}
+
+func (e *Error) Error() string {
+	return "invalid pdf report: " + strings.Join(e.Problems, ", ")
+}
+
+// CheckFormat checks the content type, the header, the trailer and the size of a pdf document
+func CheckFormat(contentType string, data []byte, minSize int) error {
+	problems := []string{}
+	if mediaType, _, err := mime.ParseMediaType(contentType); err != nil || mediaType != "application/pdf" {
+		problems = append(problems, fmt.Sprintf("content type %q, expected application/pdf", contentType))
+	}
+	if !bytes.HasPrefix(data, []byte(header)) {
+		problems = append(problems, "missing "+header+" header")
+	}
+	if !bytes.Contains(data[max(len(data)-trailerWindow, 0):], []byte(trailer)) {
+		problems = append(problems, "missing "+trailer+" trailer")
+	}
+	if len(data) < minSize {
+		problems = append(problems, fmt.Sprintf("smaller than %d bytes", minSize))
+	}
+	if len(problems) > 0 {
+		return &Error{Problems: problems}
+	}
+	return nil
+}
+
+// ContainsText reports whether the text appears in the document, either as is or in a flate compressed stream.
+// Text split by the layout of the page, e.g. into several strings of a TJ operator, is not found.
+func ContainsText(data []byte, text string) bool {
+	if bytes.Contains(data, []byte(text)) {
+		return true
+	}
+	for _, matches := range streamRegexp.FindAllSubmatch(data, -1) {
+		reader, err := zlib.NewReader(bytes.NewReader(matches[1]))
+		if err != nil {
+			continue
+		}
+		// a truncated stream still yields the text decompressed so far
+		content, _ := io.ReadAll(reader)
+		reader.Close()
+		if bytes.Contains(content, []byte(text)) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,64 @@
+package pdfcheck
+
+import (
+	"bytes"
+	"compress/zlib"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testPDF(t *testing.T, content string) []byte {
+	var compressed bytes.Buffer
+	w := zlib.NewWriter(&compressed)
+	_, err := w.Write([]byte(content))
+	require.NoError(t, err, "Expected the content to be compressed")
+	require.NoError(t, w.Close(), "Expected the content to be compressed")
+
+	var pdf bytes.Buffer
+	pdf.WriteString("%PDF-1.7\n1 0 obj\n<< /Filter /FlateDecode >>\nstream\n")
+	pdf.Write(compressed.Bytes())
+	pdf.WriteString("\nendstream\nendobj\n")
+	pdf.WriteString(strings.Repeat("% padding\n", 10))
+	pdf.WriteString("trailer\n<< >>\n%%EOF\n")
+	return pdf.Bytes()
+}
+
+func Test_pdfcheck_CheckFormat(t *testing.T) {
+	pdf := testPDF(t, "BT (Session 1234) Tj ET")
+	assert.NoError(t, CheckFormat("application/pdf", pdf, 100), "Expected a valid pdf")
+	assert.NoError(t, CheckFormat("application/pdf; charset=binary", pdf, 100), "Expected the content type parameters to be ignored")
+}
+
+func Test_pdfcheck_CheckFormat_WhenInvalid(t *testing.T) {
+	err := CheckFormat("text/html", []byte("<html>error</html>"), 100)
+	require.Error(t, err, "Expected an html page to be invalid")
+
+	var pdfErr *Error
+	require.True(t, errors.As(err, &pdfErr), "Expected a report correctness error")
+	assert.Equal(t, []string{
+		`content type "text/html", expected application/pdf`,
+		"missing %PDF- header",
+		"missing %%EOF trailer",
+		"smaller than 100 bytes",
+	}, pdfErr.Problems, "Expected every problem of the report")
+	assert.Equal(t, `invalid pdf report: content type "text/html", expected application/pdf, missing %PDF- header, missing %%EOF trailer, smaller than 100 bytes`, err.Error(), "Expected the problems in the message")
+}
+
+func Test_pdfcheck_CheckFormat_WhenTruncated(t *testing.T) {
+	pdf := testPDF(t, "BT (Session 1234) Tj ET")
+	err := CheckFormat("application/pdf", pdf[:len(pdf)-10], 0)
+	require.Error(t, err, "Expected a truncated pdf to be invalid")
+	assert.Contains(t, err.Error(), "missing %%EOF trailer", "Expected the missing trailer")
+}
+
+func Test_pdfcheck_ContainsText(t *testing.T) {
+	pdf := testPDF(t, "BT (Session 1234) Tj (Score: 7) Tj ET")
+	assert.True(t, ContainsText(pdf, "1234"), "Expected the text of a compressed stream to be found")
+	assert.True(t, ContainsText(pdf, "Score: 7"), "Expected the text of a compressed stream to be found")
+	assert.True(t, ContainsText(pdf, "trailer"), "Expected the raw text to be found")
+	assert.False(t, ContainsText(pdf, "Score: 8"), "Expected a missing text not to be found")
+}
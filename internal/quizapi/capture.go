@@ -0,0 +1,91 @@
+package quizapi
+
+import (
+	"bytes"
+	"encoding/base64"
+	"io"
+	"net/http"
+	"time"
+	"unicode/utf8"
+)
+
+// Exchange is a request sent by the QuizAPI and its response, as captured to reproduce the request.
+// The bodies are truncated to the capture limit, a body which is not valid utf-8 is base64 encoded
+type Exchange struct {
+	Endpoint              string      `json:"endpoint"`
+	Method                string      `json:"method"`
+	URL                   string      `json:"url"`
+	StartTime             time.Time   `json:"start_time"`
+	DurationMs            float64     `json:"duration_ms"`
+	RequestHeaders        http.Header `json:"request_headers"`
+	RequestBody           string      `json:"request_body,omitempty"`
+	RequestBodyEncoding   string      `json:"request_body_encoding,omitempty"`
+	RequestBodyTruncated  bool        `json:"request_body_truncated,omitempty"`
+	StatusCode            int         `json:"status_code,omitempty"`
+	ResponseHeaders       http.Header `json:"response_headers,omitempty"`
+	ResponseBody          string      `json:"response_body,omitempty"`
+	ResponseBodyEncoding  string      `json:"response_body_encoding,omitempty"`
+	ResponseBodyTruncated bool        `json:"response_body_truncated,omitempty"`
+	Error                 string      `json:"error,omitempty"`
+}
+
+// SetCapture captures every request and its response into the RequestInfo passed to the observer,
+// with their bodies truncated to bodyLimit bytes
+func (q *QuizAPI) SetCapture(bodyLimit int) {
+	q.capture = true
+	q.captureBodyLimit = bodyLimit
+}
+
+// captureRequest captures the request as it is sent, its body is read from a copy so the request is unchanged
+func captureRequest(endpoint string, req *http.Request, bodyLimit int) *Exchange {
+	exchange := &Exchange{
+		Endpoint:       endpoint,
+		Method:         req.Method,
+		URL:            req.URL.String(),
+		RequestHeaders: req.Header.Clone(),
+	}
+	if req.GetBody != nil {
+		if body, err := req.GetBody(); err == nil {
+			data, _ := io.ReadAll(io.LimitReader(body, int64(bodyLimit)+1))
+			body.Close()
+			exchange.RequestBody, exchange.RequestBodyEncoding, exchange.RequestBodyTruncated = captureBody(data, bodyLimit)
+		}
+	}
+	return exchange
+}
+
+// captureResponse captures the status, the headers and the start of the body of the response, and returns the body
+// to read in its place. The start of the body is read at once, so it is captured even when the body is not read
+func (e *Exchange) captureResponse(resp *http.Response, bodyLimit int) io.ReadCloser {
+	e.StatusCode = resp.StatusCode
+	e.ResponseHeaders = resp.Header.Clone()
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, int64(bodyLimit)+1))
+	e.ResponseBody, e.ResponseBodyEncoding, e.ResponseBodyTruncated = captureBody(data, bodyLimit)
+	return &capturedBody{Reader: io.MultiReader(bytes.NewReader(data), resp.Body), Closer: resp.Body}
+}
+
+// captureBody returns the body truncated to the limit, base64 encoded when it is not valid utf-8
+func captureBody(data []byte, limit int) (body, encoding string, truncated bool) {
+	if len(data) > limit {
+		data = data[:limit]
+		truncated = true
+	}
+	if utf8.Valid(data) {
+		return string(data), "", truncated
+	}
+	// the limit may cut a character of a text body in two
+	if truncated {
+		for i := 1; i < utf8.UTFMax && i < len(data); i++ {
+			if utf8.Valid(data[:len(data)-i]) {
+				return string(data[:len(data)-i]), "", truncated
+			}
+		}
+	}
+	return base64.StdEncoding.EncodeToString(data), "base64", truncated
+}
+
+// capturedBody reads the captured start of a response body, then the rest of the body
+type capturedBody struct {
+	io.Reader
+	io.Closer
+}
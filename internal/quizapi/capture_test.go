@@ -0,0 +1,104 @@
+package quizapi
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_quizapi_capture_SetCapture(t *testing.T) {
+	body := `{"session_id": "12345", "message": "created"}`
+	q := NewTestQuizAPI("http://localhost:3000", "http://localhost:3001", func(req *http.Request) *http.Response {
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Header:     http.Header{"Content-Type": {"application/json"}},
+			Body:       io.NopCloser(strings.NewReader(body)),
+		}
+	})
+	q.SetCapture(1024)
+
+	observed := []RequestInfo{}
+	q.SetObserver(func(info RequestInfo) {
+		observed = append(observed, info)
+	})
+
+	ssid, err := q.CreateSession("test@example.com", "go")
+	require.NoError(t, err, "Expected the response to be parsed after being captured")
+	assert.Equal(t, "12345", ssid, "Expected the session ID of the captured response")
+
+	require.Len(t, observed, 1, "Expected the observer to be called once per request")
+	assert.Equal(t, "test@example.com", observed[0].Email, "Expected the email of the user creating the session")
+	exchange := observed[0].Exchange
+	require.NotNil(t, exchange, "Expected the request to be captured")
+	assert.Equal(t, EndpointCreateSession, exchange.Endpoint, "Expected the endpoint of the request")
+	assert.Equal(t, http.MethodPost, exchange.Method, "Expected the method of the request")
+	assert.Equal(t, "http://localhost:3000/session/create", exchange.URL, "Expected the url of the request")
+	assert.Equal(t, "application/json", exchange.RequestHeaders.Get("Content-Type"), "Expected the headers of the request")
+	assert.JSONEq(t, `{"email": "test@example.com", "topic": "go"}`, exchange.RequestBody, "Expected the body of the request")
+	assert.Equal(t, http.StatusOK, exchange.StatusCode, "Expected the status code of the response")
+	assert.Equal(t, "application/json", exchange.ResponseHeaders.Get("Content-Type"), "Expected the headers of the response")
+	assert.Equal(t, body, exchange.ResponseBody, "Expected the body of the response")
+	assert.False(t, exchange.ResponseBodyTruncated, "Expected the body within the limit not to be truncated")
+	assert.Empty(t, exchange.Error, "Expected no error")
+	assert.Equal(t, int64(len(body)), observed[0].Bytes, "Expected the bytes of the captured body to be counted")
+}
+
+func Test_quizapi_capture_SetCapture_WhenErrorStatus(t *testing.T) {
+	q := NewTestQuizAPI("http://localhost:3000", "http://localhost:3001", func(req *http.Request) *http.Response {
+		return &http.Response{
+			StatusCode: http.StatusInternalServerError,
+			Body:       io.NopCloser(strings.NewReader(`{"error": "database unavailable, retry later"}`)),
+		}
+	})
+	q.SetCapture(20)
+
+	var exchange *Exchange
+	q.SetObserver(func(info RequestInfo) {
+		exchange = info.Exchange
+	})
+
+	_, err := q.SubmitQuiz("12345", []Answer{{QuestionID: "q1", Answer: "a"}})
+	require.Error(t, err, "Expected an error for status 500")
+
+	require.NotNil(t, exchange, "Expected the failed request to be captured")
+	assert.Equal(t, http.StatusInternalServerError, exchange.StatusCode, "Expected the status code of the response")
+	assert.Equal(t, `{"error": "database `, exchange.ResponseBody, "Expected the body of the response truncated to the limit")
+	assert.True(t, exchange.ResponseBodyTruncated, "Expected the body to be marked truncated")
+	assert.Equal(t, `{"session_id":"12345`, exchange.RequestBody, "Expected the body of the request truncated to the limit")
+	assert.True(t, exchange.RequestBodyTruncated, "Expected the body of the request to be marked truncated")
+	assert.Equal(t, err.Error(), exchange.Error, "Expected the error of the request")
+}
+
+func Test_quizapi_capture_SetCapture_WhenDisabled(t *testing.T) {
+	q := NewTestQuizAPI("http://localhost:3000", "http://localhost:3001", func(req *http.Request) *http.Response {
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Body:       io.NopCloser(strings.NewReader(`{"session_id": "12345", "message": "created"}`)),
+		}
+	})
+
+	captured := true
+	q.SetObserver(func(info RequestInfo) {
+		captured = info.Exchange != nil
+	})
+
+	_, err := q.CreateSession("test@example.com", "go")
+	require.NoError(t, err, "Expected no error while creating session")
+	assert.False(t, captured, "Expected no capture unless enabled")
+}
+
+func Test_quizapi_capture_captureBody(t *testing.T) {
+	body, encoding, truncated := captureBody([]byte("héllo"), 2)
+	assert.Equal(t, "h", body, "Expected a character cut by the limit to be left out")
+	assert.Empty(t, encoding, "Expected a text body not to be encoded")
+	assert.True(t, truncated, "Expected the body to be marked truncated")
+
+	body, encoding, truncated = captureBody([]byte{0x25, 0x50, 0xff, 0xfe}, 10)
+	assert.Equal(t, "JVD//g==", body, "Expected a binary body to be base64 encoded")
+	assert.Equal(t, "base64", encoding, "Expected the encoding of the binary body")
+	assert.False(t, truncated, "Expected the body within the limit not to be truncated")
+}
@@ -0,0 +1,289 @@
+package quizapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/go-squad-5/quiz-load-test/internal/pdfcheck"
+)
+
+// ContractError lists the violations of the response contract of an endpoint,
+// it is a correctness error of the response, the request succeeded
+type ContractError struct {
+	Endpoint   string
+	Violations []string
+}
+
+func (e *ContractError) Error() string {
+	return fmt.Sprintf("contract violation of %s: %s", e.Endpoint, strings.Join(e.Violations, ", "))
+}
+
+// SetContractValidation checks every successful response strictly against the contract of its endpoint
+func (q *QuizAPI) SetContractValidation() {
+	q.validateContracts = true
+}
+
+// contractCheck returns the violations of the contract in a json response body
+type contractCheck func(data []byte) []string
+
+// checkContract reads the response body and checks it when the contracts are validated,
+// the body is returned to be parsed as usual
+func (q *QuizAPI) checkContract(endpoint string, body io.ReadCloser, check contractCheck) (io.ReadCloser, error) {
+	if !q.validateContracts {
+		return body, nil
+	}
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response body: %w", err)
+	}
+	if violations := check(data); len(violations) > 0 {
+		return nil, &ContractError{Endpoint: endpoint, Violations: violations}
+	}
+	return io.NopCloser(bytes.NewReader(data)), nil
+}
+
+// isCorrectnessError reports whether the response was received but is invalid, such a request is not a failed request
+func isCorrectnessError(err error) bool {
+	var pdfErr *pdfcheck.Error
+	var contractErr *ContractError
+	return errors.As(err, &pdfErr) || errors.As(err, &contractErr)
+}
+
+// fields are the fields of a json object of a response, a field is missing when it is absent or null
+type fields struct {
+	required []string
+	optional []string
+}
+
+// decodeObject decodes a json object, the violation is returned when the data is not an object
+func decodeObject(data []byte, path string) (map[string]json.RawMessage, string) {
+	object := map[string]json.RawMessage{}
+	if err := json.Unmarshal(data, &object); err != nil || object == nil {
+		return nil, fmt.Sprintf("%s is not a json object", path)
+	}
+	return object, ""
+}
+
+// check returns the missing and the unknown fields of the object, the fields are named after their path in the response
+func (f fields) check(object map[string]json.RawMessage, path string) []string {
+	violations := []string{}
+	for _, name := range f.required {
+		if !present(object, name) {
+			violations = append(violations, fmt.Sprintf("missing field %q", fieldPath(path, name)))
+		}
+	}
+	unknown := []string{}
+	for name := range object {
+		if !slices.Contains(f.required, name) && !slices.Contains(f.optional, name) {
+			unknown = append(unknown, name)
+		}
+	}
+	// map iteration order is random, the violations are grouped by message in the summary
+	sort.Strings(unknown)
+	for _, name := range unknown {
+		violations = append(violations, fmt.Sprintf("unknown field %q", fieldPath(path, name)))
+	}
+	return violations
+}
+
+func present(object map[string]json.RawMessage, name string) bool {
+	value, ok := object[name]
+	return ok && string(value) != "null"
+}
+
+// decodeField decodes a field of the object, a field of the wrong type is a violation
+func decodeField(object map[string]json.RawMessage, path, name string, value any) string {
+	raw, ok := object[name]
+	if !ok {
+		return ""
+	}
+	if err := json.Unmarshal(raw, value); err != nil {
+		return fmt.Sprintf("invalid field %q", fieldPath(path, name))
+	}
+	return ""
+}
+
+func fieldPath(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
+
+// appendViolation appends the violation unless it is empty
+func appendViolation(violations []string, violation string) []string {
+	if violation == "" {
+		return violations
+	}
+	return append(violations, violation)
+}
+
+var createSessionFields = fields{required: []string{"session_id"}, optional: []string{"message"}}
+
+func checkCreateSessionContract(data []byte) []string {
+	object, violation := decodeObject(data, "response")
+	if object == nil {
+		return []string{violation}
+	}
+	violations := createSessionFields.check(object, "")
+	sessionID := ""
+	violations = appendViolation(violations, decodeField(object, "", "session_id", &sessionID))
+	if present(object, "session_id") && sessionID == "" {
+		violations = append(violations, "empty session_id")
+	}
+	return violations
+}
+
+var (
+	startQuizFields = fields{required: []string{"session_id", "questions"}}
+	questionFields  = fields{required: []string{"ques_id", "question", "options"}}
+)
+
+// checkStartQuizContract checks the questions of the quiz, and that they are the questions of the requested session
+func checkStartQuizContract(sessionID string) contractCheck {
+	return func(data []byte) []string {
+		object, violation := decodeObject(data, "response")
+		if object == nil {
+			return []string{violation}
+		}
+		violations := startQuizFields.check(object, "")
+
+		responseSessionID := ""
+		violations = appendViolation(violations, decodeField(object, "", "session_id", &responseSessionID))
+		if present(object, "session_id") && responseSessionID != sessionID {
+			// the ids are left out of the message, so the sessions with the same violation are grouped
+			violations = append(violations, "session_id does not match the requested session")
+		}
+
+		questions := []json.RawMessage{}
+		if violation := decodeField(object, "", "questions", &questions); violation != "" {
+			return append(violations, violation)
+		}
+		if present(object, "questions") && len(questions) == 0 {
+			violations = append(violations, "empty questions")
+		}
+		ids := map[string]bool{}
+		for i, raw := range questions {
+			path := fmt.Sprintf("questions[%d]", i)
+			question, violation := decodeObject(raw, path)
+			if question == nil {
+				violations = append(violations, violation)
+				continue
+			}
+			violations = append(violations, checkQuestion(question, path, ids)...)
+		}
+		return violations
+	}
+}
+
+func checkQuestion(question map[string]json.RawMessage, path string, ids map[string]bool) []string {
+	violations := questionFields.check(question, path)
+
+	id, text, options := "", "", []string{}
+	idViolation := decodeField(question, path, "ques_id", &id)
+	textViolation := decodeField(question, path, "question", &text)
+	optionsViolation := decodeField(question, path, "options", &options)
+	violations = appendViolation(violations, idViolation)
+	violations = appendViolation(violations, textViolation)
+	violations = appendViolation(violations, optionsViolation)
+
+	// a field of the wrong type is only reported as invalid
+	if present(question, "ques_id") && idViolation == "" {
+		if id == "" {
+			violations = append(violations, fmt.Sprintf("empty %s.ques_id", path))
+		} else if ids[id] {
+			violations = append(violations, fmt.Sprintf("duplicate ques_id %q", id))
+		}
+		ids[id] = true
+	}
+	if present(question, "question") && textViolation == "" && strings.TrimSpace(text) == "" {
+		violations = append(violations, fmt.Sprintf("empty %s.question", path))
+	}
+	if present(question, "options") && optionsViolation == "" && len(options) == 0 {
+		violations = append(violations, fmt.Sprintf("empty %s.options", path))
+	}
+	seen := map[string]bool{}
+	for _, option := range options {
+		if strings.TrimSpace(option) == "" {
+			violations = append(violations, fmt.Sprintf("empty option in %s.options", path))
+			continue
+		}
+		if seen[option] {
+			violations = append(violations, fmt.Sprintf("duplicate option %q in %s.options", option, path))
+		}
+		seen[option] = true
+	}
+	return violations
+}
+
+var submitQuizFields = fields{required: []string{"score"}}
+
+func checkSubmitQuizContract(data []byte) []string {
+	object, violation := decodeObject(data, "response")
+	if object == nil {
+		return []string{violation}
+	}
+	violations := submitQuizFields.check(object, "")
+	score := 0
+	violations = appendViolation(violations, decodeField(object, "", "score", &score))
+	if score < 0 {
+		violations = append(violations, "negative score")
+	}
+	return violations
+}
+
+var (
+	getReportFields     = fields{required: []string{"success"}, optional: []string{"message", "data"}}
+	getReportDataFields = fields{optional: []string{"documentId", "fileName", "downloadUrl", "expiresAt", "contentBase64"}}
+)
+
+// checkGetReportContract checks the json document of a report, the pdf streamed as is has no contract
+func checkGetReportContract(data []byte) []string {
+	object, violation := decodeObject(data, "response")
+	if object == nil {
+		return []string{violation}
+	}
+	violations := getReportFields.check(object, "")
+	success := false
+	violations = appendViolation(violations, decodeField(object, "", "success", &success))
+	if present(object, "data") {
+		raw := object["data"]
+		reportData, violation := decodeObject(raw, "data")
+		if reportData == nil {
+			return append(violations, violation)
+		}
+		violations = append(violations, getReportDataFields.check(reportData, "data")...)
+		var document GetReportResponseData
+		if err := json.Unmarshal(raw, &document); err != nil {
+			violations = append(violations, `invalid field "data"`)
+		} else if success && document.ContentBase64 == "" && document.DownloadURL == "" {
+			violations = append(violations, "data has neither contentBase64 nor downloadUrl")
+		}
+	} else if success {
+		violations = append(violations, `missing field "data"`)
+	}
+	return violations
+}
+
+var emailStatusFields = fields{required: []string{"status"}, optional: []string{"message"}}
+
+func checkEmailStatusContract(data []byte) []string {
+	object, violation := decodeObject(data, "response")
+	if object == nil {
+		return []string{violation}
+	}
+	violations := emailStatusFields.check(object, "")
+	status := EmailReportStatus{}
+	violations = appendViolation(violations, decodeField(object, "", "status", &status.Status))
+	violations = appendViolation(violations, decodeField(object, "", "message", &status.Message))
+	if present(object, "status") && status.Status == "" {
+		violations = append(violations, "empty status")
+	}
+	return violations
+}
@@ -0,0 +1,147 @@
+package quizapi
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestContractQuizAPI(body string) *QuizAPI {
+	q := NewTestQuizAPI("http://example.com", "http://reportserver.com", func(req *http.Request) *http.Response {
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Body:       io.NopCloser(strings.NewReader(body)),
+			Header:     http.Header{"Content-Type": []string{"application/json"}},
+		}
+	})
+	q.SetContractValidation()
+	return q
+}
+
+func Test_quizapi_contract_CheckStartQuizContract(t *testing.T) {
+	tests := []struct {
+		name       string
+		body       string
+		violations []string
+	}{
+		{
+			"valid response",
+			`{"session_id":"1234","questions":[{"ques_id":"q1","question":"What is 2+2?","options":["3","4"]},{"ques_id":"q2","question":"Capital of France?","options":["Paris","Rome"]}]}`,
+			[]string{},
+		},
+		{
+			"not an object",
+			`[]`,
+			[]string{"response is not a json object"},
+		},
+		{
+			"missing and unknown fields",
+			`{"session_id":"1234","extra":1,"questions":[{"ques_id":"q1","question":"What is 2+2?","answer":"4"}]}`,
+			[]string{`unknown field "extra"`, `missing field "questions[0].options"`, `unknown field "questions[0].answer"`},
+		},
+		{
+			"other session",
+			`{"session_id":"5678","questions":[{"ques_id":"q1","question":"What is 2+2?","options":["3","4"]}]}`,
+			[]string{"session_id does not match the requested session"},
+		},
+		{
+			"empty questions",
+			`{"session_id":"1234","questions":[]}`,
+			[]string{"empty questions"},
+		},
+		{
+			"invalid questions",
+			`{"session_id":"1234","questions":[{"ques_id":"q1","question":" ","options":["3","3"]},{"ques_id":"q1","question":"What is 3+3?","options":[]}]}`,
+			[]string{
+				`empty questions[0].question`,
+				`duplicate option "3" in questions[0].options`,
+				`duplicate ques_id "q1"`,
+				`empty questions[1].options`,
+			},
+		},
+		{
+			"invalid field type",
+			`{"session_id":"1234","questions":[{"ques_id":1,"question":"What is 2+2?","options":["3","4"]}]}`,
+			[]string{`invalid field "questions[0].ques_id"`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			violations := checkStartQuizContract("1234")([]byte(tt.body))
+			assert.Equal(t, tt.violations, violations, "Expected the violations of the response")
+		})
+	}
+}
+
+func Test_quizapi_contract_CheckContracts(t *testing.T) {
+	tests := []struct {
+		name       string
+		check      contractCheck
+		body       string
+		violations []string
+	}{
+		{"create session", checkCreateSessionContract, `{"session_id":"1234","message":"created"}`, []string{}},
+		{"create session without id", checkCreateSessionContract, `{"session_id":"","message":"created"}`, []string{"empty session_id"}},
+		{"create session with null id", checkCreateSessionContract, `{"session_id":null}`, []string{`missing field "session_id"`}},
+		{"submit quiz", checkSubmitQuizContract, `{"score":3}`, []string{}},
+		{"submit quiz with unknown field", checkSubmitQuizContract, `{"score":3,"total":5}`, []string{`unknown field "total"`}},
+		{"submit quiz with invalid score", checkSubmitQuizContract, `{"score":"3"}`, []string{`invalid field "score"`}},
+		{"submit quiz with negative score", checkSubmitQuizContract, `{"score":-1}`, []string{"negative score"}},
+		{"get report", checkGetReportContract, `{"success":true,"data":{"fileName":"report.pdf","contentBase64":"JVBERi0="}}`, []string{}},
+		{"get report without content", checkGetReportContract, `{"success":true,"data":{"fileName":"report.pdf","size":10}}`, []string{`unknown field "data.size"`, "data has neither contentBase64 nor downloadUrl"}},
+		{"get report without data", checkGetReportContract, `{"success":true}`, []string{`missing field "data"`}},
+		{"get report failure", checkGetReportContract, `{"success":false,"message":"not found"}`, []string{}},
+		{"email status", checkEmailStatusContract, `{"status":"queued","message":"waiting"}`, []string{}},
+		{"email status without status", checkEmailStatusContract, `{"state":"queued"}`, []string{`missing field "status"`, `unknown field "state"`}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.violations, tt.check([]byte(tt.body)), "Expected the violations of the response")
+		})
+	}
+}
+
+func Test_quizapi_contract_StartQuiz_WhenContractViolation(t *testing.T) {
+	q := newTestContractQuizAPI(`{"session_id":"5678","questions":[{"ques_id":"q1","question":"What is 2+2?","options":["3","4"]}]}`)
+	observed := []RequestInfo{}
+	q.SetObserver(func(info RequestInfo) {
+		observed = append(observed, info)
+	})
+
+	questions, err := q.StartQuiz("1234", "math")
+	assert.Nil(t, questions, "Expected no questions for a response violating the contract")
+	var contractErr *ContractError
+	require.ErrorAs(t, err, &contractErr, "Expected a contract error")
+	assert.Equal(t, EndpointStartQuiz, contractErr.Endpoint, "Expected the endpoint of the violation")
+	assert.EqualError(t, err, "contract violation of start_quiz: session_id does not match the requested session", "Expected the violations in the error")
+
+	require.Len(t, observed, 1, "Expected the request to be observed")
+	assert.NoError(t, observed[0].Err, "Expected the request not to be observed as failed")
+}
+
+func Test_quizapi_contract_StartQuiz_WhenValid(t *testing.T) {
+	q := newTestContractQuizAPI(`{"session_id":"1234","questions":[{"ques_id":"q1","question":"What is 2+2?","options":["3","4"]}]}`)
+
+	questions, err := q.StartQuiz("1234", "math")
+	require.NoError(t, err, "Expected no error for a response matching the contract")
+	assert.Equal(t, []Question{{ID: "q1", Question: "What is 2+2?", Options: []string{"3", "4"}}}, questions, "Expected the questions to be parsed")
+}
+
+func Test_quizapi_contract_WhenNotValidated(t *testing.T) {
+	q := newTestContractQuizAPI(`{"session_id":"1234","message":"created","extra":true}`)
+	q.validateContracts = false
+
+	ssid, err := q.CreateSession("test@example.com", "go")
+	require.NoError(t, err, "Expected unknown fields to be ignored unless the contracts are validated")
+	assert.Equal(t, "1234", ssid, "Expected the session ID to be parsed")
+
+	q.SetContractValidation()
+	_, err = q.CreateSession("test@example.com", "go")
+	assert.EqualError(t, err, `contract violation of create_session: unknown field "extra"`, "Expected the unknown field to be a violation")
+}
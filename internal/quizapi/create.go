@@ -19,7 +19,11 @@ type CreateSessionAPIResponse struct {
 	Message   string `json:"message"`
 }
 
-func (q *QuizAPI) CreateSession(email, topic string) (string, error) {
+func (q *QuizAPI) CreateSession(email, topic string) (ssid string, err error) {
+	call := q.newCall(EndpointCreateSession, "", topic)
+	call.info.Email = email
+	defer func() { call.finish(err) }()
+
 	if err := validateCreateSessionInputs(email, topic); err != nil {
 		return "", err
 	}
@@ -30,7 +34,7 @@ func (q *QuizAPI) CreateSession(email, topic string) (string, error) {
 	}
 
 	// send the request
-	resp, err := q.client.Post(
+	resp, err := call.post(
 		q.endpoints.createSession,
 		"application/json",
 		body,
@@ -41,10 +45,16 @@ func (q *QuizAPI) CreateSession(email, topic string) (string, error) {
 	defer resp.Body.Close()
 
 	if err = validateCreateSessionAPIResponseStatus(resp); err != nil {
-		return "", err
+		return "", newStatusError(resp, err)
 	}
 
-	return parseCreateSessionAPIResponse(resp.Body)
+	respBody, err := q.checkContract(EndpointCreateSession, resp.Body, checkCreateSessionContract)
+	if err != nil {
+		return "", err
+	}
+	ssid, err = parseCreateSessionAPIResponse(respBody)
+	call.info.SessionID = ssid
+	return ssid, err
 }
 
 func isValidEmail(email string) bool {
@@ -13,11 +13,14 @@ type GetEmailReportErrorResponse struct {
 	StatusCode int    `json:"statusCode"`
 }
 
-func (q *QuizAPI) GetEmailReport(sessionID string) (string, error) {
+func (q *QuizAPI) GetEmailReport(sessionID string) (message string, err error) {
+	call := q.newCall(EndpointEmailReport, sessionID, "")
+	defer func() { call.finish(err) }()
+
 	reqUrl := buildGetEmailReportAPIURL(q.endpoints.getEmailReport, sessionID)
 
 	// send the request
-	resp, err := q.client.Post(reqUrl, "application/json", nil)
+	resp, err := call.post(reqUrl, "application/json", nil)
 	if err != nil {
 		return "", fmt.Errorf("failed to send request to get email report: %w", err)
 	}
@@ -26,9 +29,9 @@ func (q *QuizAPI) GetEmailReport(sessionID string) (string, error) {
 	if err := validateGetEmailReportResponseStatus(resp); err != nil {
 		errResp, err := parseGetEmailReportErrorResponse(resp.Body)
 		if err != nil {
-			return "", fmt.Errorf("failed to parse error response: %w", err)
+			return "", newStatusError(resp, fmt.Errorf("failed to parse error response: %w", err))
 		}
-		return "", errors.New(errResp)
+		return "", newStatusError(resp, errors.New(errResp))
 	}
 
 	return "Email report request accepted", nil
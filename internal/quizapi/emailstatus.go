@@ -0,0 +1,72 @@
+package quizapi
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// EmailReportStatus is the progress of an accepted email report request
+type EmailReportStatus struct {
+	Status  string `json:"status"`
+	Message string `json:"message"`
+}
+
+// Completed reports whether the email was sent
+func (s EmailReportStatus) Completed() bool {
+	switch strings.ToLower(s.Status) {
+	case "sent", "delivered", "completed", "done", "success":
+		return true
+	}
+	return false
+}
+
+// Failed reports whether the email will not be sent
+func (s EmailReportStatus) Failed() bool {
+	switch strings.ToLower(s.Status) {
+	case "failed", "error", "rejected", "cancelled":
+		return true
+	}
+	return false
+}
+
+func (q *QuizAPI) GetEmailReportStatus(sessionID string) (status EmailReportStatus, err error) {
+	call := q.newCall(EndpointEmailStatus, sessionID, "")
+	defer func() { call.finish(err) }()
+
+	reqUrl := buildGetEmailReportAPIURL(q.endpoints.getEmailStatus, sessionID)
+
+	resp, err := call.get(reqUrl)
+	if err != nil {
+		return EmailReportStatus{}, fmt.Errorf("failed to get email report status: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		errResp, err := parseGetEmailReportErrorResponse(resp.Body)
+		if err != nil {
+			return EmailReportStatus{}, newStatusError(resp, fmt.Errorf("failed to get email report status, status code: %d", resp.StatusCode))
+		}
+		return EmailReportStatus{}, newStatusError(resp, errors.New(errResp))
+	}
+
+	body, err := q.checkContract(EndpointEmailStatus, resp.Body, checkEmailStatusContract)
+	if err != nil {
+		return EmailReportStatus{}, err
+	}
+	return parseEmailReportStatusResponse(body)
+}
+
+func parseEmailReportStatusResponse(body io.ReadCloser) (EmailReportStatus, error) {
+	var status EmailReportStatus
+	if err := json.NewDecoder(body).Decode(&status); err != nil {
+		return EmailReportStatus{}, fmt.Errorf("failed to parse response body: %w", err)
+	}
+	if status.Status == "" {
+		return EmailReportStatus{}, fmt.Errorf("email report status response should have a status")
+	}
+	return status, nil
+}
@@ -0,0 +1,93 @@
+package quizapi
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_quizapi_emailstatus_EmailReportStatus(t *testing.T) {
+	tests := []struct {
+		status    string
+		completed bool
+		failed    bool
+	}{
+		{"pending", false, false},
+		{"processing", false, false},
+		{"SENT", true, false},
+		{"delivered", true, false},
+		{"failed", false, true},
+		{"error", false, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.status, func(t *testing.T) {
+			status := EmailReportStatus{Status: tt.status}
+			assert.Equal(t, tt.completed, status.Completed(), "Expected the status %s to be completed: %v", tt.status, tt.completed)
+			assert.Equal(t, tt.failed, status.Failed(), "Expected the status %s to be failed: %v", tt.status, tt.failed)
+		})
+	}
+}
+
+func Test_quizapi_emailstatus_GetEmailReportStatus_WhenSuccess(t *testing.T) {
+	q := NewTestQuizAPI("http://localhost:3000", "http://localhost:3001", func(req *http.Request) *http.Response {
+		assert.Equal(t, http.MethodGet, req.Method, "Expected a GET request")
+		assert.Equal(t, "http://localhost:3001/sessions/12345/email-report/status", req.URL.String(), "Expected the email status endpoint")
+		return &http.Response{
+			StatusCode: 200,
+			Body:       io.NopCloser(strings.NewReader(`{"status": "sent", "message": "email sent to user@example.com"}`)),
+		}
+	})
+	var observed []RequestInfo
+	q.SetObserver(func(info RequestInfo) {
+		observed = append(observed, info)
+	})
+
+	status, err := q.GetEmailReportStatus("12345")
+	require.NoError(t, err, "Expected no error while getting the email status")
+	assert.Equal(t, EmailReportStatus{Status: "sent", Message: "email sent to user@example.com"}, status, "Expected the email status")
+	require.Len(t, observed, 1, "Expected the request to be observed")
+	assert.Equal(t, EndpointEmailStatus, observed[0].Endpoint, "Expected the request to be labelled with the email status endpoint")
+}
+
+func Test_quizapi_emailstatus_GetEmailReportStatus_WhenError(t *testing.T) {
+	tests := []struct {
+		name       string
+		statusCode int
+		body       string
+		message    string
+	}{
+		{"error response", 404, `{"message": "email report not found", "statusCode": 404}`, "email report not found"},
+		{"invalid error response", 500, `internal error`, "status code: 500"},
+		{"missing status", 200, `{"message": "ok"}`, "should have a status"},
+		{"invalid response", 200, `{"status":`, "failed to parse response body"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q := NewTestQuizAPI("http://localhost:3000", "http://localhost:3001", func(req *http.Request) *http.Response {
+				return &http.Response{
+					StatusCode: tt.statusCode,
+					Body:       io.NopCloser(strings.NewReader(tt.body)),
+				}
+			})
+
+			_, err := q.GetEmailReportStatus("12345")
+			require.Error(t, err, "Expected an error while getting the email status")
+			assert.Contains(t, err.Error(), tt.message, "Expected the reason in the error")
+		})
+	}
+}
+
+func Test_quizapi_emailstatus_GetEmailReportStatus_WhenNetworkError(t *testing.T) {
+	q := NewTestQuizAPI("http://localhost:3000", "http://localhost:3001", func(req *http.Request) *http.Response {
+		return nil
+	})
+
+	_, err := q.GetEmailReportStatus("12345")
+	assert.Error(t, err, "Expected a network error while getting the email status")
+}
@@ -33,3 +33,8 @@ func (m *MockQuizAPI) GetEmailReport(sessionId string) (string, error) {
 	args := m.Called(sessionId)
 	return args.String(0), args.Error(1)
 }
+
+func (m *MockQuizAPI) GetEmailReportStatus(sessionId string) (quizapi.EmailReportStatus, error) {
+	args := m.Called(sessionId)
+	return args.Get(0).(quizapi.EmailReportStatus), args.Error(1)
+}
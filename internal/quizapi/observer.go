@@ -0,0 +1,150 @@
+package quizapi
+
+import (
+	"io"
+	"net/http"
+	"time"
+)
+
+// names of the quiz api endpoints, used to label the observed requests
+const (
+	EndpointCreateSession = "create_session"
+	EndpointStartQuiz     = "start_quiz"
+	EndpointSubmitQuiz    = "submit_quiz"
+	EndpointGetReport     = "get_report"
+	EndpointEmailReport   = "email_report"
+	// only polled when enabled, so it is not listed in Endpoints
+	EndpointEmailStatus = "email_status"
+)
+
+// Endpoints lists the quiz api endpoints in the order they are called in a session
+var Endpoints []string = []string{
+	EndpointCreateSession,
+	EndpointStartQuiz,
+	EndpointSubmitQuiz,
+	EndpointGetReport,
+	EndpointEmailReport,
+}
+
+// RequestInfo describes a single request sent by the QuizAPI
+type RequestInfo struct {
+	Endpoint   string
+	SessionID  string
+	Topic      string
+	Method     string
+	URL        string
+	StatusCode int
+	StartTime  time.Time
+	Duration   time.Duration
+	Bytes      int64
+	Err        error
+	// email of the user, only set for create_session as the session has no ID until created
+	Email string
+	// request and response as sent and received, only set when the capture is enabled
+	Exchange *Exchange
+}
+
+// Observer is called once for every request sent by the QuizAPI,
+// after the response has been processed
+type Observer func(info RequestInfo)
+
+func (q *QuizAPI) SetObserver(observer Observer) {
+	q.observer = observer
+}
+
+// call tracks a request to one of the endpoints, to report it to the observer
+type call struct {
+	q    *QuizAPI
+	info RequestInfo
+	sent bool
+	body *countingReadCloser
+	// captured request and response, nil unless the capture is enabled
+	exchange *Exchange
+}
+
+func (q *QuizAPI) newCall(endpoint, sessionID, topic string) *call {
+	return &call{
+		q: q,
+		info: RequestInfo{
+			Endpoint:  endpoint,
+			SessionID: sessionID,
+			Topic:     topic,
+		},
+	}
+}
+
+func (c *call) do(req *http.Request) (*http.Response, error) {
+	c.sent = true
+	c.info.Method = req.Method
+	c.info.URL = req.URL.String()
+	c.info.StartTime = time.Now()
+	if c.q.capture {
+		c.exchange = captureRequest(c.info.Endpoint, req, c.q.captureBodyLimit)
+	}
+
+	resp, err := c.q.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	c.info.StatusCode = resp.StatusCode
+	if c.exchange != nil {
+		resp.Body = c.exchange.captureResponse(resp, c.q.captureBodyLimit)
+	}
+	c.body = &countingReadCloser{ReadCloser: resp.Body}
+	resp.Body = c.body
+	return resp, nil
+}
+
+func (c *call) post(url, contentType string, body io.Reader) (*http.Response, error) {
+	req, err := http.NewRequest(http.MethodPost, url, body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", contentType)
+	return c.do(req)
+}
+
+func (c *call) get(url string) (*http.Response, error) {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	return c.do(req)
+}
+
+// finish reports the call to the observer, calls that never sent a request are not reported
+func (c *call) finish(err error) {
+	if !c.sent || c.q.observer == nil {
+		return
+	}
+	c.info.Duration = time.Since(c.info.StartTime)
+	if c.body != nil {
+		c.info.Bytes = c.body.n
+	}
+	// an invalid response is a correctness error, not a failed request
+	if !isCorrectnessError(err) {
+		c.info.Err = err
+	}
+	if c.exchange != nil {
+		c.exchange.StartTime = c.info.StartTime
+		c.exchange.DurationMs = float64(c.info.Duration.Microseconds()) / 1000
+		// the capture keeps every error, to reproduce the invalid responses too
+		if err != nil {
+			c.exchange.Error = err.Error()
+		}
+		c.info.Exchange = c.exchange
+	}
+	c.q.observer(c.info)
+}
+
+// countingReadCloser counts the bytes read from the response body
+type countingReadCloser struct {
+	io.ReadCloser
+	n int64
+}
+
+func (r *countingReadCloser) Read(p []byte) (int, error) {
+	n, err := r.ReadCloser.Read(p)
+	r.n += int64(n)
+	return n, err
+}
@@ -0,0 +1,99 @@
+package quizapi
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_quizapi_observer_SetObserver_WhenSuccess(t *testing.T) {
+	body := `{"session_id": "12345", "message": "created"}`
+	q := NewTestQuizAPI("http://localhost:3000", "http://localhost:3001", func(req *http.Request) *http.Response {
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Body:       io.NopCloser(strings.NewReader(body)),
+		}
+	})
+
+	observed := []RequestInfo{}
+	q.SetObserver(func(info RequestInfo) {
+		observed = append(observed, info)
+	})
+
+	ssid, err := q.CreateSession("test@example.com", "go")
+	require.NoError(t, err, "Expected no error while creating session")
+
+	require.Len(t, observed, 1, "Expected the observer to be called once per request")
+	info := observed[0]
+	assert.Equal(t, EndpointCreateSession, info.Endpoint, "Expected the endpoint name to be create_session")
+	assert.Equal(t, ssid, info.SessionID, "Expected the observed session ID to be the created session ID")
+	assert.Equal(t, "go", info.Topic, "Expected the observed topic to match")
+	assert.Equal(t, http.MethodPost, info.Method, "Expected the observed method to be POST")
+	assert.Equal(t, "http://localhost:3000/session/create", info.URL, "Expected the observed url to match the endpoint")
+	assert.Equal(t, http.StatusOK, info.StatusCode, "Expected the observed status code to be 200")
+	assert.Equal(t, int64(len(body)), info.Bytes, "Expected the observed bytes to be the response body size")
+	assert.False(t, info.StartTime.IsZero(), "Expected the start time to be set")
+	assert.NoError(t, info.Err, "Expected no error to be observed")
+}
+
+func Test_quizapi_observer_SetObserver_WhenErrorStatus(t *testing.T) {
+	q := NewTestQuizAPI("http://localhost:3000", "http://localhost:3001", func(req *http.Request) *http.Response {
+		return &http.Response{
+			StatusCode: http.StatusInternalServerError,
+			Body:       io.NopCloser(strings.NewReader("")),
+		}
+	})
+
+	observed := []RequestInfo{}
+	q.SetObserver(func(info RequestInfo) {
+		observed = append(observed, info)
+	})
+
+	_, err := q.SubmitQuiz("12345", []Answer{{QuestionID: "q1", Answer: "a"}})
+	require.Error(t, err, "Expected an error for status 500")
+
+	require.Len(t, observed, 1, "Expected the observer to be called once per request")
+	assert.Equal(t, EndpointSubmitQuiz, observed[0].Endpoint, "Expected the endpoint name to be submit_quiz")
+	assert.Equal(t, "12345", observed[0].SessionID, "Expected the observed session ID to match")
+	assert.Equal(t, http.StatusInternalServerError, observed[0].StatusCode, "Expected the observed status code to be 500")
+	assert.Equal(t, err, observed[0].Err, "Expected the observed error to be the returned error")
+}
+
+func Test_quizapi_observer_SetObserver_WhenInvalidInputs(t *testing.T) {
+	q := NewTestQuizAPI("http://localhost:3000", "http://localhost:3001", func(req *http.Request) *http.Response {
+		t.Fatal("Expected no request to be sent for invalid inputs")
+		return nil
+	})
+
+	called := false
+	q.SetObserver(func(info RequestInfo) {
+		called = true
+	})
+
+	_, err := q.StartQuiz("", "go")
+	require.Error(t, err, "Expected an error for an empty session ID")
+	assert.False(t, called, "Expected the observer not to be called when no request was sent")
+}
+
+func Test_quizapi_observer_SetObserver_WhenNetworkError(t *testing.T) {
+	q := NewTestQuizAPI("http://localhost:3000", "http://localhost:3001", func(req *http.Request) *http.Response {
+		return nil
+	})
+
+	observed := []RequestInfo{}
+	q.SetObserver(func(info RequestInfo) {
+		observed = append(observed, info)
+	})
+
+	_, err := q.GetEmailReport("12345")
+	require.Error(t, err, "Expected a network error")
+
+	require.Len(t, observed, 1, "Expected the observer to be called for failed requests")
+	assert.Equal(t, EndpointEmailReport, observed[0].Endpoint, "Expected the endpoint name to be email_report")
+	assert.Equal(t, 0, observed[0].StatusCode, "Expected no status code for a network error")
+	assert.Error(t, observed[0].Err, "Expected the network error to be observed")
+}
@@ -3,6 +3,8 @@ package quizapi
 import (
 	"net/http"
 	"time"
+
+	"github.com/go-squad-5/quiz-load-test/internal/sampler"
 )
 
 type IQuizAPI interface {
@@ -11,11 +13,25 @@ type IQuizAPI interface {
 	SubmitQuiz(sessionId string, answers []Answer) (int, error) // Score, error
 	GetReport(sessionId string) (string, error)
 	GetEmailReport(sessionId string) (string, error)
+	GetEmailReportStatus(sessionId string) (EmailReportStatus, error)
 }
 
 type QuizAPI struct {
 	client    *http.Client
 	endpoints endpoints
+	observer  Observer
+	// reports are checked to be valid pdf documents when enabled
+	validateReports bool
+	reportMinSize   int
+	reportCheck     ReportCheck
+	// reports saved to the reports directory, every report is saved unless set
+	reportStorage ReportStorage
+	reportSample  *sampler.Sampler
+	// successful responses are checked strictly against the contract of their endpoint when enabled
+	validateContracts bool
+	// requests and responses are captured for the observer when enabled
+	capture          bool
+	captureBodyLimit int
 }
 
 type endpoints struct {
@@ -24,6 +40,7 @@ type endpoints struct {
 	submitQuiz     string
 	getReport      string
 	getEmailReport string
+	getEmailStatus string
 }
 
 func NewQuizAPI(baseUrl, reportServerBaseUrl string) *QuizAPI {
@@ -37,6 +54,13 @@ func NewQuizAPI(baseUrl, reportServerBaseUrl string) *QuizAPI {
 			submitQuiz:     baseUrl + "/quiz/submit",
 			getReport:      reportServerBaseUrl + "/sessions/%s/report",
 			getEmailReport: reportServerBaseUrl + "/sessions/%s/email-report",
+			getEmailStatus: reportServerBaseUrl + "/sessions/%s/email-report/status",
 		},
 	}
 }
+
+// SetReportValidation checks the reports returned by GetReport are pdf documents of at least the given size
+func (q *QuizAPI) SetReportValidation(minSize int) {
+	q.validateReports = true
+	q.reportMinSize = minSize
+}
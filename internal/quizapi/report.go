@@ -1,14 +1,22 @@
 package quizapi
 
 import (
+	"bytes"
+	"crypto/sha256"
 	"encoding/base64"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
-
 	"fmt"
 	"io"
+	"mime"
 	"net/http"
+	"net/url"
 	"os"
+	"strings"
+	"time"
+
+	"github.com/go-squad-5/quiz-load-test/internal/pdfcheck"
 )
 
 type GetReportResponseData struct {
@@ -30,10 +38,19 @@ type GetReportErrorResponse struct {
 	Message    string `json:"message"`
 }
 
-func (q *QuizAPI) GetReport(sessionID string) (string, error) {
+func (q *QuizAPI) GetReport(sessionID string) (report string, err error) {
+	call := q.newCall(EndpointGetReport, sessionID, "")
+	defer func() { call.finish(err) }()
+
 	reqUrl := buildGetReportAPIURL(q.endpoints.getReport, sessionID)
 
-	resp, err := q.client.Get(reqUrl)
+	req, err := http.NewRequest(http.MethodGet, reqUrl, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to create request: %w", err)
+	}
+	// the report server streams the pdf or answers with the json document, see saveReport
+	req.Header.Set("Accept", "application/pdf, application/json")
+	resp, err := call.do(req)
 	if err != nil {
 		return "", fmt.Errorf("failed to get report: %w", err)
 	}
@@ -42,21 +59,70 @@ func (q *QuizAPI) GetReport(sessionID string) (string, error) {
 	if err := validateGetReportResponseStatus(resp); err != nil {
 		errResp, err := parseGetReportErrorResponse(resp.Body)
 		if err != nil {
-			return "", fmt.Errorf("failed to parse error response: %w", err)
+			return "", newStatusError(resp, fmt.Errorf("failed to parse error response: %w", err))
 		}
-		return "", errors.New(errResp)
+		return "", newStatusError(resp, errors.New(errResp))
 	}
 
+	// the report is written to the file when saved, to the hash to identify it, and to memory to be checked
+	hash := sha256.New()
+	writers := []io.Writer{hash}
+	filePath := ""
+	if q.saveReportFile() {
+		file, path, err := openSessionReportFile(sessionID)
+		if err != nil {
+			return "", fmt.Errorf("failed to open session report file: %w", err)
+		}
+		defer file.Close()
+		filePath = path
+		writers = append(writers, file)
+	}
+	var data bytes.Buffer
+	if q.keepReportData() {
+		writers = append(writers, &data)
+	}
+
+	contentType, err := q.saveReport(resp, reqUrl, io.MultiWriter(writers...))
+	if err != nil {
+		return "", err
+	}
+
+	err = q.checkReport(sessionID, contentType, data.Bytes())
+	if err != nil && filePath == "" && q.reportStorage == ReportStorageFailures {
+		// the invalid report is saved for inspection
+		if path, saveErr := saveReportData(sessionID, data.Bytes()); saveErr == nil {
+			filePath = path
+		}
+	}
+	if filePath == "" {
+		// a report which is not saved is identified by its hash
+		return "sha256:" + hex.EncodeToString(hash.Sum(nil)), err
+	}
+	return filePath, err
+}
+
+// checkReport validates the format of the report, then runs the report check
+func (q *QuizAPI) checkReport(sessionID, contentType string, data []byte) error {
+	if q.validateReports {
+		if err := pdfcheck.CheckFormat(contentType, data, q.reportMinSize); err != nil {
+			return err
+		}
+	}
+	if q.reportCheck != nil {
+		return q.reportCheck(sessionID, data)
+	}
+	return nil
+}
+
+func saveReportData(sessionID string, data []byte) (string, error) {
 	file, filePath, err := openSessionReportFile(sessionID)
 	if err != nil {
 		return "", fmt.Errorf("failed to open session report file: %w", err)
 	}
 	defer file.Close()
-
-	if err := saveResponseToFile(resp, file); err != nil {
-		return "", err
+	if _, err := file.Write(data); err != nil {
+		return "", fmt.Errorf("failed to write file: %w", err)
 	}
-
 	return filePath, nil
 }
 
@@ -102,23 +168,92 @@ func openSessionReportFile(sessionId string) (*os.File, string, error) {
 	return file, filePath, nil
 }
 
-func saveResponseToFile(resp *http.Response, file *os.File) error {
+func saveResponseToFile(resp *http.Response, file io.Writer) error {
 	if _, err := io.Copy(file, resp.Body); err != nil {
 		return fmt.Errorf("failed to write file: %w", err)
 	}
 	return nil
 }
 
-// NOTE: if json - base64 response (as per API docs, we have two possible response formats)
-//
-// respJson, err := parseJsonGetReportResponse(resp.Body)
-// if err != nil {
-//   return "", fmt.Errorf("failed to parse JSON response: %w", err)
-// }
-//
-// if err := decodeAndSaveBase64Response(respJson.Data.ContentBase64, file); err != nil {
-// 	 return "", fmt.Errorf("failed to parse base64 response: %w", err)
-// }
+// saveReport writes the report of the response and returns the content type of the report.
+// As per the API docs there are two possible response formats, told apart by the content type:
+// the pdf streamed as is, or a json document with the pdf in base64 or a url to download it from
+func (q *QuizAPI) saveReport(resp *http.Response, reqUrl string, file io.Writer) (string, error) {
+	contentType := resp.Header.Get("Content-Type")
+	if !isJsonContentType(contentType) {
+		if err := saveResponseToFile(resp, file); err != nil {
+			return "", err
+		}
+		return contentType, nil
+	}
+
+	body, err := q.checkContract(EndpointGetReport, resp.Body, checkGetReportContract)
+	if err != nil {
+		return "", err
+	}
+	respJson, err := parseJsonGetReportResponse(body)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse JSON response: %w", err)
+	}
+	if !respJson.Success {
+		return "", fmt.Errorf("failed to get report, message: %s", respJson.Message)
+	}
+
+	if respJson.Data.ContentBase64 != "" {
+		if err := decodeAndSaveBase64Response(respJson.Data.ContentBase64, file); err != nil {
+			return "", fmt.Errorf("failed to parse base64 response: %w", err)
+		}
+		// the inlined content is the pdf document itself
+		return "application/pdf", nil
+	}
+	if respJson.Data.DownloadURL == "" {
+		return "", fmt.Errorf("report response has neither content nor download url")
+	}
+	return q.downloadReport(reqUrl, respJson.Data, file)
+}
+
+func isJsonContentType(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	return err == nil && (mediaType == "application/json" || strings.HasSuffix(mediaType, "+json"))
+}
+
+// downloadReport follows the download url of a report which is not inlined, a relative url is resolved against the report endpoint
+func (q *QuizAPI) downloadReport(reqUrl string, data GetReportResponseData, file io.Writer) (string, error) {
+	if data.ExpiresAt != "" {
+		// expiry dates in another format are not checked, the download then fails on its own
+		if expiresAt, err := time.Parse(time.RFC3339, data.ExpiresAt); err == nil && time.Now().After(expiresAt) {
+			return "", fmt.Errorf("report download url expired at %s", data.ExpiresAt)
+		}
+	}
+
+	base, err := url.Parse(reqUrl)
+	if err != nil {
+		return "", fmt.Errorf("invalid report url: %w", err)
+	}
+	ref, err := url.Parse(data.DownloadURL)
+	if err != nil {
+		return "", fmt.Errorf("invalid report download url: %w", err)
+	}
+
+	req, err := http.NewRequest(http.MethodGet, base.ResolveReference(ref).String(), nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to create download request: %w", err)
+	}
+	req.Header.Set("Accept", "application/pdf")
+	resp, err := q.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to download report: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("failed to download report, status code: %d", resp.StatusCode)
+	}
+	if err := saveResponseToFile(resp, file); err != nil {
+		return "", err
+	}
+	return resp.Header.Get("Content-Type"), nil
+}
 
 func parseJsonGetReportResponse(respBody io.ReadCloser) (GetReportResponse, error) {
 	var reportResp GetReportResponse
@@ -128,7 +263,7 @@ func parseJsonGetReportResponse(respBody io.ReadCloser) (GetReportResponse, erro
 	return reportResp, nil
 }
 
-func decodeAndSaveBase64Response(base64String string, file *os.File) error {
+func decodeAndSaveBase64Response(base64String string, file io.Writer) error {
 	binaryData, err := base64.StdEncoding.DecodeString(base64String)
 	if err != nil {
 		return fmt.Errorf("failed to decode base64 string: %w", err)
@@ -2,6 +2,7 @@ package quizapi
 
 import (
 	"encoding/base64"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -10,7 +11,9 @@ import (
 	"strings"
 	"sync"
 	"testing"
+	"time"
 
+	"github.com/go-squad-5/quiz-load-test/internal/pdfcheck"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -417,6 +420,154 @@ func Test_quizapi_report_GetReport_WhenSuccess(t *testing.T) {
 	assert.Equalf(t, filePath, fmt.Sprintf("%s/%s_report.pdf", reportsDirPath, ssid), "Expected report file path to match %s/%s_report.pdf, but got %s", reportsDirPath, ssid, filePath)
 }
 
+func Test_quizapi_report_GetReport_WhenValidPDF(t *testing.T) {
+	reportsDirPath = "../../tmp/reports"
+	pdf := "%PDF-1.7\n" + strings.Repeat("0", 100) + "\n%%EOF\n"
+
+	q := NewTestQuizAPI("http://localhost:3000", "http://localhost:3001", func(req *http.Request) *http.Response {
+		return &http.Response{
+			StatusCode: 200,
+			Header:     http.Header{"Content-Type": []string{"application/pdf"}},
+			Body:       io.NopCloser(strings.NewReader(pdf)),
+		}
+	})
+	q.SetReportValidation(100)
+
+	filePath, err := q.GetReport("valid-pdf")
+	require.NoError(t, err, "Expected a valid pdf report")
+	defer os.Remove(filePath)
+	content, err := os.ReadFile(filePath)
+	require.NoError(t, err, "Expected the report to be saved")
+	assert.Equal(t, pdf, string(content), "Expected the report content to be saved")
+}
+
+func Test_quizapi_report_GetReport_WhenInvalidPDF(t *testing.T) {
+	reportsDirPath = "../../tmp/reports"
+
+	q := NewTestQuizAPI("http://localhost:3000", "http://localhost:3001", func(req *http.Request) *http.Response {
+		return &http.Response{
+			StatusCode: 200,
+			Header:     http.Header{"Content-Type": []string{"text/html"}},
+			Body:       io.NopCloser(strings.NewReader("<html>maintenance</html>")),
+		}
+	})
+	q.SetReportValidation(100)
+	var observed []RequestInfo
+	q.SetObserver(func(info RequestInfo) {
+		observed = append(observed, info)
+	})
+
+	filePath, err := q.GetReport("invalid-pdf")
+	defer os.Remove(filePath)
+	require.Error(t, err, "Expected an invalid pdf report")
+	var pdfErr *pdfcheck.Error
+	assert.True(t, errors.As(err, &pdfErr), "Expected a report correctness error")
+	assert.NotEmpty(t, filePath, "Expected the invalid report to be saved for inspection")
+	require.Len(t, observed, 1, "Expected the request to be observed")
+	assert.NoError(t, observed[0].Err, "Expected the request itself not to be failed")
+}
+
+func Test_quizapi_report_GetReport_WhenJsonBase64(t *testing.T) {
+	reportsDirPath = "../../tmp/reports"
+	pdf := "%PDF-1.7\n" + strings.Repeat("0", 100) + "\n%%EOF\n"
+	body := fmt.Sprintf(`{"success":true,"message":"report generated","data":{"documentId":"1","contentBase64":"%s"}}`, base64.StdEncoding.EncodeToString([]byte(pdf)))
+
+	q := NewTestQuizAPI("http://localhost:3000", "http://localhost:3001", func(req *http.Request) *http.Response {
+		assert.Equal(t, "application/pdf, application/json", req.Header.Get("Accept"), "Expected both report formats to be accepted")
+		return &http.Response{
+			StatusCode: 200,
+			Header:     http.Header{"Content-Type": []string{"application/json; charset=utf-8"}},
+			Body:       io.NopCloser(strings.NewReader(body)),
+		}
+	})
+	q.SetReportValidation(100)
+
+	filePath, err := q.GetReport("json-base64")
+	require.NoError(t, err, "Expected the base64 report to be saved")
+	defer os.Remove(filePath)
+	content, err := os.ReadFile(filePath)
+	require.NoError(t, err, "Expected the report to be saved")
+	assert.Equal(t, pdf, string(content), "Expected the decoded report to be saved")
+}
+
+func Test_quizapi_report_GetReport_WhenJsonDownloadURL(t *testing.T) {
+	reportsDirPath = "../../tmp/reports"
+	pdf := "%PDF-1.7\n" + strings.Repeat("0", 100) + "\n%%EOF\n"
+	expiresAt := time.Now().Add(time.Hour).Format(time.RFC3339)
+
+	var requested []string
+	q := NewTestQuizAPI("http://localhost:3000", "http://localhost:3001", func(req *http.Request) *http.Response {
+		requested = append(requested, req.URL.String())
+		if req.URL.Path == "/documents/1.pdf" {
+			return &http.Response{
+				StatusCode: 200,
+				Header:     http.Header{"Content-Type": []string{"application/pdf"}},
+				Body:       io.NopCloser(strings.NewReader(pdf)),
+			}
+		}
+		return &http.Response{
+			StatusCode: 200,
+			Header:     http.Header{"Content-Type": []string{"application/json"}},
+			Body:       io.NopCloser(strings.NewReader(`{"success":true,"data":{"documentId":"1","downloadUrl":"/documents/1.pdf","expiresAt":"` + expiresAt + `"}}`)),
+		}
+	})
+	q.SetReportValidation(100)
+	var observed []RequestInfo
+	q.SetObserver(func(info RequestInfo) {
+		observed = append(observed, info)
+	})
+
+	filePath, err := q.GetReport("json-download")
+	require.NoError(t, err, "Expected the report to be downloaded")
+	defer os.Remove(filePath)
+	content, err := os.ReadFile(filePath)
+	require.NoError(t, err, "Expected the report to be saved")
+	assert.Equal(t, pdf, string(content), "Expected the downloaded report to be saved")
+	assert.Equal(t, []string{
+		"http://localhost:3001/sessions/json-download/report",
+		"http://localhost:3001/documents/1.pdf",
+	}, requested, "Expected the relative download url to be resolved against the report endpoint")
+	assert.Len(t, observed, 1, "Expected the download to be part of the get report request")
+}
+
+func Test_quizapi_report_GetReport_WhenJsonWithoutReport(t *testing.T) {
+	reportsDirPath = "../../tmp/reports"
+	expired := time.Now().Add(-time.Hour).Format(time.RFC3339)
+
+	tests := []struct {
+		name    string
+		body    string
+		message string
+	}{
+		{"unsuccessful", `{"success":false,"message":"report not ready","data":{}}`, "report not ready"},
+		{"no content", `{"success":true,"data":{"documentId":"1"}}`, "neither content nor download url"},
+		{"invalid base64", `{"success":true,"data":{"contentBase64":"not base64"}}`, "failed to parse base64 response"},
+		{"expired url", `{"success":true,"data":{"downloadUrl":"/documents/1.pdf","expiresAt":"` + expired + `"}}`, "expired"},
+		{"download failed", `{"success":true,"data":{"downloadUrl":"http://localhost:3002/documents/1.pdf"}}`, "failed to download report, status code: 404"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q := NewTestQuizAPI("http://localhost:3000", "http://localhost:3001", func(req *http.Request) *http.Response {
+				if req.URL.Host == "localhost:3002" {
+					return &http.Response{StatusCode: 404, Body: io.NopCloser(strings.NewReader("not found"))}
+				}
+				return &http.Response{
+					StatusCode: 200,
+					Header:     http.Header{"Content-Type": []string{"application/json"}},
+					Body:       io.NopCloser(strings.NewReader(tt.body)),
+				}
+			})
+
+			filePath, err := q.GetReport("json-without-report")
+			defer os.Remove(fmt.Sprintf("%s/json-without-report_report.pdf", reportsDirPath))
+			require.Error(t, err, "Expected an error without a report")
+			assert.Empty(t, filePath, "Expected no report file path")
+			assert.Contains(t, err.Error(), tt.message, "Expected the reason in the error")
+		})
+	}
+}
+
 func Test_quizapi_report_GetReport_WhenError(t *testing.T) {
 	reportsDirPath = "../../tmp/reports"
 	baseUrl := "http://localhost:3000"
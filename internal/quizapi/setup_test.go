@@ -23,6 +23,7 @@ func NewTestQuizAPI(baseUrl, reportServerBaseUrl string, transportFunc RoundTrip
 			submitQuiz:     baseUrl + "/quiz/submit",
 			getReport:      reportServerBaseUrl + "/sessions/%s/report",
 			getEmailReport: reportServerBaseUrl + "/sessions/%s/email-report",
+			getEmailStatus: reportServerBaseUrl + "/sessions/%s/email-report/status",
 		},
 	}
 }
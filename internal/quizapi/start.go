@@ -24,7 +24,10 @@ type Question struct {
 	Options  []string `json:"options"`
 }
 
-func (q *QuizAPI) StartQuiz(sessionId, topic string) ([]Question, error) {
+func (q *QuizAPI) StartQuiz(sessionId, topic string) (questions []Question, err error) {
+	call := q.newCall(EndpointStartQuiz, sessionId, topic)
+	defer func() { call.finish(err) }()
+
 	if err := validateStartQuizInputs(sessionId, topic); err != nil {
 		return nil, err
 	}
@@ -35,7 +38,7 @@ func (q *QuizAPI) StartQuiz(sessionId, topic string) ([]Question, error) {
 	}
 
 	// Send Request
-	resp, err := q.client.Post(
+	resp, err := call.post(
 		q.endpoints.startQuiz,
 		"application/json",
 		body,
@@ -46,10 +49,14 @@ func (q *QuizAPI) StartQuiz(sessionId, topic string) ([]Question, error) {
 	defer resp.Body.Close()
 
 	if err := validateStartQuizAPIStatus(resp); err != nil {
-		return nil, err
+		return nil, newStatusError(resp, err)
 	}
 
-	return parseStartQuizResponse(resp.Body)
+	respBody, err := q.checkContract(EndpointStartQuiz, resp.Body, checkStartQuizContract(sessionId))
+	if err != nil {
+		return nil, err
+	}
+	return parseStartQuizResponse(respBody)
 }
 
 func validateStartQuizInputs(sessionId, topic string) error {
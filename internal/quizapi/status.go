@@ -0,0 +1,33 @@
+package quizapi
+
+import (
+	"errors"
+	"net/http"
+)
+
+// StatusError is the error of a response with an unexpected status code, its message is the message of the wrapped error
+type StatusError struct {
+	StatusCode int
+	Err        error
+}
+
+func (e *StatusError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *StatusError) Unwrap() error {
+	return e.Err
+}
+
+// StatusCode returns the status code of the response rejected with the error, 0 if the error is not a rejected response
+func StatusCode(err error) int {
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.StatusCode
+	}
+	return 0
+}
+
+func newStatusError(resp *http.Response, err error) error {
+	return &StatusError{StatusCode: resp.StatusCode, Err: err}
+}
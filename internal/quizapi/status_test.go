@@ -0,0 +1,27 @@
+package quizapi
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_quizapi_status_StatusCode(t *testing.T) {
+	q := NewTestQuizAPI("http://example.com", "http://reportserver.com", func(req *http.Request) *http.Response {
+		return &http.Response{
+			StatusCode: http.StatusConflict,
+			Body:       io.NopCloser(strings.NewReader(`{"message":"already submitted"}`)),
+		}
+	})
+
+	_, err := q.SubmitQuiz("1234", []Answer{{QuestionID: "q1", Answer: "4"}})
+	assert.EqualError(t, err, "failed to submit quiz, status code: 409", "Expected the message of the status error")
+	assert.Equal(t, http.StatusConflict, StatusCode(err), "Expected the status code of the rejected request")
+	assert.Equal(t, http.StatusConflict, StatusCode(fmt.Errorf("wrapped: %w", err)), "Expected the status code of a wrapped error")
+	assert.Equal(t, 0, StatusCode(errors.New("connection refused")), "Expected no status code without a response")
+}
@@ -0,0 +1,56 @@
+package quizapi
+
+import (
+	"fmt"
+
+	"github.com/go-squad-5/quiz-load-test/internal/sampler"
+)
+
+// ReportStorage tells which reports GetReport saves to the reports directory,
+// the reports which are not saved are only counted and hashed
+type ReportStorage string
+
+const (
+	ReportStorageAll      ReportStorage = "all"
+	ReportStorageSample   ReportStorage = "sample"
+	ReportStorageFailures ReportStorage = "failures"
+	ReportStorageDiscard  ReportStorage = "discard"
+)
+
+func ParseReportStorage(value string) (ReportStorage, error) {
+	switch storage := ReportStorage(value); storage {
+	case ReportStorageAll, ReportStorageSample, ReportStorageFailures, ReportStorageDiscard:
+		return storage, nil
+	}
+	return "", fmt.Errorf("unknown report storage %q, expected all, sample, failures or discard", value)
+}
+
+// ReportCheck checks the content of the report of a session, an error of type *pdfcheck.Error marks the report invalid
+type ReportCheck func(sessionID string, data []byte) error
+
+// SetReportStorage sets which reports are saved, sampleRate is the fraction of the reports saved by the sample storage
+func (q *QuizAPI) SetReportStorage(storage ReportStorage, sampleRate float64) {
+	q.reportStorage = storage
+	q.reportSample = sampler.New(sampleRate)
+}
+
+// SetReportCheck sets a check of the content of every report, run after the pdf format is validated
+func (q *QuizAPI) SetReportCheck(check ReportCheck) {
+	q.reportCheck = check
+}
+
+// saveReportFile reports whether the next report is saved as it is received, the failures storage saves the invalid reports once checked
+func (q *QuizAPI) saveReportFile() bool {
+	switch q.reportStorage {
+	case ReportStorageSample:
+		return q.reportSample.Next()
+	case ReportStorageFailures, ReportStorageDiscard:
+		return false
+	}
+	return true
+}
+
+// keepReportData reports whether the content of the reports is kept in memory to be checked
+func (q *QuizAPI) keepReportData() bool {
+	return q.validateReports || q.reportCheck != nil
+}
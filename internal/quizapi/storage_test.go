@@ -0,0 +1,102 @@
+package quizapi
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/go-squad-5/quiz-load-test/internal/pdfcheck"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestReportQuizAPI(body string) *QuizAPI {
+	return NewTestQuizAPI("http://localhost:3000", "http://localhost:3001", func(req *http.Request) *http.Response {
+		return &http.Response{
+			StatusCode: 200,
+			Header:     http.Header{"Content-Type": []string{"application/pdf"}},
+			Body:       io.NopCloser(strings.NewReader(body)),
+		}
+	})
+}
+
+func Test_quizapi_storage_ParseReportStorage(t *testing.T) {
+	for _, value := range []string{"all", "sample", "failures", "discard"} {
+		storage, err := ParseReportStorage(value)
+		require.NoError(t, err, "Expected %s to be a report storage", value)
+		assert.Equal(t, ReportStorage(value), storage, "Expected the parsed report storage")
+	}
+
+	_, err := ParseReportStorage("none")
+	assert.Error(t, err, "Expected an error for an unknown report storage")
+}
+
+func Test_quizapi_storage_SaveReportFile(t *testing.T) {
+	q := NewTestQuizAPI("http://localhost:3000", "http://localhost:3001", nil)
+	assert.True(t, q.saveReportFile(), "Expected every report to be saved by default")
+
+	q.SetReportStorage(ReportStorageSample, 0.25)
+	saved := []bool{}
+	for range 8 {
+		saved = append(saved, q.saveReportFile())
+	}
+	assert.Equal(t, []bool{true, false, false, false, true, false, false, false}, saved, "Expected one report out of four to be saved")
+
+	q.SetReportStorage(ReportStorageDiscard, 0)
+	assert.False(t, q.saveReportFile(), "Expected the reports to be discarded")
+	q.SetReportStorage(ReportStorageFailures, 0)
+	assert.False(t, q.saveReportFile(), "Expected the reports to be saved only once found invalid")
+}
+
+func Test_quizapi_storage_GetReport_WhenDiscard(t *testing.T) {
+	reportsDirPath = "../../tmp/reports"
+	body := "%PDF-1.7 report %%EOF"
+	q := newTestReportQuizAPI(body)
+	q.SetReportStorage(ReportStorageDiscard, 0)
+	var observed []RequestInfo
+	q.SetObserver(func(info RequestInfo) {
+		observed = append(observed, info)
+	})
+
+	report, err := q.GetReport("discarded")
+	require.NoError(t, err, "Expected the report to be received")
+
+	hash := sha256.Sum256([]byte(body))
+	assert.Equal(t, "sha256:"+hex.EncodeToString(hash[:]), report, "Expected the hash of the discarded report")
+	_, err = os.Stat(fmt.Sprintf("%s/discarded_report.pdf", reportsDirPath))
+	assert.True(t, os.IsNotExist(err), "Expected the report not to be saved")
+	require.Len(t, observed, 1, "Expected the request to be observed")
+	assert.Equal(t, int64(len(body)), observed[0].Bytes, "Expected the bytes of the discarded report to be counted")
+}
+
+func Test_quizapi_storage_GetReport_WhenFailures(t *testing.T) {
+	reportsDirPath = "../../tmp/reports"
+	q := newTestReportQuizAPI("%PDF-1.7 (Session valid) %%EOF")
+	q.SetReportStorage(ReportStorageFailures, 0)
+	q.SetReportCheck(func(sessionID string, data []byte) error {
+		if !strings.Contains(string(data), sessionID) {
+			return &pdfcheck.Error{Problems: []string{"missing session"}}
+		}
+		return nil
+	})
+
+	report, err := q.GetReport("valid")
+	require.NoError(t, err, "Expected the report to be valid")
+	assert.True(t, strings.HasPrefix(report, "sha256:"), "Expected the valid report not to be saved")
+
+	report, err = q.GetReport("invalid")
+	defer os.Remove(report)
+	require.Error(t, err, "Expected the report check to fail")
+	var pdfErr *pdfcheck.Error
+	assert.True(t, errors.As(err, &pdfErr), "Expected a report correctness error")
+	assert.Equal(t, fmt.Sprintf("%s/invalid_report.pdf", reportsDirPath), report, "Expected the invalid report to be saved")
+	content, err := os.ReadFile(report)
+	require.NoError(t, err, "Expected the invalid report to be saved")
+	assert.Equal(t, "%PDF-1.7 (Session valid) %%EOF", string(content), "Expected the content of the invalid report")
+}
@@ -22,7 +22,10 @@ type SubmitQuizAPIResponse struct {
 	Score int `json:"score"`
 }
 
-func (q *QuizAPI) SubmitQuiz(sessionId string, answers []Answer) (int, error) {
+func (q *QuizAPI) SubmitQuiz(sessionId string, answers []Answer) (score int, err error) {
+	call := q.newCall(EndpointSubmitQuiz, sessionId, "")
+	defer func() { call.finish(err) }()
+
 	if err := validateSubmitQuizInputs(sessionId, answers); err != nil {
 		return 0, err
 	}
@@ -32,7 +35,7 @@ func (q *QuizAPI) SubmitQuiz(sessionId string, answers []Answer) (int, error) {
 		return 0, fmt.Errorf("failed to build request body: %w", err)
 	}
 
-	resp, err := q.client.Post(
+	resp, err := call.post(
 		q.endpoints.submitQuiz,
 		"application/json",
 		body,
@@ -43,10 +46,14 @@ func (q *QuizAPI) SubmitQuiz(sessionId string, answers []Answer) (int, error) {
 	defer resp.Body.Close()
 
 	if err := validateSubmitQuizAPIStatus(resp); err != nil {
-		return 0, err
+		return 0, newStatusError(resp, err)
 	}
 
-	return parseSubmitQuizAPIResponse(resp.Body)
+	respBody, err := q.checkContract(EndpointSubmitQuiz, resp.Body, checkSubmitQuizContract)
+	if err != nil {
+		return 0, err
+	}
+	return parseSubmitQuizAPIResponse(respBody)
 }
 
 func validateSubmitQuizInputs(sessionId string, answers []Answer) error {
@@ -0,0 +1,25 @@
+package sampler
+
+import (
+	"math"
+	"sync/atomic"
+)
+
+// Sampler picks a fraction of the items of a run, e.g. the sessions captured or the reports saved
+type Sampler struct {
+	rate  float64
+	count atomic.Int64
+}
+
+// New returns a sampler which picks the rate of the items, e.g. 0.01 for one item in 100
+func New(rate float64) *Sampler {
+	return &Sampler{rate: rate}
+}
+
+// Next reports whether the next item is picked, it is safe for concurrent use
+func (s *Sampler) Next() bool {
+	// the items are numbered from 1 and every item crossing the next multiple of 1/rate is picked,
+	// so the first item is picked and the picked items are evenly spread over the run
+	n := float64(s.count.Add(1))
+	return math.Ceil(n*s.rate) > math.Ceil((n-1)*s.rate)
+}
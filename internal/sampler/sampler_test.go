@@ -0,0 +1,22 @@
+package sampler
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_sampler_Sampler_Next(t *testing.T) {
+	s := New(0.25)
+	picked := []bool{}
+	for range 8 {
+		picked = append(picked, s.Next())
+	}
+	assert.Equal(t, []bool{true, false, false, false, true, false, false, false}, picked, "Expected the first item and every fourth item after it to be picked")
+
+	s = New(0)
+	for range 10 {
+		assert.False(t, s.Next(), "Expected no item picked with a zero rate")
+	}
+	assert.True(t, New(1).Next(), "Expected every item picked with a rate of one")
+}
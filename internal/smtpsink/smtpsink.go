@@ -0,0 +1,326 @@
+package smtpsink
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net"
+	"net/textproto"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Message is an email received by the sink
+type Message struct {
+	From       string
+	To         []string
+	Data       []byte
+	ReceivedAt time.Time
+}
+
+// Delivery is the email expected for a session, ReceivedAt is zero while the email is missing
+type Delivery struct {
+	SessionID  string
+	Email      string
+	AcceptedAt time.Time
+	ReceivedAt time.Time
+}
+
+func (d Delivery) Delivered() bool {
+	return !d.ReceivedAt.IsZero()
+}
+
+// Latency is the time from the accepted email request to the email received, zero while the email is missing
+func (d Delivery) Latency() time.Duration {
+	if !d.Delivered() {
+		return 0
+	}
+	return max(d.ReceivedAt.Sub(d.AcceptedAt), 0)
+}
+
+// Sink is an smtp server which accepts every email and records it, to check the emails requested from the report server are sent
+type Sink struct {
+	listener net.Listener
+	conns    sync.WaitGroup
+
+	mu       sync.Mutex
+	messages []Message
+	// index of the delivery matched to every message, -1 if not matched
+	matches    []int
+	deliveries []Delivery
+	// signalled when a message is received, to wait for the missing emails
+	received chan struct{}
+}
+
+// Listen starts the sink on the address, e.g. ":2525"
+func Listen(addr string) (*Sink, error) {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen on %s: %w", addr, err)
+	}
+	s := &Sink{listener: listener, received: make(chan struct{}, 1)}
+	go s.serve()
+	return s, nil
+}
+
+// Addr is the address the sink listens on
+func (s *Sink) Addr() string {
+	return s.listener.Addr().String()
+}
+
+// Close stops accepting emails and waits for the connections in progress
+func (s *Sink) Close() error {
+	err := s.listener.Close()
+	s.conns.Wait()
+	return err
+}
+
+// Expect records an email expected for the session, once the email request is accepted
+func (s *Sink) Expect(sessionID, email string, acceptedAt time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.deliveries = append(s.deliveries, Delivery{SessionID: sessionID, Email: email, AcceptedAt: acceptedAt})
+	// the email may be received before the response of the email request
+	for i := range s.messages {
+		if s.matches[i] < 0 && s.matchesSession(i, len(s.deliveries)-1) {
+			s.match(i, len(s.deliveries)-1)
+			break
+		}
+	}
+}
+
+// Wait waits until every expected email is received or the timeout expires, it reports whether no email is missing.
+// It is called once every email request is answered, the emails are matched as by Deliveries
+func (s *Sink) Wait(timeout time.Duration) bool {
+	deadline := time.NewTimer(timeout)
+	defer deadline.Stop()
+	for {
+		if s.missing() == 0 {
+			return true
+		}
+		select {
+		case <-s.received:
+		case <-deadline.C:
+			return s.missing() == 0
+		}
+	}
+}
+
+func (s *Sink) missing() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.matchByRecipient()
+	missing := 0
+	for _, delivery := range s.deliveries {
+		if !delivery.Delivered() {
+			missing++
+		}
+	}
+	return missing
+}
+
+// Deliveries returns the expected emails in the order they were accepted.
+// An email without its session ID, e.g. with the report as an attachment only, is matched to the oldest
+// missing email of its recipient accepted before it was received
+func (s *Sink) Deliveries() []Delivery {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.matchByRecipient()
+
+	deliveries := append([]Delivery{}, s.deliveries...)
+	sort.SliceStable(deliveries, func(i, j int) bool {
+		return deliveries[i].AcceptedAt.Before(deliveries[j].AcceptedAt)
+	})
+	return deliveries
+}
+
+// Unmatched is the number of emails received which match no session
+func (s *Sink) Unmatched() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	unmatched := 0
+	for _, delivery := range s.matches {
+		if delivery < 0 {
+			unmatched++
+		}
+	}
+	return unmatched
+}
+
+// Messages returns the emails received, in the order they were received
+func (s *Sink) Messages() []Message {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Message{}, s.messages...)
+}
+
+func (s *Sink) receive(message Message) {
+	s.mu.Lock()
+	s.messages = append(s.messages, message)
+	s.matches = append(s.matches, -1)
+	i := len(s.messages) - 1
+	for j := range s.deliveries {
+		if !s.deliveries[j].Delivered() && s.matchesSession(i, j) {
+			s.match(i, j)
+			break
+		}
+	}
+	s.mu.Unlock()
+
+	select {
+	case s.received <- struct{}{}:
+	default:
+	}
+}
+
+// matchByRecipient matches the emails without a session ID to the oldest missing email of their recipient accepted
+// before they were received. An email may be received before its session is expected, so it is only matched once every
+// email request is answered. It should be called with the lock held
+func (s *Sink) matchByRecipient() {
+	for i := range s.messages {
+		if s.matches[i] >= 0 || s.containsSessionID(i) {
+			continue
+		}
+		for j := range s.deliveries {
+			delivery := s.deliveries[j]
+			if !delivery.Delivered() && !delivery.AcceptedAt.After(s.messages[i].ReceivedAt) && s.sentTo(i, delivery.Email) {
+				s.match(i, j)
+				break
+			}
+		}
+	}
+}
+
+func (s *Sink) match(message, delivery int) {
+	s.matches[message] = delivery
+	s.deliveries[delivery].ReceivedAt = s.messages[message].ReceivedAt
+}
+
+func (s *Sink) matchesSession(message, delivery int) bool {
+	return s.sentTo(message, s.deliveries[delivery].Email) &&
+		containsID(s.messages[message].Data, s.deliveries[delivery].SessionID)
+}
+
+func (s *Sink) sentTo(message int, email string) bool {
+	for _, to := range s.messages[message].To {
+		if strings.EqualFold(to, email) {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *Sink) containsSessionID(message int) bool {
+	for _, delivery := range s.deliveries {
+		if containsID(s.messages[message].Data, delivery.SessionID) {
+			return true
+		}
+	}
+	return false
+}
+
+// containsID reports whether the id appears in the data as a whole word, so the session "s1" is not found in "s12"
+func containsID(data []byte, id string) bool {
+	isWordByte := func(b byte) bool {
+		return b == '-' || b == '_' || b >= '0' && b <= '9' || b >= 'a' && b <= 'z' || b >= 'A' && b <= 'Z'
+	}
+	for offset := 0; ; {
+		i := bytes.Index(data[offset:], []byte(id))
+		if i < 0 || id == "" {
+			return false
+		}
+		start, end := offset+i, offset+i+len(id)
+		if (start == 0 || !isWordByte(data[start-1])) && (end == len(data) || !isWordByte(data[end])) {
+			return true
+		}
+		offset = start + 1
+	}
+}
+
+func (s *Sink) serve() {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		s.conns.Add(1)
+		go func() {
+			defer s.conns.Done()
+			defer conn.Close()
+			s.handle(conn)
+		}()
+	}
+}
+
+// handle runs an smtp session, every command needed to send an email is accepted, authentication and tls are not supported
+func (s *Sink) handle(conn net.Conn) {
+	text := textproto.NewConn(conn)
+	reply := func(code int, message string) error {
+		return text.PrintfLine("%d %s", code, message)
+	}
+
+	if reply(220, "quiz-load-test smtp sink") != nil {
+		return
+	}
+	message := Message{}
+	for {
+		// a stalled client must not block Close
+		conn.SetReadDeadline(time.Now().Add(time.Minute))
+		line, err := text.ReadLine()
+		if err != nil {
+			return
+		}
+		command, arg, _ := strings.Cut(line, " ")
+		switch strings.ToUpper(command) {
+		case "HELO", "EHLO":
+			err = reply(250, "hello")
+		case "MAIL":
+			message = Message{From: parsePath(arg)}
+			err = reply(250, "ok")
+		case "RCPT":
+			message.To = append(message.To, parsePath(arg))
+			err = reply(250, "ok")
+		case "DATA":
+			if err = reply(354, "end data with <CR><LF>.<CR><LF>"); err != nil {
+				return
+			}
+			message.Data, err = io.ReadAll(text.DotReader())
+			if err != nil {
+				return
+			}
+			message.ReceivedAt = time.Now()
+			s.receive(message)
+			message = Message{}
+			err = reply(250, "ok")
+		case "RSET":
+			message = Message{}
+			err = reply(250, "ok")
+		case "NOOP":
+			err = reply(250, "ok")
+		case "QUIT":
+			reply(221, "bye")
+			return
+		default:
+			err = reply(502, "command not implemented")
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+// parsePath returns the address of a "FROM:<address>" or "TO:<address>" argument, without its parameters
+func parsePath(arg string) string {
+	_, path, found := strings.Cut(arg, ":")
+	if !found {
+		return ""
+	}
+	path = strings.TrimSpace(path)
+	if start, end := strings.Index(path, "<"), strings.Index(path, ">"); start >= 0 && end > start {
+		return path[start+1 : end]
+	}
+	address, _, _ := strings.Cut(path, " ")
+	return address
+}
@@ -0,0 +1,98 @@
+package smtpsink
+
+import (
+	"net/smtp"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func sendTestEmail(t *testing.T, sink *Sink, to, body string) {
+	message := "Subject: Quiz report\r\n\r\n" + body + "\r\n.starts with a dot\r\n"
+	err := smtp.SendMail(sink.Addr(), nil, "reports@example.com", []string{to}, []byte(message))
+	require.NoError(t, err, "Expected the email to be accepted by the sink")
+}
+
+func Test_smtpsink_Listen(t *testing.T) {
+	sink, err := Listen("127.0.0.1:0")
+	require.NoError(t, err, "Expected the sink to listen")
+	defer sink.Close()
+
+	sendTestEmail(t, sink, "user@example.com", "Report of the session s1")
+
+	messages := sink.Messages()
+	require.Len(t, messages, 1, "Expected the email to be recorded")
+	assert.Equal(t, "reports@example.com", messages[0].From, "Expected the sender of the email")
+	assert.Equal(t, []string{"user@example.com"}, messages[0].To, "Expected the recipients of the email")
+	assert.Contains(t, string(messages[0].Data), "Report of the session s1", "Expected the content of the email")
+	assert.Contains(t, string(messages[0].Data), "\n.starts with a dot", "Expected the dot stuffing to be removed")
+}
+
+func Test_smtpsink_Deliveries(t *testing.T) {
+	sink, err := Listen("127.0.0.1:0")
+	require.NoError(t, err, "Expected the sink to listen")
+	defer sink.Close()
+
+	accepted := time.Now()
+	sink.Expect("s1", "user@example.com", accepted)
+	sink.Expect("s12", "user@example.com", accepted)
+	sink.Expect("s3", "other@example.com", accepted)
+	sink.Expect("s4", "missing@example.com", accepted)
+
+	sendTestEmail(t, sink, "user@example.com", "Report of the session s12")
+	sendTestEmail(t, sink, "USER@example.com", "Report of the session s1")
+	// without its session ID the email is matched by its recipient
+	sendTestEmail(t, sink, "other@example.com", "Your quiz report is attached")
+	sendTestEmail(t, sink, "spam@example.com", "Unrelated")
+
+	assert.False(t, sink.Wait(50*time.Millisecond), "Expected an email to be missing")
+
+	deliveries := sink.Deliveries()
+	require.Len(t, deliveries, 4, "Expected every expected email")
+	for _, delivery := range deliveries[:3] {
+		assert.True(t, delivery.Delivered(), "Expected the email of %s to be delivered", delivery.SessionID)
+		assert.Greater(t, delivery.Latency(), time.Duration(0), "Expected the delivery latency of %s", delivery.SessionID)
+	}
+	assert.False(t, deliveries[3].Delivered(), "Expected the email of s4 to be missing")
+	assert.Equal(t, time.Duration(0), deliveries[3].Latency(), "Expected no latency for a missing email")
+	assert.Equal(t, 1, sink.Unmatched(), "Expected the unrelated email to be unmatched")
+}
+
+func Test_smtpsink_Wait(t *testing.T) {
+	sink, err := Listen("127.0.0.1:0")
+	require.NoError(t, err, "Expected the sink to listen")
+	defer sink.Close()
+
+	// the email may be received before the email request is accepted
+	sendTestEmail(t, sink, "user@example.com", "Report of the session s1")
+	sink.Expect("s1", "user@example.com", time.Now())
+	sink.Expect("s2", "user@example.com", time.Now())
+
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		sendTestEmail(t, sink, "user@example.com", "Report of the session s2")
+	}()
+	assert.True(t, sink.Wait(5*time.Second), "Expected every email to be received")
+	assert.Equal(t, time.Duration(0), sink.Deliveries()[0].Latency(), "Expected no negative latency for an email received early")
+}
+
+func Test_smtpsink_Wait_WhenNoSessionID(t *testing.T) {
+	sink, err := Listen("127.0.0.1:0")
+	require.NoError(t, err, "Expected the sink to listen")
+	defer sink.Close()
+
+	sink.Expect("s1", "user@example.com", time.Now())
+	sendTestEmail(t, sink, "user@example.com", "Your quiz report is attached")
+
+	start := time.Now()
+	assert.True(t, sink.Wait(5*time.Second), "Expected the email without its session ID to be matched by its recipient")
+	assert.Less(t, time.Since(start), time.Second, "Expected not to wait for the timeout")
+}
+
+func Test_smtpsink_ParsePath(t *testing.T) {
+	assert.Equal(t, "user@example.com", parsePath("FROM:<user@example.com> SIZE=100"), "Expected the address without parameters")
+	assert.Equal(t, "user@example.com", parsePath("TO: user@example.com"), "Expected the address without brackets")
+	assert.Equal(t, "", parsePath("user@example.com"), "Expected no address without a path")
+}
@@ -0,0 +1,253 @@
+package thresholds
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/go-squad-5/quiz-load-test/internal/metrics"
+)
+
+type kind int
+
+const (
+	kindLatency kind = iota
+	kindRate
+	kindCount
+	kindThroughput
+)
+
+var metricKinds map[string]kind = map[string]kind{
+	"p50":                  kindLatency,
+	"p90":                  kindLatency,
+	"p95":                  kindLatency,
+	"p99":                  kindLatency,
+	"mean":                 kindLatency,
+	"max":                  kindLatency,
+	"error_rate":           kindRate,
+	"session_failure_rate": kindRate,
+	"requests":             kindCount,
+	"failed_sessions":      kindCount,
+	"rps":                  kindThroughput,
+}
+
+// metrics which are only measured for the whole run
+var sessionMetrics map[string]bool = map[string]bool{
+	"session_failure_rate": true,
+	"failed_sessions":      true,
+}
+
+var expressionRegexp = regexp.MustCompile(`^(?:([a-z_]+)\.)?([a-z0-9_]+)\s*(<=|>=|==|!=|<|>)\s*([0-9]*\.?[0-9]+)\s*(ms|s|%)?$`)
+
+// Threshold is a condition on a run metric, e.g. "p95<500ms" or "submit_quiz.error_rate<1%".
+// Latency metrics are in milliseconds and rates are fractions.
+type Threshold struct {
+	Expression string
+	Endpoint   string
+	Metric     string
+	Operator   string
+	Value      float64
+}
+
+// Parse parses a comma separated list of threshold expressions
+func Parse(expressions string) ([]Threshold, error) {
+	thresholds := []Threshold{}
+	for _, expression := range strings.Split(expressions, ",") {
+		expression = strings.TrimSpace(expression)
+		if expression == "" {
+			continue
+		}
+		threshold, err := ParseThreshold(expression)
+		if err != nil {
+			return nil, err
+		}
+		thresholds = append(thresholds, threshold)
+	}
+	return thresholds, nil
+}
+
+func ParseThreshold(expression string) (Threshold, error) {
+	matches := expressionRegexp.FindStringSubmatch(strings.TrimSpace(expression))
+	if matches == nil {
+		return Threshold{}, fmt.Errorf("invalid threshold %q, expected [endpoint.]metric<op>value, e.g. p95<500ms", expression)
+	}
+	endpoint, metric, operator, number, unit := matches[1], matches[2], matches[3], matches[4], matches[5]
+
+	k, ok := metricKinds[metric]
+	if !ok {
+		return Threshold{}, fmt.Errorf("invalid threshold %q, unknown metric %q", expression, metric)
+	}
+	if endpoint != "" && sessionMetrics[metric] {
+		return Threshold{}, fmt.Errorf("invalid threshold %q, %s can't be measured per endpoint", expression, metric)
+	}
+
+	value, err := strconv.ParseFloat(number, 64)
+	if err != nil {
+		return Threshold{}, fmt.Errorf("invalid threshold %q: %w", expression, err)
+	}
+
+	switch {
+	case k == kindLatency && unit == "s":
+		value *= 1000
+	case k == kindLatency && (unit == "ms" || unit == ""):
+	case k == kindRate && unit == "%":
+		value /= 100
+	case k == kindRate && unit == "":
+	case (k == kindCount || k == kindThroughput) && unit == "":
+	default:
+		return Threshold{}, fmt.Errorf("invalid threshold %q, unit %q can't be used with %s", expression, unit, metric)
+	}
+
+	return Threshold{
+		Expression: strings.TrimSpace(expression),
+		Endpoint:   endpoint,
+		Metric:     metric,
+		Operator:   operator,
+		Value:      value,
+	}, nil
+}
+
+func (t Threshold) String() string {
+	return t.Expression
+}
+
+// Actual returns the value of the threshold metric in the run.
+// Latency metrics without an endpoint are the worst value across all the endpoints.
+func (t Threshold) Actual(summary *metrics.Summary) (float64, error) {
+	switch t.Metric {
+	case "session_failure_rate":
+		if summary.Sessions.Total == 0 {
+			return 0, nil
+		}
+		return float64(summary.Sessions.Failed) / float64(summary.Sessions.Total), nil
+	case "failed_sessions":
+		return float64(summary.Sessions.Failed), nil
+	}
+
+	if t.Endpoint == "" {
+		switch t.Metric {
+		case "error_rate":
+			return summary.ErrorRate, nil
+		case "requests":
+			return float64(summary.Requests), nil
+		case "rps":
+			return summary.RPS, nil
+		}
+		worst := 0.0
+		for _, stats := range summary.Endpoints {
+			worst = max(worst, endpointValue(stats, t.Metric))
+		}
+		return worst, nil
+	}
+
+	for _, stats := range summary.Endpoints {
+		if stats.Endpoint == t.Endpoint {
+			return endpointValue(stats, t.Metric), nil
+		}
+	}
+	return 0, fmt.Errorf("no requests recorded for endpoint %s", t.Endpoint)
+}
+
+func endpointValue(stats metrics.EndpointStats, metric string) float64 {
+	switch metric {
+	case "p50":
+		return stats.P50
+	case "p90":
+		return stats.P90
+	case "p95":
+		return stats.P95
+	case "p99":
+		return stats.P99
+	case "mean":
+		return stats.Mean
+	case "max":
+		return stats.Max
+	case "error_rate":
+		if stats.Count == 0 {
+			return 0
+		}
+		return float64(stats.Errors) / float64(stats.Count)
+	case "requests":
+		return float64(stats.Count)
+	case "rps":
+		return stats.RPS
+	}
+	return 0
+}
+
+// Passes reports whether the actual value satisfies the threshold
+func (t Threshold) Passes(actual float64) bool {
+	switch t.Operator {
+	case "<":
+		return actual < t.Value
+	case "<=":
+		return actual <= t.Value
+	case ">":
+		return actual > t.Value
+	case ">=":
+		return actual >= t.Value
+	case "==":
+		return actual == t.Value
+	case "!=":
+		return actual != t.Value
+	}
+	return false
+}
+
+// FormatValue formats a value of the threshold metric with its unit
+func (t Threshold) FormatValue(value float64) string {
+	switch metricKinds[t.Metric] {
+	case kindLatency:
+		return strconv.FormatFloat(value, 'f', 1, 64) + "ms"
+	case kindRate:
+		return strconv.FormatFloat(value*100, 'f', 2, 64) + "%"
+	case kindThroughput:
+		return strconv.FormatFloat(value, 'f', 2, 64) + "/s"
+	}
+	return strconv.FormatFloat(value, 'f', 0, 64)
+}
+
+// Check is the result of a threshold evaluated against a run
+type Check struct {
+	Threshold Threshold
+	Actual    float64
+	Passed    bool
+	Err       error
+}
+
+func (c Check) Message() string {
+	if c.Err != nil {
+		return fmt.Sprintf("%s: %v", c.Threshold, c.Err)
+	}
+	status := "passed"
+	if !c.Passed {
+		status = "failed"
+	}
+	return fmt.Sprintf("%s %s, actual %s", c.Threshold, status, c.Threshold.FormatValue(c.Actual))
+}
+
+// Evaluate checks all the thresholds against the run summary
+func Evaluate(thresholds []Threshold, summary *metrics.Summary) []Check {
+	checks := make([]Check, 0, len(thresholds))
+	for _, threshold := range thresholds {
+		actual, err := threshold.Actual(summary)
+		checks = append(checks, Check{
+			Threshold: threshold,
+			Actual:    actual,
+			Passed:    err == nil && threshold.Passes(actual),
+			Err:       err,
+		})
+	}
+	return checks
+}
+
+// AllPassed reports whether none of the checks failed
+func AllPassed(checks []Check) bool {
+	for _, check := range checks {
+		if !check.Passed {
+			return false
+		}
+	}
+	return true
+}
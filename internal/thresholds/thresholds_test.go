@@ -0,0 +1,80 @@
+package thresholds
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/go-squad-5/quiz-load-test/internal/metrics"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_thresholds_Parse(t *testing.T) {
+	parsed, err := Parse("p95<500ms, error_rate<1%,submit_quiz.p99<=2s,rps>=10,")
+	require.NoError(t, err, "Expected valid thresholds to be parsed")
+	require.Len(t, parsed, 4, "Expected every threshold, ignoring empty entries")
+
+	assert.Equal(t, Threshold{Expression: "p95<500ms", Metric: "p95", Operator: "<", Value: 500}, parsed[0], "Expected latency in milliseconds")
+	assert.InDelta(t, 0.01, parsed[1].Value, 0.0001, "Expected the percentage as a fraction")
+	assert.Equal(t, "submit_quiz", parsed[2].Endpoint, "Expected the endpoint of the threshold")
+	assert.Equal(t, float64(2000), parsed[2].Value, "Expected seconds converted to milliseconds")
+	assert.Equal(t, ">=", parsed[3].Operator, "Expected the operator of the threshold")
+}
+
+func Test_thresholds_Parse_WhenEmpty(t *testing.T) {
+	parsed, err := Parse("")
+	require.NoError(t, err, "Expected no error without thresholds")
+	assert.Empty(t, parsed, "Expected no thresholds")
+}
+
+func Test_thresholds_Parse_WhenInvalid(t *testing.T) {
+	for _, expression := range []string{"p95", "p95<fast", "p42<1s", "rps<5ms", "p95<5%", "start_quiz.failed_sessions<1"} {
+		_, err := Parse(expression)
+		assert.Errorf(t, err, "Expected %q to be invalid", expression)
+	}
+}
+
+func testSummary() *metrics.Summary {
+	return &metrics.Summary{
+		Sessions:  metrics.SessionCounts{Total: 10, Completed: 9, Failed: 1},
+		Requests:  100,
+		Errors:    2,
+		RPS:       20,
+		ErrorRate: 0.02,
+		Endpoints: []metrics.EndpointStats{
+			{Endpoint: "start_quiz", Count: 50, Errors: 0, P95: 120},
+			{Endpoint: "submit_quiz", Count: 50, Errors: 2, P95: 480},
+		},
+	}
+}
+
+func Test_thresholds_Evaluate(t *testing.T) {
+	parsed, err := Parse("p95<400ms,start_quiz.p95<400ms,error_rate<5%,submit_quiz.error_rate<=4%,session_failure_rate<10%,failed_sessions==0,get_report.p95<1s")
+	require.NoError(t, err, "Expected valid thresholds to be parsed")
+
+	checks := Evaluate(parsed, testSummary())
+	require.Len(t, checks, 7, "Expected a check for every threshold")
+
+	assert.False(t, checks[0].Passed, "Expected the worst endpoint p95 to fail")
+	assert.Equal(t, float64(480), checks[0].Actual, "Expected the worst endpoint p95")
+	assert.True(t, checks[1].Passed, "Expected the start_quiz p95 to pass")
+	assert.True(t, checks[2].Passed, "Expected the run error rate to pass")
+	assert.True(t, checks[3].Passed, "Expected the submit_quiz error rate to pass")
+	assert.InDelta(t, 0.04, checks[3].Actual, 0.0001, "Expected the submit_quiz error rate")
+	assert.False(t, checks[4].Passed, "Expected the session failure rate to fail")
+	assert.False(t, checks[5].Passed, "Expected the failed sessions to fail")
+	assert.False(t, checks[6].Passed, "Expected a threshold on an endpoint without requests to fail")
+	assert.Error(t, checks[6].Err, "Expected an error for an endpoint without requests")
+
+	assert.False(t, AllPassed(checks), "Expected the run to fail")
+	assert.True(t, AllPassed(checks[1:4]), "Expected the passing checks to pass")
+}
+
+func Test_thresholds_Check_Message(t *testing.T) {
+	threshold, err := ParseThreshold("error_rate<1%")
+	require.NoError(t, err, "Expected a valid threshold")
+
+	assert.Equal(t, "error_rate<1% failed, actual 2.00%", Check{Threshold: threshold, Actual: 0.02}.Message(), "Expected the actual value as a percentage")
+	assert.Equal(t, "error_rate<1% passed, actual 0.50%", Check{Threshold: threshold, Actual: 0.005, Passed: true}.Message(), "Expected a passed message")
+	assert.Equal(t, "error_rate<1%: no data", Check{Threshold: threshold, Err: errors.New("no data")}.Message(), "Expected the error message")
+}